@@ -14,6 +14,8 @@ import (
 const (
 	API_BASE = "https://fullnode.mainnet.aptoslabs.com/v1/"
 	LIBNAME  = "aptoslabs"
+	// APT_COIN_STORE is the account resource holding the APT balance
+	APT_COIN_STORE = "0x1::coin::CoinStore<0x1::aptos_coin::AptosCoin>"
 )
 
 func init() {
@@ -64,7 +66,7 @@ func (a *aptExplorer) VerifyByAddress(req blockexplorer.AddressVerifyRequest) (v
 			}
 		}
 	}
-	return nil, fmt.Errorf("tx not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "deposit")
 }
 
 func (a *aptExplorer) blockchainInfo() (*Blockchain, error) {
@@ -77,6 +79,34 @@ func (a *aptExplorer) blockchainInfo() (*Blockchain, error) {
 	return &b, err
 }
 
+// GetChainTip returns the latest block, aptos timestamps are in microseconds.
+func (a *aptExplorer) GetChainTip() (*blockexplorer.ChainTip, error) {
+	blockchain, err := a.blockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	block, err := a.getBlockByHeight(blockchain.BlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{
+		Height: block.BlockHeight,
+		Hash:   block.BlockHash,
+		Time:   block.BlockTimestamp / 1e6,
+	}, nil
+}
+
+// confirmations returns the confirmations of a transaction mined at
+// blockHeight, only the height of the chain is requested.
+func (a *aptExplorer) confirmations(blockHeight int) (int, error) {
+	blockchain, err := a.blockchainInfo()
+	if err != nil {
+		return 0, err
+	}
+	tip := blockexplorer.ChainTip{Height: blockchain.BlockHeight}
+	return tip.Confirmations(blockHeight), nil
+}
+
 func (a *aptExplorer) getTxByHash(hash string) (*Transaction, error) {
 	r, err := a.client.Do("GET", fmt.Sprintf("transactions/by_hash/%s", hash), "", false)
 	if err != nil {
@@ -113,9 +143,9 @@ func (a *aptExplorer) GetTransaction(txId string) (tx *blockexplorer.ITransactio
 	if block != nil {
 		blockHeight = block.BlockHeight
 	}
-	blockchain, _ := a.blockchainInfo()
-	if blockchain != nil {
-		confirmations = blockchain.BlockHeight - blockHeight
+	confirmations, err = a.confirmations(blockHeight)
+	if err != nil {
+		return nil, err
 	}
 	vIns, vOuts := aptTx.getInOutPuts()
 	return &blockexplorer.ITransaction{
@@ -187,11 +217,14 @@ func (a *aptExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest)
 		return nil, err
 	}
 	tx.Hash = aptTx.Hash
-	block, _ := a.getBlockByVersion(aptTx.Version)
+	block, err := a.getBlockByVersion(aptTx.Version)
+	if err != nil {
+		return nil, err
+	}
 	tx.BlockHeight = block.BlockHeight
-	blockchain, _ := a.blockchainInfo()
-	if blockchain != nil {
-		tx.Confirmations = blockchain.BlockHeight - tx.BlockHeight
+	tx.Confirmations, err = a.confirmations(tx.BlockHeight)
+	if err != nil {
+		return nil, err
 	}
 	for _, event := range aptTx.Events {
 		if event.Guid.AccountAddress == verifier.Address {
@@ -213,6 +246,23 @@ func (a *aptExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest)
 	return
 }
 
+// GetAddressBalance returns the APT balance of the account, the transactions
+// of aptos are final once executed so there is no unconfirmed balance.
+func (a *aptExplorer) GetAddressBalance(address string, viewKey string) (*blockexplorer.AddressBalance, error) {
+	r, err := a.client.Do("GET", fmt.Sprintf("accounts/%s/resource/%s", address, APT_COIN_STORE), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var store CoinStore
+	if err = parseResponseData(r, &store); err != nil {
+		return nil, err
+	}
+	return &blockexplorer.AddressBalance{
+		Address:   address,
+		Confirmed: idaemon.Amount(store.Data.Coin.Value),
+	}, nil
+}
+
 func (a *aptExplorer) PushTx(rawTxHash string) (result string, err error) {
 	return "", fmt.Errorf("%s:not supported", LIBNAME)
 }
@@ -226,3 +276,18 @@ func (a *aptExplorer) getBlockByVersion(version string) (*BlockInfo, error) {
 	err = parseResponseData(r, &b)
 	return &b, err
 }
+
+func (a *aptExplorer) getBlockByHeight(height int) (*BlockInfo, error) {
+	r, err := a.client.Do("GET", fmt.Sprintf("blocks/by_height/%d", height), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var b BlockInfo
+	err = parseResponseData(r, &b)
+	return &b, err
+}
+
+// GetUTXOs is not supported, aptos is account based
+func (a *aptExplorer) GetUTXOs(address string) ([]blockexplorer.UTXO, error) {
+	return nil, blockexplorer.NotSupportedError(LIBNAME, "GetUTXOs")
+}
@@ -119,6 +119,16 @@ type Blockchain struct {
 	GitHash             string `json:"git_hash"`
 }
 
+type CoinStore struct {
+	Type string `json:"type"`
+	Data struct {
+		Coin struct {
+			Value int64 `json:"value,string"`
+		} `json:"coin"`
+		Frozen bool `json:"frozen"`
+	} `json:"data"`
+}
+
 type BlockInfo struct {
 	BlockHeight    int    `json:"block_height,string"`
 	BlockHash      string `json:"block_hash"`
@@ -0,0 +1,90 @@
+package blockexplorer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
+)
+
+// amountDecimals is the precision of idaemon.Amount.
+const amountDecimals = 8
+
+// AddressBalance is the balance of an address. Unconfirmed is the balance of
+// the transactions which are not mined yet, it can be negative when they
+// spend from the address.
+type AddressBalance struct {
+	Address     string
+	Confirmed   idaemon.Amount
+	Unconfirmed idaemon.Amount
+	// Token is the balance of a token, or of a coin with more decimals than
+	// idaemon.Amount, in its own precision. Confirmed and Unconfirmed are
+	// then truncated.
+	Token *TokenBalance
+}
+
+// Total returns the confirmed and unconfirmed balance.
+func (b *AddressBalance) Total() idaemon.Amount {
+	return b.Confirmed + b.Unconfirmed
+}
+
+// TokenBalance is the balance of an address in the precision of a token,
+// Contract is empty for the coin of the chain.
+type TokenBalance struct {
+	Symbol      string
+	Contract    string
+	Confirmed   TokenAmount
+	Unconfirmed TokenAmount
+}
+
+// TokenAmount is an amount in the smallest unit of a token which has Decimals
+// decimals.
+type TokenAmount struct {
+	Value    *big.Int
+	Decimals int
+}
+
+// NewTokenAmount parses value, an integer in the smallest unit of the token.
+func NewTokenAmount(value string, decimals int) (TokenAmount, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return TokenAmount{}, fmt.Errorf("invalid token amount: %s", value)
+	}
+	return TokenAmount{Value: v, Decimals: decimals}, nil
+}
+
+// Amount returns the amount truncated to the precision of idaemon.Amount.
+func (a TokenAmount) Amount() idaemon.Amount {
+	if a.Value == nil {
+		return 0
+	}
+	v := new(big.Int).Set(a.Value)
+	if a.Decimals > amountDecimals {
+		v.Quo(v, pow10(a.Decimals-amountDecimals))
+	} else {
+		v.Mul(v, pow10(amountDecimals-a.Decimals))
+	}
+	return idaemon.Amount(v.Int64())
+}
+
+func (a TokenAmount) String() string {
+	if a.Value == nil {
+		return "0"
+	}
+	return new(big.Rat).SetFrac(a.Value, pow10(a.Decimals)).FloatString(a.Decimals)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// NewTokenBalance returns the balance of the token, its amounts are also
+// truncated to the precision of idaemon.Amount.
+func NewTokenBalance(address string, token TokenBalance) *AddressBalance {
+	return &AddressBalance{
+		Address:     address,
+		Confirmed:   token.Confirmed.Amount(),
+		Unconfirmed: token.Unconfirmed.Amount(),
+		Token:       &token,
+	}
+}
@@ -0,0 +1,37 @@
+package blockexplorer
+
+import (
+	"testing"
+
+	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
+)
+
+func TestTokenAmount(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		amount   idaemon.Amount
+		str      string
+	}{
+		{"1234567890123456789", 18, 123456789, "1.234567890123456789"},
+		{"1500000", 6, 150000000, "1.500000"},
+		{"0", 12, 0, "0.000000000000"},
+		{"-250000000000", 12, -25000000, "-0.250000000000"},
+		{"42", 0, 4200000000, "42"},
+	}
+	for _, test := range tests {
+		amount, err := NewTokenAmount(test.value, test.decimals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := amount.Amount(); got != test.amount {
+			t.Errorf("%s.Amount() = %d, expected: %d", test.value, got, test.amount)
+		}
+		if got := amount.String(); got != test.str {
+			t.Errorf("%s.String() = %s, expected: %s", test.value, got, test.str)
+		}
+	}
+	if _, err := NewTokenAmount("1.5", 18); err == nil {
+		t.Error("NewTokenAmount(1.5) succeeded, expected an error")
+	}
+}
@@ -3,6 +3,8 @@ package blockchair
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/vibros68/instantswap/blockexplorer"
 	"github.com/vibros68/instantswap/blockexplorer/global/clients/blockexplorerclient"
@@ -10,19 +12,41 @@ import (
 )
 
 const (
-	API_BASE = "https://api.blockchair.com"
-	LIBNAME  = "blockchair"
+	API_BASE  = "https://api.blockchair.com"
+	LIBNAME   = "blockchair"
+	UTXO_PAGE = 1000 // unspent outputs requested at once
 )
 
+// networks are the blockchair networks by symbol.
+var networks = map[string]string{
+	"BTC":  "bitcoin",
+	"DOGE": "dogecoin",
+	"LTC":  "litecoin",
+}
+
 func init() {
-	blockexplorer.RegisterExplorer("ZEC", "", func(conf blockexplorer.Config) (blockexplorer.IBlockExplorer, error) {
-		return New("zec", "zcash", conf), nil
+	blockexplorer.RegisterProvider("ZEC", "", blockexplorer.Provider{
+		Name: LIBNAME,
+		New: func(conf blockexplorer.Config) (blockexplorer.IBlockExplorer, error) {
+			return New("zec", "zcash", conf), nil
+		},
 	})
+	// blockchair is the fallback of the explorers of the other coins.
+	for symbol, network := range networks {
+		coinName, network := strings.ToLower(symbol), network
+		blockexplorer.RegisterProvider(symbol, "", blockexplorer.Provider{
+			Name:     LIBNAME,
+			Priority: 1,
+			New: func(conf blockexplorer.Config) (blockexplorer.IBlockExplorer, error) {
+				return New(coinName, network, conf), nil
+			},
+		})
+	}
 }
 
 // New return a ClockChair client
 func New(coinName, network string, conf blockexplorer.Config) *BlockChair {
-	apiBase := fmt.Sprintf("%s/%s/dashboards/", API_BASE, network)
+	apiBase := fmt.Sprintf("%s/%s/", API_BASE, network)
 	client := blockexplorerclient.NewClient(apiBase, LIBNAME, conf.EnableOutput, nil)
 	return &BlockChair{
 		client:   client,
@@ -46,7 +70,7 @@ func (b *BlockChair) VerifyByAddress(req blockexplorer.AddressVerifyRequest) (vr
 }
 
 func (b *BlockChair) getTx(txid string) (*TxWrapper, *Context, error) {
-	r, err := b.client.Do("GET", fmt.Sprintf("transaction/%s", txid), "", false)
+	r, err := b.client.Do("GET", fmt.Sprintf("dashboards/transaction/%s", txid), "", false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -56,12 +80,12 @@ func (b *BlockChair) getTx(txid string) (*TxWrapper, *Context, error) {
 		return nil, nil, err
 	}
 	if txWrapperMap == nil {
-		return nil, nil, fmt.Errorf("not found")
+		return nil, nil, blockexplorer.NotFoundError(LIBNAME, "tx")
 	}
 	if txWrapper, ok := txWrapperMap[txid]; ok {
 		return &txWrapper, ctx, nil
 	}
-	return nil, nil, fmt.Errorf("not found")
+	return nil, nil, blockexplorer.NotFoundError(LIBNAME, "tx")
 }
 
 func (b *BlockChair) GetTransaction(txid string) (tx *blockexplorer.ITransaction, err error) {
@@ -73,7 +97,7 @@ func (b *BlockChair) GetTransaction(txid string) (tx *blockexplorer.ITransaction
 }
 
 func (b *BlockChair) GetTxsForAddress(address string, limit int, viewKey string) (txs *blockexplorer.IRawAddrResponse, err error) {
-	r, err := b.client.Do("GET", fmt.Sprintf("address/%s?transaction_details=true&omni=true", address), "", false)
+	r, err := b.client.Do("GET", fmt.Sprintf("dashboards/address/%s?transaction_details=true&omni=true", address), "", false)
 	fmt.Println(string(r))
 	if err != nil {
 		return nil, err
@@ -84,12 +108,84 @@ func (b *BlockChair) GetTxsForAddress(address string, limit int, viewKey string)
 		return nil, err
 	}
 	if addrWrapperMap == nil {
-		return nil, fmt.Errorf("not found")
+		return nil, blockexplorer.NotFoundError(LIBNAME, "address")
 	}
 	if addrWrapper, ok := addrWrapperMap[address]; ok {
 		return b.generalAddr(address, &addrWrapper, ctx), nil
 	}
-	return nil, fmt.Errorf("not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "address")
+}
+
+// GetAddressBalance returns the balance of the address, blockchair does not
+// give the unconfirmed balance.
+func (b *BlockChair) GetAddressBalance(address string, viewKey string) (*blockexplorer.AddressBalance, error) {
+	r, err := b.client.Do("GET", fmt.Sprintf("dashboards/address/%s?limit=0", address), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var addrWrapperMap map[string]AddrWrapper
+	if _, err = parseData(r, &addrWrapperMap); err != nil {
+		return nil, err
+	}
+	addrWrapper, ok := addrWrapperMap[address]
+	if !ok {
+		return nil, blockexplorer.NotFoundError(LIBNAME, "address")
+	}
+	return &blockexplorer.AddressBalance{
+		Address:   address,
+		Confirmed: idaemon.Amount(addrWrapper.Address.Balance),
+	}, nil
+}
+
+// GetUTXOs returns the unspent outputs of the address, they all have the
+// script of the address. They are listed by pages of UTXO_PAGE outputs.
+func (b *BlockChair) GetUTXOs(address string) ([]blockexplorer.UTXO, error) {
+	utxos := []blockexplorer.UTXO{}
+	for offset := 0; ; offset += UTXO_PAGE {
+		r, err := b.client.Do("GET", fmt.Sprintf("dashboards/address/%s?limit=0,%d&offset=0,%d",
+			address, UTXO_PAGE, offset), "", false)
+		if err != nil {
+			return nil, err
+		}
+		var addrWrapperMap map[string]AddrWrapper
+		ctx, err := parseData(r, &addrWrapperMap)
+		if err != nil {
+			return nil, err
+		}
+		addrWrapper, ok := addrWrapperMap[address]
+		if !ok {
+			return nil, blockexplorer.NotFoundError(LIBNAME, "address")
+		}
+		for _, u := range addrWrapper.Utxo {
+			utxos = append(utxos, blockexplorer.UTXO{
+				TxID:          u.TransactionHash,
+				Vout:          u.Index,
+				Value:         idaemon.Amount(u.Value),
+				Script:        addrWrapper.Address.ScriptHex,
+				Confirmations: ctx.tip().Confirmations(u.BlockId),
+			})
+		}
+		if len(addrWrapper.Utxo) < UTXO_PAGE {
+			return utxos, nil
+		}
+	}
+}
+
+func (b *BlockChair) GetChainTip() (*blockexplorer.ChainTip, error) {
+	r, err := b.client.Do("GET", "stats", "", false)
+	if err != nil {
+		return nil, err
+	}
+	var stats Stats
+	if _, err = parseData(r, &stats); err != nil {
+		return nil, err
+	}
+	t, _ := time.Parse(timeFormat, stats.BestBlockTime)
+	return &blockexplorer.ChainTip{
+		Height: stats.BestBlockHeight,
+		Hash:   stats.BestBlockHash,
+		Time:   int(t.Unix()),
+	}, nil
 }
 
 func (b *BlockChair) PushTx(txhash string) (res string, err error) {
@@ -110,12 +206,15 @@ func (b *BlockChair) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (
 	for _, out := range tx.Outputs {
 		if out.Addresses[0] == verifier.Address {
 			tx.Seen = true
-			tx.Verified = true
+			tx.Verified = tx.Confirmations >= verifier.Confirms
 			tx.BlockExplorerAmount = out.Value
 			tx.MissingAmount = tx.OrderedAmount - tx.BlockExplorerAmount
 			tx.MissingPercent = tx.MissingAmount.ToCoin() / tx.OrderedAmount.ToCoin() * 100
 		}
 	}
+	if tx.Seen && !tx.Verified {
+		return tx, blockexplorer.NotConfirmedError(tx.Confirmations, verifier.Confirms)
+	}
 	return tx, nil
 }
 
@@ -139,6 +139,18 @@ type TxOutput struct {
 	Cdd                     float64     `json:"cdd"`
 }
 
+// tip returns the latest block of the response, its state.
+func (c *Context) tip() *blockexplorer.ChainTip {
+	return &blockexplorer.ChainTip{Height: c.State}
+}
+
+type Stats struct {
+	Blocks          int    `json:"blocks"`
+	BestBlockHeight int    `json:"best_block_height"`
+	BestBlockHash   string `json:"best_block_hash"`
+	BestBlockTime   string `json:"best_block_time"`
+}
+
 func (b *BlockChair) generalTx(txW *TxWrapper, ctx *Context) (tx *blockexplorer.ITransaction, err error) {
 	var t, _ = time.Parse(timeFormat, txW.Transaction.Time)
 	tx = &blockexplorer.ITransaction{
@@ -156,7 +168,7 @@ func (b *BlockChair) generalTx(txW *TxWrapper, ctx *Context) (tx *blockexplorer.
 		VinSz:         0,
 		VoutSz:        0,
 		Weight:        0,
-		Confirmations: ctx.State - txW.Transaction.BlockId + 1,
+		Confirmations: ctx.tip().Confirmations(txW.Transaction.BlockId),
 	}
 	for _, txIn := range txW.Inputs {
 		tx.Inputs = append(tx.Inputs, blockexplorer.IVIN{
@@ -242,7 +254,7 @@ func (b *BlockChair) generalAddr(address string, addr *AddrWrapper, ctx *Context
 			VinSz:         0,
 			VoutSz:        0,
 			Weight:        0,
-			Confirmations: ctx.State - tx.BlockId + 1,
+			Confirmations: ctx.tip().Confirmations(tx.BlockId),
 		})
 	}
 	return txs
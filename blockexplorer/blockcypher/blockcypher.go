@@ -3,6 +3,7 @@ package blockcypher
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/vibros68/instantswap/blockexplorer"
 	"github.com/vibros68/instantswap/blockexplorer/global/clients/blockexplorerclient"
@@ -10,8 +11,9 @@ import (
 )
 
 const (
-	API_BASE = "https://api.blockcypher.com/v1"
-	LIBNAME  = "blockcypher"
+	API_BASE      = "https://api.blockcypher.com/v1"
+	LIBNAME       = "blockcypher"
+	UNSPENT_LIMIT = 2000 // max unspent outputs of a page
 )
 
 func init() {
@@ -68,7 +70,7 @@ func (c *chainzCryptoid) VerifyByAddress(req blockexplorer.AddressVerifyRequest)
 			}, nil
 		}
 	}
-	return nil, fmt.Errorf("not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "deposit")
 }
 
 // GetTransaction returns decoded transaction from api
@@ -104,6 +106,85 @@ func (c *chainzCryptoid) GetTxsForAddress(address string, limit int, viewKey str
 	return addr.getIRawAddrResponse(c)
 }
 
+// GetChainTip returns the latest block of the chain
+func (c *chainzCryptoid) GetChainTip() (tip *blockexplorer.ChainTip, err error) {
+	r, err := c.client.Do("GET", "", "", false)
+	if err != nil {
+		return nil, err
+	}
+	var chain Chain
+	if err = parseData(r, &chain); err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{
+		Height: chain.Height,
+		Hash:   chain.Hash,
+		Time:   int(chain.Time.Unix()),
+	}, nil
+}
+
+// GetAddressBalance returns the balance of the address, eth balances are
+// also given in wei.
+func (c *chainzCryptoid) GetAddressBalance(address string, viewKey string) (balance *blockexplorer.AddressBalance, err error) {
+	r, err := c.client.Do("GET", fmt.Sprintf("addrs/%s/balance", address), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var addr AddressBalance
+	if err = parseData(r, &addr); err != nil {
+		return nil, err
+	}
+	var decimals = 8
+	if c.coinName == "eth" {
+		decimals = 18
+	}
+	var token = blockexplorer.TokenBalance{Symbol: strings.ToUpper(c.coinName)}
+	if token.Confirmed, err = blockexplorer.NewTokenAmount(addr.Balance.String(), decimals); err != nil {
+		return nil, err
+	}
+	if token.Unconfirmed, err = blockexplorer.NewTokenAmount(addr.UnconfirmedBalance.String(), decimals); err != nil {
+		return nil, err
+	}
+	balance = blockexplorer.NewTokenBalance(address, token)
+	if decimals == 8 {
+		balance.Token = nil
+	}
+	return balance, nil
+}
+
+// GetUTXOs returns the confirmed and unconfirmed unspent outputs of the
+// address, eth is account based. The lists of more than UNSPENT_LIMIT outputs
+// are an error.
+func (c *chainzCryptoid) GetUTXOs(address string) (utxos []blockexplorer.UTXO, err error) {
+	if c.coinName == "eth" {
+		return nil, blockexplorer.NotSupportedError(LIBNAME, "GetUTXOs of eth")
+	}
+	r, err := c.client.Do("GET", fmt.Sprintf("addrs/%s?unspentOnly=true&includeScript=true&limit=%d", address, UNSPENT_LIMIT), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var addr Address
+	if err = parseData(r, &addr); err != nil {
+		return nil, err
+	}
+	// the next pages are requested by block height, the outputs of a block
+	// split between two pages can not be listed.
+	if addr.HasMore {
+		return nil, fmt.Errorf("%s:error: %w, the address has more than %d", LIBNAME, blockexplorer.ErrTooManyUTXOs, UNSPENT_LIMIT)
+	}
+	utxos = []blockexplorer.UTXO{}
+	for _, tx := range append(addr.Txrefs, addr.UnconfirmedTxrefs...) {
+		utxos = append(utxos, blockexplorer.UTXO{
+			TxID:          tx.TxHash,
+			Vout:          tx.TxOutputN,
+			Value:         idaemon.Amount(tx.Value),
+			Script:        tx.Script,
+			Confirmations: tx.Confirmations,
+		})
+	}
+	return utxos, nil
+}
+
 // PushTx
 func (c *chainzCryptoid) PushTx(txhash string) (res string, err error) {
 	return "", fmt.Errorf("ltc is not support PushTx yet")
@@ -113,11 +194,11 @@ func (c *chainzCryptoid) PushTx(txhash string) (res string, err error) {
 func (c *chainzCryptoid) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (tx *blockexplorer.ITransaction, err error) {
 	tx = new(blockexplorer.ITransaction)
 	if verifier.Address == "" {
-		err = fmt.Errorf("%s:error: address is blank so tx cannot be verified", LIBNAME)
+		err = blockexplorer.InvalidRequestError(LIBNAME, "address is blank so tx cannot be verified")
 		return
 	}
 	if verifier.Amount == 0 {
-		err = fmt.Errorf("%s:error: amount is %.8f so tx cannot be verified", LIBNAME, verifier.Amount)
+		err = blockexplorer.InvalidRequestError(LIBNAME, fmt.Sprintf("amount is %.8f so tx cannot be verified", verifier.Amount))
 		return
 	}
 
@@ -133,7 +214,7 @@ func (c *chainzCryptoid) VerifyTransaction(verifier blockexplorer.TxVerifyReques
 					//tx has been seen on block explorer but still only has 0 confirmations
 					if txInfo.Confirmations < verifier.Confirms {
 						tx.Seen = true
-						err = fmt.Errorf("seen, waiting for confirms (%v/%v)", txInfo.Confirmations, verifier.Confirms)
+						err = blockexplorer.NotConfirmedError(txInfo.Confirmations, verifier.Confirms)
 						return tx, err
 					}
 
@@ -179,7 +260,7 @@ func (c *chainzCryptoid) VerifyTransaction(verifier blockexplorer.TxVerifyReques
 						//tx has been seen on block explorer but still only has 0 confirmations
 						if u.Confirmations < verifier.Confirms {
 							tx.Seen = true
-							err = fmt.Errorf("seen, waiting for confirms (%v/%v)", u.Confirmations, verifier.Confirms)
+							err = blockexplorer.NotConfirmedError(u.Confirmations, verifier.Confirms)
 							return tx, err
 						}
 
@@ -1,6 +1,7 @@
 package blockcypher
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -16,6 +17,23 @@ func (err Err) Error() string {
 	return err.ErrorMsg
 }
 
+type Chain struct {
+	Name   string    `json:"name"`
+	Height int       `json:"height"`
+	Hash   string    `json:"hash"`
+	Time   time.Time `json:"time"`
+}
+
+// AddressBalance amounts are in wei for eth, they can overflow an int64.
+type AddressBalance struct {
+	Address            string      `json:"address"`
+	TotalReceived      json.Number `json:"total_received"`
+	TotalSent          json.Number `json:"total_sent"`
+	Balance            json.Number `json:"balance"`
+	UnconfirmedBalance json.Number `json:"unconfirmed_balance"`
+	FinalBalance       json.Number `json:"final_balance"`
+}
+
 type Tx struct {
 	BlockHash     string    `json:"block_hash"`
 	BlockHeight   int       `json:"block_height"`
@@ -61,7 +79,11 @@ type TxOuput struct {
 
 func (t *Tx) generalTx(c *chainzCryptoid) (tx *blockexplorer.ITransaction, err error) {
 	if t.Hash == "" {
-		return nil, fmt.Errorf("tx not found")
+		return nil, blockexplorer.NotFoundError(LIBNAME, "tx")
+	}
+	tip, err := c.GetChainTip()
+	if err != nil {
+		return nil, err
 	}
 	tx = &blockexplorer.ITransaction{
 		BlockHeight:         t.BlockHeight,
@@ -78,7 +100,7 @@ func (t *Tx) generalTx(c *chainzCryptoid) (tx *blockexplorer.ITransaction, err e
 		VinSz:               t.VinSz,
 		VoutSz:              t.VoutSz,
 		Weight:              0,
-		Confirmations:       t.Confirmations,
+		Confirmations:       tip.Confirmations(t.BlockHeight),
 		Seen:                true,
 		Verified:            true,
 		OrderedAmount:       0,
@@ -136,6 +158,7 @@ type Address struct {
 	UnconfirmedNTx     int         `json:"unconfirmed_n_tx"`
 	FinalNTx           int         `json:"final_n_tx"`
 	Txrefs             []CompactTx `json:"txrefs"`
+	UnconfirmedTxrefs  []CompactTx `json:"unconfirmed_txrefs"`
 	HasMore            bool        `json:"hasMore"`
 	TxUrl              string      `json:"tx_url"`
 }
@@ -152,6 +175,7 @@ type CompactTx struct {
 	DoubleSpend   bool      `json:"double_spend"`
 	Spent         bool      `json:"spent,omitempty"`
 	SpentBy       string    `json:"spent_by,omitempty"`
+	Script        string    `json:"script,omitempty"`
 }
 
 func (a *Address) getIRawAddrResponse(c *chainzCryptoid) (*blockexplorer.IRawAddrResponse, error) {
@@ -1,80 +1,185 @@
 package blockexplorer
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
+// ErrNotSupported is wrapped by the errors of the methods which an explorer
+// can not implement, such as GetUTXOs on the account based chains.
+var ErrNotSupported = errors.New("not supported")
+
+// NotSupportedError returns the error of a method which is not supported by
+// the explorer libName.
+func NotSupportedError(libName, method string) error {
+	return fmt.Errorf("%s:error: %s is %w", libName, method, ErrNotSupported)
+}
+
+// ErrNotConfirmed is wrapped by the errors of VerifyTransaction when the
+// transaction is seen with less confirmations than requested, the
+// transaction is returned with Seen set along with the error.
+var ErrNotConfirmed = errors.New("waiting for confirms")
+
+// NotConfirmedError returns the error of a transaction seen with
+// confirmations out of the confirms requested.
+func NotConfirmedError(confirmations, confirms int) error {
+	return fmt.Errorf("seen, %w (%v/%v)", ErrNotConfirmed, confirmations, confirms)
+}
+
+// ErrTooManyUTXOs is wrapped by the errors of GetUTXOs when the explorer can
+// not list all the unspent outputs of an address.
+var ErrTooManyUTXOs = errors.New("too many unspent outputs")
+
+// ErrNotFound is wrapped by the errors of an explorer which does not find the
+// transaction or the address requested, it is an answer rather than a failure
+// of the explorer.
+var ErrNotFound = errors.New("not found")
+
+// NotFoundError returns the error of what is not found by the explorer
+// libName.
+func NotFoundError(libName, what string) error {
+	return fmt.Errorf("%s:error: %s %w", libName, what, ErrNotFound)
+}
+
+// ErrInvalidRequest is wrapped by the errors of the requests which can not be
+// answered by any explorer, such as a verification without address.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// InvalidRequestError returns the error of a request rejected by the explorer
+// libName for reason.
+func InvalidRequestError(libName, reason string) error {
+	return fmt.Errorf("%s:error: %w, %s", libName, ErrInvalidRequest, reason)
+}
+
 type Config struct {
 	EnableOutput bool
 	Symbol       string
 	ApiKey       string
 	Type         NetworkType
+	// Contract is the address of the token contract of an erc20 Symbol,
+	// the token balances are found by it.
+	Contract string
+	// Timeout bounds the calls to each provider of a Failover, the provider
+	// clients time out after 30 seconds when it is zero.
+	Timeout time.Duration
+	// UnhealthyPeriod is how long a failed provider is tried after the
+	// healthy ones, DefaultUnhealthyPeriod when it is zero.
+	UnhealthyPeriod time.Duration
+}
+
+// Provider is an explorer registered for a symbol. The providers of a symbol
+// are tried by ascending Priority, then by registration order.
+type Provider struct {
+	Name     string
+	Priority int
+	New      NewExplorerFunc
 }
 
 var driv = driver{
 	mux:    new(sync.RWMutex),
-	stack:  make(map[string]NewExplorerFunc),
-	layer2: make(map[NetworkType]NewExplorerFunc),
+	stack:  make(map[string][]Provider),
+	layer2: make(map[NetworkType][]Provider),
 }
 
 type NewExplorerFunc func(conf Config) (IBlockExplorer, error)
 
 type driver struct {
 	mux    *sync.RWMutex
-	stack  map[string]NewExplorerFunc
-	layer2 map[NetworkType]NewExplorerFunc
+	stack  map[string][]Provider
+	layer2 map[NetworkType][]Provider
 }
 
-func (d *driver) registerExplorer(symbol string, networkType NetworkType, newExplorer NewExplorerFunc) {
+func (d *driver) registerProvider(symbol string, networkType NetworkType, provider Provider) {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 	if symbol != "" {
-		_, ok := d.stack[symbol]
-		if ok {
-			log.Panicf("[%s] explorer is registered", symbol)
-		}
-		d.stack[symbol] = newExplorer
+		d.stack[symbol] = addProvider(symbol, d.stack[symbol], provider)
+	} else if networkType != "" {
+		d.layer2[networkType] = addProvider(string(networkType), d.layer2[networkType], provider)
 	}
-	if networkType != "" {
-		if _, ok := d.stack[symbol]; !ok {
-			d.layer2[networkType] = newExplorer
+}
+
+func addProvider(key string, providers []Provider, provider Provider) []Provider {
+	for _, p := range providers {
+		if p.Name == provider.Name {
+			log.Panicf("[%s] %s explorer is registered", key, provider.Name)
 		}
 	}
+	providers = append(providers, provider)
+	sort.SliceStable(providers, func(i, j int) bool {
+		return providers[i].Priority < providers[j].Priority
+	})
+	return providers
 }
 
-func (d *driver) newExplorer(conf Config) (IBlockExplorer, error) {
-	d.mux.Lock()
-	defer d.mux.Unlock()
+// providers returns the key and the providers of the explorers of conf.
+func (d *driver) providers(conf Config) (string, []Provider, error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	var key string
+	var providers []Provider
 	if conf.Type == "" {
-		var symbol = strings.ToLower(conf.Symbol)
-		newExplorer, ok := d.stack[symbol]
-		if !ok {
-			return nil, fmt.Errorf("[%s] explorer is not available yet", symbol)
-		}
-		return newExplorer(conf)
+		key = strings.ToLower(conf.Symbol)
+		providers = d.stack[key]
 	} else {
-		newExplorer, ok := d.layer2[conf.Type]
-		if !ok {
-			return nil, fmt.Errorf("[%s] explorer is not available yet", conf.Type)
-		}
-		return newExplorer(conf)
+		key = string(conf.Type)
+		providers = d.layer2[conf.Type]
+	}
+	if len(providers) == 0 {
+		return key, nil, fmt.Errorf("[%s] explorer is not available yet", key)
+	}
+	return key, append([]Provider(nil), providers...), nil
+}
+
+func (d *driver) newExplorer(conf Config) (IBlockExplorer, error) {
+	key, providers, err := d.providers(conf)
+	if err != nil {
+		return nil, err
 	}
+	if len(providers) == 1 {
+		return providers[0].New(conf)
+	}
+	return newFailover(key, conf, providers)
 }
 
+// RegisterExplorer registers the only explorer of symbol, or of networkType
+// when symbol is empty.
 func RegisterExplorer(symbol string, networkType NetworkType, newDriver NewExplorerFunc) {
-	driv.registerExplorer(strings.ToLower(symbol), networkType, newDriver)
+	name := strings.ToLower(symbol)
+	if name == "" {
+		name = string(networkType)
+	}
+	RegisterProvider(symbol, networkType, Provider{Name: name, New: newDriver})
 }
 
+// RegisterProvider registers one of the explorers of symbol, or of
+// networkType when symbol is empty. It panics when a provider of the same
+// name is registered.
+func RegisterProvider(symbol string, networkType NetworkType, provider Provider) {
+	driv.registerProvider(strings.ToLower(symbol), networkType, provider)
+}
+
+// NewExplorer returns the explorer of conf.Symbol, or of conf.Type when it is
+// set. It is a Failover when several providers are registered.
 func NewExplorer(conf Config) (IBlockExplorer, error) {
-	if conf.Type == "" {
-		return driv.newExplorer(conf)
-	}
 	return driv.newExplorer(conf)
 }
 
+// Providers returns the names of the providers of symbol by priority.
+func Providers(symbol string) []string {
+	_, providers, _ := driv.providers(Config{Symbol: symbol})
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name
+	}
+	return names
+}
+
 type IBlockExplorer interface {
 	GetTransaction(txId string) (tx *ITransaction, err error)
 	GetTxsForAddress(address string, limit int, viewKey string) (tx *IRawAddrResponse, err error)
@@ -83,6 +188,15 @@ type IBlockExplorer interface {
 	VerifyByAddress(req AddressVerifyRequest) (vr *VerifyResult, err error)
 	//PushTx pushes a raw tx hash
 	PushTx(rawTxHash string) (result string, err error)
+	// GetChainTip returns the latest block, the confirmations of the
+	// transactions are computed from it.
+	GetChainTip() (tip *ChainTip, err error)
+	// GetAddressBalance returns the confirmed and unconfirmed balance of the
+	// address, viewKey is needed by the private coins.
+	GetAddressBalance(address string, viewKey string) (balance *AddressBalance, err error)
+	// GetUTXOs returns the unspent outputs of the address, the explorers of
+	// the account based chains return ErrNotSupported.
+	GetUTXOs(address string) (utxos []UTXO, err error)
 }
 
 type TxVerifyRequest struct {
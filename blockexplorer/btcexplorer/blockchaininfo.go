@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/vibros68/instantswap/blockexplorer"
 	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
@@ -21,6 +23,7 @@ const (
 	API_BASE                   = "https://blockchain.info/" //  API endpoint
 	DEFAULT_HTTPCLIENT_TIMEOUT = 30                         // HTTP client timeout
 	LIBNAME                    = "btcexplorer"
+	UNSPENT_LIMIT              = 1000 // max unspent outputs listed by blockchain.info
 )
 
 // New return a instanciate cryptopia struct
@@ -75,7 +78,7 @@ func (c *BlockChainInfo) VerifyByAddress(req blockexplorer.AddressVerifyRequest)
 			}
 		}
 	}
-	return nil, fmt.Errorf("not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "deposit")
 }
 
 // GetTransaction returns decoded transaction from api
@@ -90,13 +93,13 @@ func (c *BlockChainInfo) GetTransaction(txid string) (tx *blockexplorer.ITransac
 	}
 
 	//get latest block to get our confirmations
-	latestBlock, err := c.GetLatestBlock()
+	tip, err := c.GetChainTip()
 	if err != nil {
 		return
 	}
 
 	//confirmations for this tx
-	tmp.Confirmations = latestBlock.Height - tmp.BlockHeight
+	tmp.Confirmations = tip.Confirmations(tmp.BlockHeight)
 
 	tx = &blockexplorer.ITransaction{
 		Confirmations: tmp.Confirmations,
@@ -163,7 +166,7 @@ func (c *BlockChainInfo) GetTxsForAddress(address string, limit int, viewKey str
 	}
 
 	//get latest block to get our confirmations
-	latestBlock, err := c.GetLatestBlock()
+	tip, err := c.GetChainTip()
 	if err != nil {
 		return
 	}
@@ -193,7 +196,7 @@ func (c *BlockChainInfo) GetTxsForAddress(address string, limit int, viewKey str
 			VinSz:         v.VinSz,
 			VoutSz:        v.VoutSz,
 			Weight:        v.Weight,
-			Confirmations: latestBlock.Height - v.BlockHeight,
+			Confirmations: tip.Confirmations(v.BlockHeight),
 		}
 		var tmpInputs []blockexplorer.IRawAddrInput
 		for _, w := range v.Inputs {
@@ -265,12 +268,11 @@ func (c *BlockChainInfo) PushTx(txhash string) (res string, err error) {
 func (c *BlockChainInfo) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (tx *blockexplorer.ITransaction, err error) {
 	tx = new(blockexplorer.ITransaction)
 	if verifier.Address == "" {
-		err = errors.New(LIBNAME + ":error: address is blank so tx cannot be verified")
+		err = blockexplorer.InvalidRequestError(LIBNAME, "address is blank so tx cannot be verified")
 		return
 	}
 	if verifier.Amount == 0 {
-		errStr := fmt.Sprintf(LIBNAME+":error: amount is %.8f so tx cannot be verified", verifier.Amount)
-		err = errors.New(errStr)
+		err = blockexplorer.InvalidRequestError(LIBNAME, fmt.Sprintf("amount is %.8f so tx cannot be verified", verifier.Amount))
 		return
 	}
 
@@ -289,8 +291,7 @@ func (c *BlockChainInfo) VerifyTransaction(verifier blockexplorer.TxVerifyReques
 					//tx has been seen on block explorer but still only has 0 confirmations
 					if txInfo.Confirmations < verifier.Confirms {
 						tx.Seen = true
-						errStr := fmt.Sprintf("seen, waiting for confirms (%v/%v)", txInfo.Confirmations, verifier.Confirms)
-						err = errors.New(errStr)
+						err = blockexplorer.NotConfirmedError(txInfo.Confirmations, verifier.Confirms)
 						return tx, err
 					}
 
@@ -337,8 +338,7 @@ func (c *BlockChainInfo) VerifyTransaction(verifier blockexplorer.TxVerifyReques
 						//tx has been seen on block explorer but still only has 0 confirmations
 						if u.Confirmations < verifier.Confirms {
 							tx.Seen = true
-							errStr := fmt.Sprintf("seen, waiting for confirms (%v/%v)", u.Confirmations, verifier.Confirms)
-							err = errors.New(errStr)
+							err = blockexplorer.NotConfirmedError(u.Confirmations, verifier.Confirms)
 							return tx, err
 						}
 
@@ -433,3 +433,79 @@ func (c *BlockChainInfo) GetLatestBlock() (latestBlock LatestBlock, err error) {
 	//err = errors.New("test failure check error")
 	return
 }
+
+// GetChainTip returns the latest block.
+func (c *BlockChainInfo) GetChainTip() (tip *blockexplorer.ChainTip, err error) {
+	latestBlock, err := c.GetLatestBlock()
+	if err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{
+		Height: latestBlock.Height,
+		Hash:   latestBlock.Hash,
+		Time:   latestBlock.Time,
+	}, nil
+}
+
+// addressBalance returns the balance of the address with at least
+// confirmations, the api answers in plain text.
+func (c *BlockChainInfo) addressBalance(address string, confirmations int) (balance idaemon.Amount, err error) {
+	r, err := c.client.Do("GET", fmt.Sprintf("q/addressbalance/%s?confirmations=%d", address, confirmations), "", false)
+	if err != nil {
+		return
+	}
+	satoshis, err := strconv.ParseInt(strings.TrimSpace(string(r)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s:error: %s", LIBNAME, r)
+	}
+	return idaemon.Amount(satoshis), nil
+}
+
+// GetAddressBalance returns the balance of the address, the unconfirmed
+// balance is the difference with the balance of the mempool.
+func (c *BlockChainInfo) GetAddressBalance(address string, viewKey string) (balance *blockexplorer.AddressBalance, err error) {
+	confirmed, err := c.addressBalance(address, 1)
+	if err != nil {
+		return
+	}
+	total, err := c.addressBalance(address, 0)
+	if err != nil {
+		return
+	}
+	return &blockexplorer.AddressBalance{
+		Address:     address,
+		Confirmed:   confirmed,
+		Unconfirmed: total - confirmed,
+	}, nil
+}
+
+// GetUTXOs returns the unspent outputs of the address, blockchain.info
+// answers in plain text when there is none. It lists UNSPENT_LIMIT outputs
+// at most, without pagination, so the larger lists are an error.
+func (c *BlockChainInfo) GetUTXOs(address string) (utxos []blockexplorer.UTXO, err error) {
+	r, err := c.client.Do("GET", fmt.Sprintf("unspent?active=%s&limit=%d", address, UNSPENT_LIMIT), "", false)
+	if err != nil {
+		if strings.Contains(err.Error(), "No free outputs") {
+			return []blockexplorer.UTXO{}, nil
+		}
+		return
+	}
+	var unspent UnspentOutputs
+	if err = json.Unmarshal(r, &unspent); err != nil {
+		return
+	}
+	if len(unspent.UnspentOutputs) >= UNSPENT_LIMIT {
+		return nil, fmt.Errorf("%s:error: %w, the address has %d or more", LIBNAME, blockexplorer.ErrTooManyUTXOs, UNSPENT_LIMIT)
+	}
+	utxos = make([]blockexplorer.UTXO, len(unspent.UnspentOutputs))
+	for i, u := range unspent.UnspentOutputs {
+		utxos[i] = blockexplorer.UTXO{
+			TxID:          u.TxHashBigEndian,
+			Vout:          u.TxOutputN,
+			Value:         idaemon.Amount(u.Value),
+			Script:        u.Script,
+			Confirmations: u.Confirmations,
+		}
+	}
+	return utxos, nil
+}
@@ -59,6 +59,17 @@ type LatestBlock struct {
 	TxIndexes  []int  `json:"txIndexes"`
 }
 
+type UnspentOutputs struct {
+	UnspentOutputs []UnspentOutput `json:"unspent_outputs"`
+}
+type UnspentOutput struct {
+	TxHashBigEndian string `json:"tx_hash_big_endian"`
+	TxOutputN       int    `json:"tx_output_n"`
+	Script          string `json:"script"`
+	Value           int64  `json:"value"`
+	Confirmations   int    `json:"confirmations"`
+	TxIndex         int64  `json:"tx_index"`
+}
 type RawAddrResponse struct {
 	Address       string       `json:"address"`
 	FinalBalance  int          `json:"final_balance"`
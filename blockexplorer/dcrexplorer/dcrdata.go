@@ -14,6 +14,7 @@ const (
 	API_BASE                   = "https://explorer.dcrdata.org/api/" //  API endpoint
 	DEFAULT_HTTPCLIENT_TIMEOUT = 30                                  // HTTP client timeout
 	LIBNAME                    = "dcrdata"
+	INSIGHT_API_BASE           = "https://explorer.dcrdata.org/insight/api/" // insight API endpoint
 )
 
 func init() {
@@ -75,7 +76,7 @@ func (c *DCRData) VerifyByAddress(req blockexplorer.AddressVerifyRequest) (vr *b
 			}
 		}
 	}
-	return nil, fmt.Errorf("not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "deposit")
 }
 
 // GetTransaction returns decoded transaction from explorer.dcrdata.org/api
@@ -88,8 +89,12 @@ func (c *DCRData) GetTransaction(txid string) (tx *blockexplorer.ITransaction, e
 	if err = json.Unmarshal(r, &tmp); err != nil {
 		return
 	}
+	tip, err := c.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
 	tx = &blockexplorer.ITransaction{
-		Confirmations: tmp.Confirmations,
+		Confirmations: tip.Confirmations(tmp.Block.Blockheight),
 		BlockHeight:   tmp.Block.Blockheight,
 		//DoubleSpend: tmp.DoubleSpend,
 		Hash:     tmp.Txid,
@@ -235,6 +240,64 @@ func (c *DCRData) GetTxsForAddress(address string, limit int, viewKey string) (t
 	return
 }
 
+// GetChainTip returns the best block from explorer.dcrdata.org/api
+func (c *DCRData) GetChainTip() (tip *blockexplorer.ChainTip, err error) {
+	r, err := c.client.Do("GET", "block/best", "", false)
+	if err != nil {
+		return
+	}
+	var block BlockDataBasic
+	if err = json.Unmarshal(r, &block); err != nil {
+		return
+	}
+	return &blockexplorer.ChainTip{
+		Height: block.Height,
+		Hash:   block.Hash,
+		Time:   block.Time,
+	}, nil
+}
+
+// GetAddressBalance returns the balance of the address from the insight api
+func (c *DCRData) GetAddressBalance(address string, viewKey string) (balance *blockexplorer.AddressBalance, err error) {
+	r, err := c.client.Do("GET", fmt.Sprintf("%saddr/%s?noTxList=1", INSIGHT_API_BASE, address), "", false)
+	if err != nil {
+		return
+	}
+	var addr InsightAddress
+	if err = json.Unmarshal(r, &addr); err != nil {
+		return
+	}
+	return &blockexplorer.AddressBalance{
+		Address:     address,
+		Confirmed:   idaemon.Amount(addr.BalanceSat),
+		Unconfirmed: idaemon.Amount(addr.UnconfirmedBalanceSat),
+	}, nil
+}
+
+// GetUTXOs returns the unspent outputs of the address from the insight api
+func (c *DCRData) GetUTXOs(address string) (utxos []blockexplorer.UTXO, err error) {
+	r, err := c.client.Do("GET", fmt.Sprintf("%saddr/%s/utxo", INSIGHT_API_BASE, address), "", false)
+	if err != nil {
+		return
+	}
+	var unspent []InsightUTXO
+	if err = json.Unmarshal(r, &unspent); err != nil {
+		return
+	}
+	utxos = make([]blockexplorer.UTXO, len(unspent))
+	for i, u := range unspent {
+		utxos[i] = blockexplorer.UTXO{
+			TxID:          u.TxID,
+			Vout:          u.Vout,
+			Value:         idaemon.Amount(u.Satoshis),
+			Script:        u.ScriptPubKey,
+			Confirmations: u.Confirmations,
+			Tree:          u.tree(),
+		}
+	}
+	return utxos, nil
+}
+
 // PushTx pushed a raw tx hash to mainnet
 func (c *DCRData) PushTx(txhash string) (res string, err error) {
 	err = errors.New("dcrdata:error: pushtx is not available yet... ")
@@ -257,12 +320,11 @@ func (c *DCRData) PushTx(txhash string) (res string, err error) {
 func (c *DCRData) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (tx *blockexplorer.ITransaction, err error) {
 	tx = new(blockexplorer.ITransaction)
 	if verifier.Address == "" {
-		err = errors.New(LIBNAME + ":error: address is blank so tx cannot be verified")
+		err = blockexplorer.InvalidRequestError(LIBNAME, "address is blank so tx cannot be verified")
 		return
 	}
 	if verifier.Amount == 0 {
-		errStr := fmt.Sprintf(LIBNAME+":error: amount is %.8f so tx cannot be verified", verifier.Amount)
-		err = errors.New(errStr)
+		err = blockexplorer.InvalidRequestError(LIBNAME, fmt.Sprintf("amount is %.8f so tx cannot be verified", verifier.Amount))
 		return
 	}
 
@@ -283,8 +345,7 @@ func (c *DCRData) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (tx
 					//tx has been seen on block explorer but still only has 0 confirmations
 					if txInfo.Confirmations < verifier.Confirms {
 						tx.Seen = true
-						errStr := fmt.Sprintf("seen, waiting for confirms (%v/%v)", txInfo.Confirmations, verifier.Confirms)
-						err = errors.New(errStr)
+						err = blockexplorer.NotConfirmedError(txInfo.Confirmations, verifier.Confirms)
 						return tx, err
 					}
 
@@ -334,7 +395,7 @@ func (c *DCRData) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (tx
 						//tx has been seen on block explorer but still only has 0 confirmations
 						if u.Confirmations < verifier.Confirms {
 							tx.Seen = true
-							err = fmt.Errorf("seen, waiting for confirms (%v/%v)", u.Confirmations, verifier.Confirms)
+							err = blockexplorer.NotConfirmedError(u.Confirmations, verifier.Confirms)
 							return tx, err
 						}
 
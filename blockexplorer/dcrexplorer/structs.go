@@ -4,6 +4,20 @@ import (
 	"encoding/json"
 )
 
+const (
+	txTreeRegular = 0
+	txTreeStake   = 1
+)
+
+// hex of the opcodes tagging the scripts of the stake tree
+const (
+	opSSTX       = "ba"
+	opSSGEN      = "bb"
+	opSSRTX      = "bc"
+	opSSTXCHANGE = "bd"
+	opTGEN       = "c3"
+)
+
 type jsonResponse struct {
 	Success bool            `json:"Success"`
 	Message string          `json:"Message"`
@@ -47,6 +61,41 @@ type DecodedTransaction struct {
 	Vin      []VIN  `json:"vin"`
 	Vout     []VOUT `json:"vout"`
 }
+type InsightAddress struct {
+	AddrStr               string `json:"addrStr"`
+	BalanceSat            int64  `json:"balanceSat"`
+	UnconfirmedBalanceSat int64  `json:"unconfirmedBalanceSat"`
+}
+type InsightUTXO struct {
+	Address       string  `json:"address"`
+	TxID          string  `json:"txid"`
+	Vout          int     `json:"vout"`
+	ScriptPubKey  string  `json:"scriptPubKey"`
+	Height        int     `json:"height"`
+	Amount        float64 `json:"amount"`
+	Satoshis      int64   `json:"satoshis"`
+	Confirmations int     `json:"confirmations"`
+}
+
+// tree returns the tree of the output, the outputs of the stake transactions
+// have a script starting with a stake opcode.
+func (u *InsightUTXO) tree() int {
+	if len(u.ScriptPubKey) < 2 {
+		return txTreeRegular
+	}
+	switch u.ScriptPubKey[:2] {
+	case opSSTX, opSSGEN, opSSRTX, opSSTXCHANGE, opTGEN:
+		return txTreeStake
+	}
+	return txTreeRegular
+}
+
+type BlockDataBasic struct {
+	Height int    `json:"height"`
+	Size   int    `json:"size"`
+	Hash   string `json:"hash"`
+	Time   int    `json:"time"`
+}
 type Transaction struct {
 	Block struct {
 		Blockhash   string `json:"blockhash"`
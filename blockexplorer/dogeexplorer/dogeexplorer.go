@@ -3,6 +3,9 @@ package dogeexplorer
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/vibros68/instantswap/blockexplorer"
 	"github.com/vibros68/instantswap/blockexplorer/global/clients/blockexplorerclient"
 	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
@@ -17,6 +20,8 @@ func init() {
 const (
 	API_BASE = "https://dogechain.info/api/v1/"
 	LIBNAME  = "doge"
+	// BLOCK_COUNT_URL returns the height of the latest block as plain text
+	BLOCK_COUNT_URL = "https://dogechain.info/chain/Dogecoin/q/getblockcount"
 )
 
 type dogeExplorer struct {
@@ -45,7 +50,7 @@ func (d *dogeExplorer) VerifyByAddress(req blockexplorer.AddressVerifyRequest) (
 			}, nil
 		}
 	}
-	return nil, fmt.Errorf("not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "deposit")
 }
 func (d *dogeExplorer) GetTransaction(txId string) (tx *blockexplorer.ITransaction, err error) {
 	var response = struct {
@@ -63,7 +68,17 @@ func (d *dogeExplorer) GetTransaction(txId string) (tx *blockexplorer.ITransacti
 	if response.Success == 0 {
 		return nil, fmt.Errorf(response.Error)
 	}
-	return response.Tx.tx(), nil
+	var block *Block
+	if response.Tx.BlockHash != "" {
+		if block, err = d.getBlock(response.Tx.BlockHash); err != nil {
+			return nil, err
+		}
+	}
+	tip, err := d.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
+	return response.Tx.tx(block, tip), nil
 }
 func (d *dogeExplorer) getTxsForAddress(address string) (txs []TxForAddress, err error) {
 	var response = struct {
@@ -131,6 +146,105 @@ func (d *dogeExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest)
 	return tx, err
 }
 
+// GetChainTip returns the latest block
+func (d *dogeExplorer) GetChainTip() (tip *blockexplorer.ChainTip, err error) {
+	r, err := d.client.Do("GET", BLOCK_COUNT_URL, "", false)
+	if err != nil {
+		return nil, err
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(string(r)))
+	if err != nil {
+		return nil, err
+	}
+	block, err := d.getBlock(strconv.Itoa(height))
+	if err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{
+		Height: block.Height,
+		Hash:   block.Hash,
+		Time:   block.Time,
+	}, nil
+}
+
+// getBlock returns the block of the hash or height id.
+func (d *dogeExplorer) getBlock(id string) (*Block, error) {
+	var response = struct {
+		Res
+		Block Block `json:"block"`
+	}{}
+	r, err := d.client.Do("GET", "block/"+id, "", false)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(r, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Success == 0 {
+		return nil, fmt.Errorf(response.Error)
+	}
+	return &response.Block, nil
+}
+
+// GetAddressBalance returns the balance of the address, dogechain does not
+// give the unconfirmed balance.
+func (d *dogeExplorer) GetAddressBalance(address string, viewKey string) (balance *blockexplorer.AddressBalance, err error) {
+	var response = struct {
+		Res
+		Balance float64 `json:"balance,string"`
+	}{}
+	r, err := d.client.Do("GET", fmt.Sprintf("address/balance/%s", address), "", false)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(r, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Success == 0 {
+		return nil, fmt.Errorf(response.Error)
+	}
+	confirmed, err := idaemon.NewAmount(response.Balance)
+	if err != nil {
+		return nil, err
+	}
+	return &blockexplorer.AddressBalance{
+		Address:   address,
+		Confirmed: confirmed,
+	}, nil
+}
+
+// GetUTXOs returns the unspent outputs of the address
+func (d *dogeExplorer) GetUTXOs(address string) (utxos []blockexplorer.UTXO, err error) {
+	var response = struct {
+		Res
+		UnspentOutputs []UnspentOutput `json:"unspent_outputs"`
+	}{}
+	r, err := d.client.Do("GET", fmt.Sprintf("address/unspent/%s", address), "", false)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(r, &response)
+	if err != nil {
+		return nil, err
+	}
+	if response.Success == 0 {
+		return nil, fmt.Errorf(response.Error)
+	}
+	utxos = make([]blockexplorer.UTXO, len(response.UnspentOutputs))
+	for i, u := range response.UnspentOutputs {
+		utxos[i] = blockexplorer.UTXO{
+			TxID:          u.TxHash,
+			Vout:          u.TxOutputN,
+			Value:         idaemon.Amount(u.Value),
+			Script:        u.Script,
+			Confirmations: u.Confirmations,
+		}
+	}
+	return utxos, nil
+}
+
 // PushTx pushes a raw tx hash
 func (d *dogeExplorer) PushTx(rawTxHash string) (result string, err error) {
 	return "", fmt.Errorf("not supported")
@@ -53,6 +53,22 @@ type Input struct {
 	} `json:"previous_output"`
 }
 
+// UnspentOutput values are in koinu
+type UnspentOutput struct {
+	TxHash        string `json:"tx_hash"`
+	TxOutputN     int    `json:"tx_output_n"`
+	Script        string `json:"script"`
+	Address       string `json:"address"`
+	Value         int64  `json:"value,string"`
+	Confirmations int    `json:"confirmations"`
+}
+
+type Block struct {
+	Hash   string `json:"hash"`
+	Height int    `json:"height"`
+	Time   int    `json:"time"`
+}
+
 type Res struct {
 	Error   string `json:"error"`
 	Success int    `json:"success"`
@@ -96,9 +112,16 @@ func (tx *Transaction) outputs() []blockexplorer.IVOUT {
 	}
 	return outputs
 }
-func (tx *Transaction) tx() *blockexplorer.ITransaction {
+
+// tx returns the transaction mined in block, nil when it is unconfirmed, with
+// its confirmations from tip.
+func (tx *Transaction) tx(block *Block, tip *blockexplorer.ChainTip) *blockexplorer.ITransaction {
+	var blockHeight int
+	if block != nil {
+		blockHeight = block.Height
+	}
 	return &blockexplorer.ITransaction{
-		BlockHeight:   0,
+		BlockHeight:   blockHeight,
 		DoubleSpend:   false,
 		Hash:          tx.Hash,
 		Inputs:        tx.inputs(),
@@ -112,7 +135,7 @@ func (tx *Transaction) tx() *blockexplorer.ITransaction {
 		VinSz:         0,
 		VoutSz:        0,
 		Weight:        0,
-		Confirmations: tx.Confirmations,
+		Confirmations: tip.Confirmations(blockHeight),
 		// verification: ignore
 		Seen:                false,
 		Verified:            false,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/vibros68/instantswap/blockexplorer/global/utils"
 	"math"
+	"math/big"
 	"net/http"
 	"strings"
 
@@ -63,7 +64,7 @@ func (e *etherScan) VerifyByAddress(req blockexplorer.AddressVerifyRequest) (vr
 			}
 		}
 	}
-	return nil, fmt.Errorf("not found")
+	return nil, blockexplorer.NotFoundError(LIBNAME, "deposit")
 }
 
 func (e *etherScan) getTx(txId string) (*Tx, error) {
@@ -177,6 +178,66 @@ func (e *etherScan) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (t
 	}
 	return tx, nil
 }
+
+// GetAddressBalance returns the balance of the token contract of the config,
+// or of ETH. Ethplorer does not give the unconfirmed balance.
+func (e *etherScan) GetAddressBalance(address string, viewKey string) (balance *blockexplorer.AddressBalance, err error) {
+	r, err := e.client.Do("GET", fmt.Sprintf("getAddressInfo/%s?apiKey=freekey", address), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var info AddressInfo
+	if err = parse(r, &info); err != nil {
+		return nil, err
+	}
+	var symbol = strings.ToUpper(e.conf.Symbol)
+	var token = blockexplorer.TokenBalance{Symbol: "ETH"}
+	if e.conf.Type == blockexplorer.NetworkTypeErc20 && symbol != "ETH" {
+		// the symbols are not unique, spam tokens reuse them.
+		if e.conf.Contract == "" {
+			return nil, fmt.Errorf("%s:error: contract of %s is blank so the balance cannot be found", LIBNAME, symbol)
+		}
+		token.Symbol = symbol
+		token.Contract = e.conf.Contract
+		token.Confirmed = blockexplorer.TokenAmount{Value: new(big.Int)}
+		for _, t := range info.Tokens {
+			if strings.EqualFold(t.TokenInfo.Address, e.conf.Contract) {
+				token.Confirmed, err = blockexplorer.NewTokenAmount(t.RawBalance, t.TokenInfo.Decimals)
+				if err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	} else {
+		token.Confirmed, err = blockexplorer.NewTokenAmount(info.ETH.RawBalance, 18)
+		if err != nil {
+			return nil, err
+		}
+	}
+	token.Unconfirmed = blockexplorer.TokenAmount{Value: new(big.Int), Decimals: token.Confirmed.Decimals}
+	return blockexplorer.NewTokenBalance(address, token), nil
+}
+
+// GetChainTip returns the latest block, ethplorer only gives its number.
+func (e *etherScan) GetChainTip() (tip *blockexplorer.ChainTip, err error) {
+	r, err := e.client.Do("GET", "getLastBlock?apiKey=freekey", "", false)
+	if err != nil {
+		return nil, err
+	}
+	var lastBlock struct {
+		LastBlock int `json:"lastBlock"`
+	}
+	if err = parse(r, &lastBlock); err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{Height: lastBlock.LastBlock}, nil
+}
 func (e *etherScan) PushTx(rawTxHash string) (result string, err error) {
 	return "", fmt.Errorf("not supported")
 }
+
+// GetUTXOs is not supported, ethereum is account based
+func (e *etherScan) GetUTXOs(address string) ([]blockexplorer.UTXO, error) {
+	return nil, blockexplorer.NotSupportedError(LIBNAME, "GetUTXOs")
+}
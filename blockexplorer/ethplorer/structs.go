@@ -70,6 +70,19 @@ func (t *TxOperation) value() float64 {
 	return fVal
 }
 
+// AddressInfo is the balance of an address, the raw balances are in the
+// smallest unit of the coin or token.
+type AddressInfo struct {
+	Address string `json:"address"`
+	ETH     struct {
+		RawBalance string `json:"rawBalance"`
+	} `json:"ETH"`
+	Tokens []struct {
+		TokenInfo  TokenInfo `json:"tokenInfo"`
+		RawBalance string    `json:"rawBalance"`
+	} `json:"tokens"`
+}
+
 type TxLog struct {
 }
 
@@ -107,6 +120,10 @@ type TokenInfo struct {
 }
 
 func (e *etherScan) generalTx(ethTx *Tx) (*blockexplorer.ITransaction, error) {
+	tip, err := e.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
 	var tx = &blockexplorer.ITransaction{
 		BlockHeight:         ethTx.BlockNumber,
 		DoubleSpend:         false,
@@ -122,7 +139,7 @@ func (e *etherScan) generalTx(ethTx *Tx) (*blockexplorer.ITransaction, error) {
 		VinSz:               0,
 		VoutSz:              0,
 		Weight:              0,
-		Confirmations:       ethTx.Confirmations,
+		Confirmations:       tip.Confirmations(ethTx.BlockNumber),
 		Seen:                false,
 		Verified:            false,
 		OrderedAmount:       0,
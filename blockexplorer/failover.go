@@ -0,0 +1,260 @@
+package blockexplorer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultUnhealthyPeriod is how long a failed provider is tried after the
+// healthy ones.
+const DefaultUnhealthyPeriod = 5 * time.Minute
+
+// health records until when the providers are unhealthy, it is shared by the
+// explorers so a provider failing for one of them is skipped by the others.
+var health = struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}{until: make(map[string]time.Time)}
+
+func healthKey(key, name string) string {
+	return key + "/" + name
+}
+
+func setUnhealthy(key, name string, period time.Duration) {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	health.until[healthKey(key, name)] = time.Now().Add(period)
+}
+
+func setHealthy(key, name string) {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	delete(health.until, healthKey(key, name))
+}
+
+func isHealthy(key, name string) bool {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	until, ok := health.until[healthKey(key, name)]
+	return !ok || time.Now().After(until)
+}
+
+type namedExplorer struct {
+	name     string
+	explorer IBlockExplorer
+}
+
+// Failover is the explorer of a symbol registered by several providers. It
+// calls the providers by priority and fails over to the next one on an error
+// or a timeout, the failed providers are tried after the healthy ones until
+// their unhealthy period ends.
+type Failover struct {
+	key       string
+	timeout   time.Duration
+	period    time.Duration
+	explorers []namedExplorer
+}
+
+func newFailover(key string, conf Config, providers []Provider) (*Failover, error) {
+	f := &Failover{
+		key:     key,
+		timeout: conf.Timeout,
+		period:  conf.UnhealthyPeriod,
+	}
+	if f.period <= 0 {
+		f.period = DefaultUnhealthyPeriod
+	}
+	var err error
+	f.explorers, err = newExplorers(key, conf, providers)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// newExplorers returns the explorers of the providers which could be created.
+func newExplorers(key string, conf Config, providers []Provider) ([]namedExplorer, error) {
+	var explorers []namedExplorer
+	var errs []string
+	for _, p := range providers {
+		explorer, err := p.New(conf)
+		if err != nil {
+			errs = append(errs, p.Name+": "+err.Error())
+			continue
+		}
+		explorers = append(explorers, namedExplorer{name: p.Name, explorer: explorer})
+	}
+	if len(explorers) == 0 {
+		return nil, fmt.Errorf("[%s] no explorer is available: %s", key, strings.Join(errs, "; "))
+	}
+	return explorers, nil
+}
+
+// Providers returns the names of the providers by priority.
+func (f *Failover) Providers() []string {
+	names := make([]string, len(f.explorers))
+	for i, e := range f.explorers {
+		names[i] = e.name
+	}
+	return names
+}
+
+// Unhealthy returns the names of the providers which are currently unhealthy.
+func (f *Failover) Unhealthy() []string {
+	var names []string
+	for _, e := range f.explorers {
+		if !isHealthy(f.key, e.name) {
+			names = append(names, e.name)
+		}
+	}
+	return names
+}
+
+// ordered returns the healthy explorers then the unhealthy ones.
+func (f *Failover) ordered() []namedExplorer {
+	explorers := append([]namedExplorer(nil), f.explorers...)
+	sort.SliceStable(explorers, func(i, j int) bool {
+		return isHealthy(f.key, explorers[i].name) && !isHealthy(f.key, explorers[j].name)
+	})
+	return explorers
+}
+
+// do calls each explorer until one succeeds and returns its result. The
+// explorers which do not support the call, or can not list all the unspent
+// outputs, are skipped and stay healthy. A
+// transaction waiting for confirms is not a failure, its result is returned
+// with ErrNotConfirmed. Neither are a transaction or an address not found and
+// an invalid request, their error is returned without failing over.
+func (f *Failover) do(call func(explorer IBlockExplorer) (interface{}, error)) (interface{}, error) {
+	var errs []string
+	var notSupported int
+	for _, e := range f.ordered() {
+		res, err := callExplorer(e.explorer, f.timeout, call)
+		if err == nil || errors.Is(err, ErrNotConfirmed) || errors.Is(err, ErrNotFound) {
+			setHealthy(f.key, e.name)
+			return res, err
+		}
+		if errors.Is(err, ErrInvalidRequest) {
+			return res, err
+		}
+		errs = append(errs, e.name+": "+err.Error())
+		if errors.Is(err, ErrNotSupported) {
+			notSupported++
+			continue
+		}
+		if errors.Is(err, ErrTooManyUTXOs) {
+			continue
+		}
+		setUnhealthy(f.key, e.name, f.period)
+	}
+	if notSupported == len(f.explorers) {
+		return nil, fmt.Errorf("[%s] no explorer supports the call: %w", f.key, ErrNotSupported)
+	}
+	return nil, fmt.Errorf("[%s] all explorers failed: %s", f.key, strings.Join(errs, "; "))
+}
+
+// callExplorer calls explorer and gives up after timeout when it is set.
+func callExplorer(explorer IBlockExplorer, timeout time.Duration, call func(explorer IBlockExplorer) (interface{}, error)) (interface{}, error) {
+	if timeout <= 0 {
+		return call(explorer)
+	}
+	type result struct {
+		res interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		res, err := call(explorer)
+		done <- result{res, err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-timer.C:
+		return nil, fmt.Errorf("timeout after %s", timeout)
+	}
+}
+
+func (f *Failover) GetTransaction(txId string) (*ITransaction, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.GetTransaction(txId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*ITransaction), nil
+}
+
+func (f *Failover) GetTxsForAddress(address string, limit int, viewKey string) (*IRawAddrResponse, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.GetTxsForAddress(address, limit, viewKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*IRawAddrResponse), nil
+}
+
+func (f *Failover) VerifyTransaction(verifier TxVerifyRequest) (*ITransaction, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.VerifyTransaction(verifier)
+	})
+	tx, _ := res.(*ITransaction)
+	return tx, err
+}
+
+func (f *Failover) VerifyByAddress(req AddressVerifyRequest) (*VerifyResult, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.VerifyByAddress(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*VerifyResult), nil
+}
+
+func (f *Failover) GetChainTip() (*ChainTip, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.GetChainTip()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*ChainTip), nil
+}
+
+func (f *Failover) GetAddressBalance(address string, viewKey string) (*AddressBalance, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.GetAddressBalance(address, viewKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*AddressBalance), nil
+}
+
+func (f *Failover) GetUTXOs(address string) ([]UTXO, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.GetUTXOs(address)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]UTXO), nil
+}
+
+func (f *Failover) PushTx(rawTxHash string) (string, error) {
+	res, err := f.do(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.PushTx(rawTxHash)
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
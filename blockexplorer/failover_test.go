@@ -0,0 +1,257 @@
+package blockexplorer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeExplorer answers GetTransaction with tx, or with its name as the hash,
+// and the verifications with tx and result when they are set.
+type fakeExplorer struct {
+	name   string
+	err    error
+	delay  time.Duration
+	calls  int
+	tx     *ITransaction
+	result *VerifyResult
+	utxos  []UTXO
+}
+
+func (f *fakeExplorer) GetTransaction(txId string) (*ITransaction, error) {
+	f.calls++
+	time.Sleep(f.delay)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.tx != nil {
+		return f.tx, nil
+	}
+	return &ITransaction{Hash: f.name}, nil
+}
+
+func (f *fakeExplorer) GetTxsForAddress(address string, limit int, viewKey string) (*IRawAddrResponse, error) {
+	return &IRawAddrResponse{Address: f.name}, f.err
+}
+
+func (f *fakeExplorer) VerifyTransaction(verifier TxVerifyRequest) (*ITransaction, error) {
+	if f.tx != nil {
+		return f.tx, f.err
+	}
+	return f.GetTransaction(verifier.TxId)
+}
+
+func (f *fakeExplorer) VerifyByAddress(req AddressVerifyRequest) (*VerifyResult, error) {
+	if f.result != nil {
+		return f.result, f.err
+	}
+	return &VerifyResult{}, f.err
+}
+
+func (f *fakeExplorer) GetChainTip() (*ChainTip, error) {
+	return &ChainTip{Hash: f.name}, f.err
+}
+
+func (f *fakeExplorer) GetAddressBalance(address string, viewKey string) (*AddressBalance, error) {
+	return &AddressBalance{Address: f.name}, f.err
+}
+
+func (f *fakeExplorer) GetUTXOs(address string) ([]UTXO, error) {
+	if f.utxos == nil {
+		return nil, NotSupportedError(f.name, "GetUTXOs")
+	}
+	return f.utxos, f.err
+}
+
+func (f *fakeExplorer) PushTx(rawTxHash string) (string, error) {
+	return f.name, f.err
+}
+
+func registerFakes(symbol string, explorers ...*fakeExplorer) {
+	for i, e := range explorers {
+		e := e
+		RegisterProvider(symbol, "", Provider{
+			Name:     e.name,
+			Priority: len(explorers) - i,
+			New: func(conf Config) (IBlockExplorer, error) {
+				return e, nil
+			},
+		})
+	}
+}
+
+func TestFailover(t *testing.T) {
+	primary := &fakeExplorer{name: "primary"}
+	backup := &fakeExplorer{name: "backup"}
+	// registered by descending priority so the order comes from Priority
+	registerFakes("fo1", backup, primary)
+	if names := Providers("FO1"); !reflect.DeepEqual(names, []string{"primary", "backup"}) {
+		t.Fatalf("Providers = %v", names)
+	}
+	explorer, err := NewExplorer(Config{Symbol: "FO1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failover, ok := explorer.(*Failover)
+	if !ok {
+		t.Fatalf("NewExplorer = %T, expected a *Failover", explorer)
+	}
+
+	tx, err := failover.GetTransaction("tx")
+	if err != nil || tx.Hash != "primary" {
+		t.Fatalf("GetTransaction = %v, %v, expected the primary", tx, err)
+	}
+
+	primary.err = errors.New("down")
+	tx, err = failover.GetTransaction("tx")
+	if err != nil || tx.Hash != "backup" {
+		t.Fatalf("GetTransaction = %v, %v, expected the backup", tx, err)
+	}
+	if unhealthy := failover.Unhealthy(); !reflect.DeepEqual(unhealthy, []string{"primary"}) {
+		t.Errorf("Unhealthy = %v, expected: [primary]", unhealthy)
+	}
+
+	// the unhealthy primary is skipped, also by a new explorer
+	primary.err, primary.calls = nil, 0
+	explorer, _ = NewExplorer(Config{Symbol: "FO1"})
+	if tx, _ = explorer.GetTransaction("tx"); tx.Hash != "backup" || primary.calls != 0 {
+		t.Errorf("GetTransaction = %s with %d primary calls, expected the backup", tx.Hash, primary.calls)
+	}
+
+	backup.err = errors.New("down")
+	if tx, err = explorer.GetTransaction("tx"); err != nil || tx.Hash != "primary" {
+		t.Errorf("GetTransaction = %v, %v, expected the unhealthy primary", tx, err)
+	}
+	primary.err = errors.New("down")
+	if _, err = explorer.GetTransaction("tx"); err == nil {
+		t.Error("GetTransaction succeeded, expected all explorers to fail")
+	}
+}
+
+func TestFailoverTimeout(t *testing.T) {
+	slow := &fakeExplorer{name: "slow", delay: time.Second}
+	fast := &fakeExplorer{name: "fast"}
+	registerFakes("fo2", fast, slow)
+	explorer, err := NewExplorer(Config{Symbol: "FO2", Timeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := explorer.GetTransaction("tx")
+	if err != nil || tx.Hash != "fast" {
+		t.Fatalf("GetTransaction = %v, %v, expected the fast explorer", tx, err)
+	}
+}
+
+func TestSingleProvider(t *testing.T) {
+	only := &fakeExplorer{name: "only"}
+	RegisterExplorer("fo3", "", func(conf Config) (IBlockExplorer, error) {
+		return only, nil
+	})
+	explorer, err := NewExplorer(Config{Symbol: "fo3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explorer != IBlockExplorer(only) {
+		t.Errorf("NewExplorer = %T, expected the provider explorer", explorer)
+	}
+}
+
+func TestFailoverNotSupported(t *testing.T) {
+	account := &fakeExplorer{name: "account"}
+	utxo := &fakeExplorer{name: "utxo", utxos: []UTXO{{TxID: "tx", Vout: 1}}}
+	registerFakes("fo4", account, utxo)
+	explorer, err := NewExplorer(Config{Symbol: "FO4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxos, err := explorer.GetUTXOs("address")
+	if err != nil || !reflect.DeepEqual(utxos, utxo.utxos) {
+		t.Fatalf("GetUTXOs = %v, %v, expected: %v", utxos, err, utxo.utxos)
+	}
+	if unhealthy := explorer.(*Failover).Unhealthy(); len(unhealthy) != 0 {
+		t.Errorf("Unhealthy = %v, expected the explorers not supporting the call to stay healthy", unhealthy)
+	}
+
+	utxo.utxos = nil
+	if _, err = explorer.GetUTXOs("address"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GetUTXOs error = %v, expected: ErrNotSupported", err)
+	}
+}
+
+func TestFailoverNotConfirmed(t *testing.T) {
+	waiting := &fakeExplorer{
+		name: "waiting",
+		tx:   &ITransaction{Hash: "waiting", Seen: true, Confirmations: 1},
+		err:  NotConfirmedError(1, 3),
+	}
+	backup := &fakeExplorer{name: "backup"}
+	registerFakes("fo5", backup, waiting)
+	explorer, err := NewExplorer(Config{Symbol: "FO5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := explorer.VerifyTransaction(TxVerifyRequest{TxId: "tx", Confirms: 3})
+	if !errors.Is(err, ErrNotConfirmed) {
+		t.Fatalf("VerifyTransaction error = %v, expected: ErrNotConfirmed", err)
+	}
+	if tx == nil || tx.Hash != "waiting" || !tx.Seen {
+		t.Errorf("VerifyTransaction = %+v, expected the seen transaction", tx)
+	}
+	if backup.calls != 0 {
+		t.Errorf("backup called %d times, expected no failover", backup.calls)
+	}
+	if unhealthy := explorer.(*Failover).Unhealthy(); len(unhealthy) != 0 {
+		t.Errorf("Unhealthy = %v, expected the waiting explorer to stay healthy", unhealthy)
+	}
+}
+
+func TestFailoverTooManyUTXOs(t *testing.T) {
+	truncated := &fakeExplorer{name: "truncated", utxos: []UTXO{}, err: fmt.Errorf("truncated: %w", ErrTooManyUTXOs)}
+	paginated := &fakeExplorer{name: "paginated", utxos: []UTXO{{TxID: "tx", Vout: 1}}}
+	registerFakes("fo6", paginated, truncated)
+	explorer, err := NewExplorer(Config{Symbol: "FO6"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxos, err := explorer.GetUTXOs("address")
+	if err != nil || !reflect.DeepEqual(utxos, paginated.utxos) {
+		t.Fatalf("GetUTXOs = %v, %v, expected: %v", utxos, err, paginated.utxos)
+	}
+	if unhealthy := explorer.(*Failover).Unhealthy(); len(unhealthy) != 0 {
+		t.Errorf("Unhealthy = %v, expected the truncated explorer to stay healthy", unhealthy)
+	}
+}
+
+func TestFailoverNotFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		symbol string
+		err    error
+	}{
+		{name: "not found", symbol: "fo7", err: NotFoundError("missing", "tx")},
+		{name: "invalid request", symbol: "fo8", err: InvalidRequestError("missing", "address is blank so tx cannot be verified")},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			missing := &fakeExplorer{name: "missing", err: test.err}
+			backup := &fakeExplorer{name: "backup"}
+			registerFakes(test.symbol, backup, missing)
+			explorer, err := NewExplorer(Config{Symbol: test.symbol})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = explorer.GetTransaction("tx"); !errors.Is(err, test.err) {
+				t.Fatalf("GetTransaction error = %v, expected: %v", err, test.err)
+			}
+			if backup.calls != 0 {
+				t.Errorf("backup called %d times, expected no failover", backup.calls)
+			}
+			if unhealthy := explorer.(*Failover).Unhealthy(); len(unhealthy) != 0 {
+				t.Errorf("Unhealthy = %v, expected the explorer to stay healthy", unhealthy)
+			}
+		})
+	}
+}
@@ -9,4 +9,5 @@ import (
 	_ "github.com/vibros68/instantswap/blockexplorer/dogeexplorer"
 	_ "github.com/vibros68/instantswap/blockexplorer/ethplorer"
 	_ "github.com/vibros68/instantswap/blockexplorer/xmrexplorer"
+	_ "github.com/vibros68/instantswap/blockexplorer/zecexplorer"
 )
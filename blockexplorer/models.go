@@ -26,6 +26,17 @@ type IVOUT struct {
 	Value       idaemon.Amount `json:"value"`
 }
 
+// UTXO is an unspent output, Script is the hex of its public key script.
+type UTXO struct {
+	TxID          string         `json:"txid"`
+	Vout          int            `json:"vout"`
+	Value         idaemon.Amount `json:"value"`
+	Script        string         `json:"script"`
+	Confirmations int            `json:"confirmations"`
+	//only for dcrdata
+	Tree int `json:"tree,omitempty"`
+}
+
 type ITransaction struct {
 	BlockHeight   int     `json:"block_height,omitempty"`
 	DoubleSpend   bool    `json:"double_spend,omitempty"`
@@ -101,6 +112,22 @@ type IRawAddrOutput struct {
 	Value     idaemon.Amount `json:"value"`
 }
 
+// ChainTip is the latest block of a chain, Time is a unix timestamp.
+type ChainTip struct {
+	Height int    `json:"height"`
+	Hash   string `json:"hash"`
+	Time   int    `json:"time"`
+}
+
+// Confirmations returns the confirmations of a transaction mined at
+// blockHeight, zero when it is not mined yet.
+func (t *ChainTip) Confirmations(blockHeight int) int {
+	if t == nil || blockHeight <= 0 || blockHeight > t.Height {
+		return 0
+	}
+	return t.Height - blockHeight + 1
+}
+
 type IPushTxResult struct {
 	Success bool
 	Message string
@@ -0,0 +1,26 @@
+package blockexplorer
+
+import "testing"
+
+func TestChainTipConfirmations(t *testing.T) {
+	tip := &ChainTip{Height: 100}
+	tests := []struct {
+		blockHeight   int
+		confirmations int
+	}{
+		{100, 1},
+		{91, 10},
+		{0, 0},
+		{-1, 0},
+		{101, 0},
+	}
+	for _, test := range tests {
+		if got := tip.Confirmations(test.blockHeight); got != test.confirmations {
+			t.Errorf("Confirmations(%d) = %d, expected: %d", test.blockHeight, got, test.confirmations)
+		}
+	}
+	var none *ChainTip
+	if got := none.Confirmations(91); got != 0 {
+		t.Errorf("Confirmations of a nil tip = %d, expected: 0", got)
+	}
+}
@@ -0,0 +1,271 @@
+package blockexplorer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
+)
+
+// Quorum verifies the deposits with every provider of a symbol, a deposit is
+// verified when at least min providers agree on its amount, output address
+// and confirmations.
+type Quorum struct {
+	min       int
+	timeout   time.Duration
+	explorers []namedExplorer
+}
+
+// NewQuorum returns the quorum of the providers of conf.Symbol, or of
+// conf.Type when it is set. It fails when fewer than min providers are
+// available.
+func NewQuorum(conf Config, min int) (*Quorum, error) {
+	if min < 1 {
+		return nil, fmt.Errorf("quorum of %d providers", min)
+	}
+	key, providers, err := driv.providers(conf)
+	if err != nil {
+		return nil, err
+	}
+	explorers, err := newExplorers(key, conf, providers)
+	if err != nil {
+		return nil, err
+	}
+	if len(explorers) < min {
+		return nil, fmt.Errorf("[%s] %d explorers are available for a quorum of %d", key, len(explorers), min)
+	}
+	return &Quorum{min: min, timeout: conf.Timeout, explorers: explorers}, nil
+}
+
+// Vote is the verification of a deposit by a provider. Address is empty when
+// no output pays the verified address.
+type Vote struct {
+	Provider      string
+	Verified      bool
+	Address       string
+	Amount        idaemon.Amount
+	Confirmations int
+	// Confirmed tells whether the deposit has the requested confirmations.
+	Confirmed bool
+	Err       error
+}
+
+func (v Vote) agrees(o Vote) bool {
+	return v.Err == nil && o.Err == nil && v.Verified == o.Verified && v.Address == o.Address &&
+		v.Amount == o.Amount && v.Confirmed == o.Confirmed
+}
+
+// Disagreement is a field of a vote which differs from the quorum.
+type Disagreement struct {
+	Provider string
+	Field    string
+	Expected string
+	Got      string
+}
+
+func (d Disagreement) String() string {
+	return fmt.Sprintf("%s: %s is %s, expected: %s", d.Provider, d.Field, d.Got, d.Expected)
+}
+
+// QuorumResult is the verification of a deposit by the providers. Agreed is
+// the number of providers of the largest agreeing group, its deposit is
+// Verified when it has at least the quorum.
+type QuorumResult struct {
+	Verified      bool
+	Agreed        int
+	Quorum        int
+	Votes         []Vote
+	Disagreements []Disagreement
+	// Tx is the transaction returned by the first provider of the quorum to
+	// VerifyTransaction.
+	Tx *ITransaction
+	// Result is the result returned by the first provider of the quorum to
+	// VerifyByAddress.
+	Result *VerifyResult
+}
+
+// vote calls every explorer concurrently.
+func (q *Quorum) vote(call func(explorer IBlockExplorer) (interface{}, error)) ([]interface{}, []error) {
+	results := make([]interface{}, len(q.explorers))
+	errs := make([]error, len(q.explorers))
+	var wg sync.WaitGroup
+	for i, e := range q.explorers {
+		wg.Add(1)
+		go func(i int, e namedExplorer) {
+			defer wg.Done()
+			results[i], errs[i] = callExplorer(e.explorer, q.timeout, call)
+		}(i, e)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// VerifyTransaction verifies the transaction with every provider. The
+// transaction is requested with GetTransaction and its output, amount and
+// confirmations are checked by the quorum, so the providers agree on a
+// deposit waiting for its confirmations too. The requests without TxId, or
+// with a ViewKey, are verified by the providers, the ones waiting for
+// confirms vote for an unconfirmed deposit.
+func (q *Quorum) VerifyTransaction(verifier TxVerifyRequest) *QuorumResult {
+	results, errs := q.vote(func(explorer IBlockExplorer) (interface{}, error) {
+		if verifier.TxId == "" || verifier.ViewKey != "" {
+			return explorer.VerifyTransaction(verifier)
+		}
+		tx, err := explorer.GetTransaction(verifier.TxId)
+		if err != nil {
+			return nil, err
+		}
+		return checkTransaction(tx, verifier), nil
+	})
+	votes := make([]Vote, len(q.explorers))
+	for i, e := range q.explorers {
+		votes[i] = Vote{Provider: e.name, Err: errs[i]}
+		if errors.Is(errs[i], ErrNotConfirmed) {
+			votes[i].Err = nil
+		}
+		tx, _ := results[i].(*ITransaction)
+		if votes[i].Err != nil || tx == nil {
+			if votes[i].Err == nil {
+				votes[i].Err = fmt.Errorf("no transaction")
+			}
+			continue
+		}
+		votes[i].Verified = tx.Verified
+		votes[i].Amount = tx.BlockExplorerAmount
+		votes[i].Confirmations = tx.Confirmations
+		votes[i].Confirmed = tx.Confirmations >= verifier.Confirms
+		for _, out := range tx.Outputs {
+			for _, address := range out.Addresses {
+				if address == verifier.Address {
+					votes[i].Address = address
+				}
+			}
+		}
+	}
+	res, best := q.result(votes)
+	if best >= 0 {
+		res.Tx = results[best].(*ITransaction)
+	}
+	return res
+}
+
+// checkTransaction returns a copy of tx verified against verifier, the
+// amount is the sum of the outputs paying the verified address.
+func checkTransaction(tx *ITransaction, verifier TxVerifyRequest) *ITransaction {
+	checked := *tx
+	checked.OrderedAmount, _ = idaemon.NewAmount(verifier.Amount)
+	checked.BlockExplorerAmount = 0
+	checked.Seen = false
+	for _, out := range tx.Outputs {
+		for _, address := range out.Addresses {
+			if address == verifier.Address {
+				checked.Seen = true
+				checked.BlockExplorerAmount += out.Value
+				break
+			}
+		}
+	}
+	checked.Verified = checked.Seen && checked.Confirmations >= verifier.Confirms
+	checked.MissingAmount = checked.OrderedAmount - checked.BlockExplorerAmount
+	if checked.OrderedAmount != 0 {
+		checked.MissingPercent = checked.MissingAmount.ToCoin() / checked.OrderedAmount.ToCoin() * 100
+	}
+	return &checked
+}
+
+// VerifyByAddress verifies the deposit to the address with every provider,
+// the providers agree on the verification and on the amount.
+func (q *Quorum) VerifyByAddress(req AddressVerifyRequest) *QuorumResult {
+	results, errs := q.vote(func(explorer IBlockExplorer) (interface{}, error) {
+		return explorer.VerifyByAddress(req)
+	})
+	votes := make([]Vote, len(q.explorers))
+	for i, e := range q.explorers {
+		votes[i] = Vote{Provider: e.name, Err: errs[i]}
+		vr, _ := results[i].(*VerifyResult)
+		if errs[i] != nil || vr == nil {
+			if votes[i].Err == nil {
+				votes[i].Err = fmt.Errorf("no result")
+			}
+			continue
+		}
+		amount, err := idaemon.NewAmount(vr.BlockExplorerAmount)
+		if err != nil {
+			votes[i].Err = err
+			continue
+		}
+		votes[i].Verified = vr.Verified
+		votes[i].Amount = amount
+		votes[i].Address = req.Address
+		votes[i].Confirmed = vr.Verified
+	}
+	res, best := q.result(votes)
+	if best >= 0 {
+		res.Result = results[best].(*VerifyResult)
+	}
+	return res
+}
+
+// result groups the agreeing votes, the largest group is the one of the
+// quorum and the other votes are disagreements. It returns the index of the
+// first vote of the quorum, -1 when every provider failed.
+func (q *Quorum) result(votes []Vote) (*QuorumResult, int) {
+	res := &QuorumResult{Quorum: q.min, Votes: votes}
+	best := -1
+	for i, v := range votes {
+		if v.Err != nil {
+			continue
+		}
+		agreed := 0
+		for _, o := range votes {
+			if v.agrees(o) {
+				agreed++
+			}
+		}
+		if agreed > res.Agreed {
+			res.Agreed, best = agreed, i
+		}
+	}
+	if best < 0 {
+		for _, v := range votes {
+			res.Disagreements = append(res.Disagreements, Disagreement{Provider: v.Provider, Field: "error", Got: v.Err.Error()})
+		}
+		return res, best
+	}
+	quorum := votes[best]
+	res.Verified = quorum.Verified && quorum.Confirmed && quorum.Address != "" && res.Agreed >= q.min
+	for _, v := range votes {
+		if v.agrees(quorum) {
+			continue
+		}
+		if v.Err != nil {
+			res.Disagreements = append(res.Disagreements, Disagreement{Provider: v.Provider, Field: "error", Got: v.Err.Error()})
+			continue
+		}
+		res.Disagreements = append(res.Disagreements, disagreements(quorum, v)...)
+	}
+	return res, best
+}
+
+func disagreements(quorum, v Vote) []Disagreement {
+	var ds []Disagreement
+	add := func(field, expected, got string) {
+		if expected != got {
+			ds = append(ds, Disagreement{Provider: v.Provider, Field: field, Expected: expected, Got: got})
+		}
+	}
+	add("verified", strconv.FormatBool(quorum.Verified), strconv.FormatBool(v.Verified))
+	add("address", quorum.Address, v.Address)
+	add("amount", formatAmount(quorum.Amount), formatAmount(v.Amount))
+	if quorum.Confirmed != v.Confirmed {
+		add("confirmations", strconv.Itoa(quorum.Confirmations), strconv.Itoa(v.Confirmations))
+	}
+	return ds
+}
+
+func formatAmount(amount idaemon.Amount) string {
+	return strconv.FormatFloat(amount.ToCoin(), 'f', -1, 64)
+}
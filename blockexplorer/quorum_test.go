@@ -0,0 +1,96 @@
+package blockexplorer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
+)
+
+func verifiedTx(address string, amount idaemon.Amount, confirmations int) *ITransaction {
+	return &ITransaction{
+		Hash:                "tx",
+		Outputs:             []IVOUT{{Addresses: []string{address}, Value: amount}},
+		Confirmations:       confirmations,
+		Verified:            true,
+		BlockExplorerAmount: amount,
+	}
+}
+
+func TestQuorumVerifyTransaction(t *testing.T) {
+	a := &fakeExplorer{name: "a", tx: verifiedTx("addr", 1e8, 6)}
+	b := &fakeExplorer{name: "b", tx: verifiedTx("addr", 1e8, 7)}
+	c := &fakeExplorer{name: "c", tx: verifiedTx("addr", 5e7, 6)}
+	registerFakes("qr1", a, b, c)
+	if _, err := NewQuorum(Config{Symbol: "qr1"}, 4); err == nil {
+		t.Error("NewQuorum of 4 providers succeeded with 3 providers")
+	}
+	quorum, err := NewQuorum(Config{Symbol: "qr1"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := TxVerifyRequest{TxId: "tx", Address: "addr", Amount: 1, Confirms: 3}
+
+	res := quorum.VerifyTransaction(req)
+	if !res.Verified || res.Agreed != 2 || res.Tx == nil {
+		t.Fatalf("result = %+v, expected verified by 2 providers", res)
+	}
+	if len(res.Disagreements) != 1 || res.Disagreements[0] != (Disagreement{Provider: "c", Field: "amount", Expected: "1", Got: "0.5"}) {
+		t.Errorf("disagreements = %v", res.Disagreements)
+	}
+
+	// a single provider is not a quorum
+	b.err = errors.New("down")
+	b.tx = verifiedTx("other", 1e8, 7)
+	res = quorum.VerifyTransaction(req)
+	if res.Verified || res.Agreed != 1 || len(res.Disagreements) != 2 {
+		t.Errorf("result = %+v, expected no quorum", res)
+	}
+
+	// the agreeing providers wait for the confirmations
+	b.err = nil
+	a.tx = verifiedTx("addr", 1e8, 1)
+	b.tx = verifiedTx("addr", 1e8, 2)
+	res = quorum.VerifyTransaction(req)
+	if res.Verified || res.Agreed != 2 {
+		t.Errorf("result = %+v, expected an unconfirmed quorum", res)
+	}
+}
+
+func TestQuorumNotConfirmed(t *testing.T) {
+	waiting := &ITransaction{Hash: "tx", Seen: true, Confirmations: 1}
+	a := &fakeExplorer{name: "a", tx: waiting, err: NotConfirmedError(1, 3)}
+	b := &fakeExplorer{name: "b", tx: waiting, err: NotConfirmedError(1, 3)}
+	registerFakes("qr3", a, b)
+	quorum, err := NewQuorum(Config{Symbol: "qr3"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the providers verify the requests without txid
+	res := quorum.VerifyTransaction(TxVerifyRequest{Address: "addr", Amount: 1, Confirms: 3})
+	if res.Verified || res.Agreed != 2 || len(res.Disagreements) != 0 {
+		t.Fatalf("result = %+v, expected an unconfirmed quorum", res)
+	}
+	if res.Tx == nil || !res.Tx.Seen {
+		t.Errorf("tx = %+v, expected the seen transaction", res.Tx)
+	}
+}
+
+func TestQuorumVerifyByAddress(t *testing.T) {
+	a := &fakeExplorer{name: "a", result: &VerifyResult{Seen: true, Verified: true, BlockExplorerAmount: 0.5}}
+	b := &fakeExplorer{name: "b", result: &VerifyResult{Seen: true, Verified: false, BlockExplorerAmount: 0.5}}
+	registerFakes("qr2", a, b)
+	quorum, err := NewQuorum(Config{Symbol: "qr2"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := AddressVerifyRequest{Address: "addr", Amount: 0.5}
+	res := quorum.VerifyByAddress(req)
+	if res.Verified || len(res.Disagreements) != 1 || res.Disagreements[0].Field != "verified" {
+		t.Errorf("result = %+v, expected a disagreement on the verification", res)
+	}
+	b.result.Verified = true
+	if res = quorum.VerifyByAddress(req); !res.Verified || res.Result == nil {
+		t.Errorf("result = %+v, expected verified", res)
+	}
+}
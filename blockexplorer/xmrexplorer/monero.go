@@ -12,6 +12,7 @@ const (
 	API_BASE                   = "https://xmrchain.net/api/" //  API endpoint
 	DEFAULT_HTTPCLIENT_TIMEOUT = 30                          // HTTP client timeout
 	LIBNAME                    = "monero"
+	BALANCE_BLOCKS             = 5 // blocks searched for the outputs of a balance
 )
 
 func init() {
@@ -56,11 +57,18 @@ func (z *MoneroExplorer) VerifyByAddress(req blockexplorer.AddressVerifyRequest)
 
 func (z *MoneroExplorer) GetTransaction(txId string) (*blockexplorer.ITransaction, error) {
 	r, err := z.client.Do("GET", fmt.Sprintf("transaction/%s", txId), "", false)
+	if err != nil {
+		return nil, err
+	}
 	var tx Transaction
 	if err = parseMoneroResponseData(r, &tx); err != nil {
 		return nil, err
 	}
-	return tx.ITransaction(), nil
+	tip, err := z.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
+	return tx.ITransaction(tip), nil
 }
 func (z *MoneroExplorer) GetTxsForAddress(address string, limit int, viewKey string) (account *blockexplorer.IRawAddrResponse, err error) {
 	r, err := z.client.Do("GET", fmt.Sprintf("outputsblocks?address=%s&viewkey=%s&limit=%d&mempool=1", address, viewKey, limit), "", false)
@@ -87,7 +95,58 @@ func (z *MoneroExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyReques
 	return txVerify.ITransaction(verifier), nil
 }
 
+// GetAddressBalance returns the total of the outputs received by the address
+// in the last BALANCE_BLOCKS blocks and in the mempool. A view key only
+// reveals the received outputs, the spent ones are not deducted so it is not
+// the spendable balance.
+func (z *MoneroExplorer) GetAddressBalance(address string, viewKey string) (balance *blockexplorer.AddressBalance, err error) {
+	if viewKey == "" {
+		return nil, blockexplorer.InvalidRequestError(LIBNAME, "view key is blank so the balance cannot be computed")
+	}
+	r, err := z.client.Do("GET", fmt.Sprintf("outputsblocks?address=%s&viewkey=%s&limit=%d&mempool=1",
+		address, viewKey, BALANCE_BLOCKS), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var outputsBlocks OutputsBlocks
+	if err = parseMoneroResponseData(r, &outputsBlocks); err != nil {
+		return nil, err
+	}
+	outputsBlocks.Address = address
+	return outputsBlocks.balance(), nil
+}
+
+// GetChainTip returns the top block of the network
+func (z *MoneroExplorer) GetChainTip() (tip *blockexplorer.ChainTip, err error) {
+	r, err := z.client.Do("GET", "networkinfo", "", false)
+	if err != nil {
+		return nil, err
+	}
+	var info NetworkInfo
+	if err = parseMoneroResponseData(r, &info); err != nil {
+		return nil, err
+	}
+	r, err = z.client.Do("GET", fmt.Sprintf("block/%d", info.Height-1), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var block Block
+	if err = parseMoneroResponseData(r, &block); err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{
+		Height: block.BlockHeight,
+		Hash:   block.Hash,
+		Time:   block.Timestamp,
+	}, nil
+}
+
 // PushTx pushes a raw tx hash
 func (z *MoneroExplorer) PushTx(rawTxHash string) (result string, err error) {
 	return "", fmt.Errorf("%s:error: PushTx is not supported yet... ", LIBNAME)
 }
+
+// GetUTXOs is not supported, the outputs of monero are private
+func (z *MoneroExplorer) GetUTXOs(address string) ([]blockexplorer.UTXO, error) {
+	return nil, blockexplorer.NotSupportedError(LIBNAME, "GetUTXOs")
+}
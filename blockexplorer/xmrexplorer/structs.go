@@ -1,12 +1,17 @@
 package xmrexplorer
 
 import (
+	"math/big"
+
 	"github.com/vibros68/instantswap/blockexplorer"
 	"github.com/vibros68/instantswap/blockexplorer/global/interfaces/idaemon"
 )
 
 const XMR_EXTRA_UNIT = 10000
 
+// XMR_DECIMALS is the precision of the amounts in piconero
+const XMR_DECIMALS = 12
+
 type Response struct {
 	Data   interface{} `json:"data"`
 	Status string      `json:"status"`
@@ -96,7 +101,9 @@ func (t *Transaction) outputs() []blockexplorer.IVOUT {
 	return iVouts
 }
 
-func (t *Transaction) ITransaction() *blockexplorer.ITransaction {
+// ITransaction returns the transaction with its confirmations from tip.
+func (t *Transaction) ITransaction(tip *blockexplorer.ChainTip) *blockexplorer.ITransaction {
+	confirmations := tip.Confirmations(t.BlockHeight)
 	return &blockexplorer.ITransaction{
 		BlockHeight:         t.BlockHeight,
 		DoubleSpend:         false,
@@ -112,9 +119,9 @@ func (t *Transaction) ITransaction() *blockexplorer.ITransaction {
 		VinSz:               0,
 		VoutSz:              0,
 		Weight:              0,
-		Confirmations:       t.Confirmations,
+		Confirmations:       confirmations,
 		Seen:                false,
-		Verified:            t.Confirmations != 0,
+		Verified:            confirmations != 0,
 		OrderedAmount:       0,
 		BlockExplorerAmount: 0,
 		MissingAmount:       0,
@@ -175,6 +182,20 @@ func (v *TxVerifier) ITransaction(verifier blockexplorer.TxVerifyRequest) *block
 	}
 }
 
+// NetworkInfo is the state of the daemon, Height is the number of blocks.
+type NetworkInfo struct {
+	Height       int    `json:"height"`
+	TopBlockHash string `json:"top_block_hash"`
+	TxCount      int    `json:"tx_count"`
+	TxPoolSize   int    `json:"tx_pool_size"`
+}
+
+type Block struct {
+	BlockHeight int    `json:"block_height"`
+	Hash        string `json:"hash"`
+	Timestamp   int    `json:"timestamp"`
+}
+
 type OutputsBlocks struct {
 	Address string        `json:"address"`
 	Height  int           `json:"height"`
@@ -206,6 +227,23 @@ func (o *OutputsBlocks) IRawAddrResponse() *blockexplorer.IRawAddrResponse {
 	}
 }
 
+// balance sums the received outputs, the ones in the mempool are unconfirmed.
+func (o *OutputsBlocks) balance() *blockexplorer.AddressBalance {
+	var confirmed, unconfirmed int64
+	for _, output := range o.Outputs {
+		if output.InMempool {
+			unconfirmed += output.Amount
+		} else {
+			confirmed += output.Amount
+		}
+	}
+	return blockexplorer.NewTokenBalance(o.Address, blockexplorer.TokenBalance{
+		Symbol:      "XMR",
+		Confirmed:   blockexplorer.TokenAmount{Value: big.NewInt(confirmed), Decimals: XMR_DECIMALS},
+		Unconfirmed: blockexplorer.TokenAmount{Value: big.NewInt(unconfirmed), Decimals: XMR_DECIMALS},
+	})
+}
+
 func convertIRawAddrTx(outputs []OutputBlock, address string) []blockexplorer.IRawAddrTx {
 	var addrTxs = make([]blockexplorer.IRawAddrTx, len(outputs))
 	for i, output := range outputs {
@@ -13,7 +13,7 @@ func (t *Transaction) valueZat() int {
 	return amount
 }
 
-func (t *Transaction) generalTx(network *Network) *blockexplorer.ITransaction {
+func (t *Transaction) generalTx(tip *blockexplorer.ChainTip) *blockexplorer.ITransaction {
 	var iTx = &blockexplorer.ITransaction{
 		BlockHeight:         t.BlockHeight,
 		DoubleSpend:         false,
@@ -37,11 +37,9 @@ func (t *Transaction) generalTx(network *Network) *blockexplorer.ITransaction {
 		MissingAmount:       0,
 		MissingPercent:      0,
 	}
-	if network != nil {
-		iTx.Confirmations = network.BlockNumber - t.BlockHeight + 1
-		if iTx.Confirmations != 0 {
-			iTx.Verified = true
-		}
+	iTx.Confirmations = tip.Confirmations(t.BlockHeight)
+	if iTx.Confirmations != 0 {
+		iTx.Verified = true
 	}
 	return iTx
 }
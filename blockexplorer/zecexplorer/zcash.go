@@ -17,8 +17,13 @@ const (
 )
 
 func init() {
-	blockexplorer.RegisterExplorer("ZEC", "", func(config blockexplorer.Config) (blockexplorer.IBlockExplorer, error) {
-		return New(config), nil
+	// zcha.in is the fallback of blockchair.
+	blockexplorer.RegisterProvider("ZEC", "", blockexplorer.Provider{
+		Name:     LIBNAME,
+		Priority: 1,
+		New: func(config blockexplorer.Config) (blockexplorer.IBlockExplorer, error) {
+			return New(config), nil
+		},
 	})
 }
 
@@ -55,8 +60,23 @@ func (z *ZcashExplorer) GetTransaction(txId string) (*blockexplorer.ITransaction
 	if err = json.Unmarshal(r, &tx); err != nil {
 		return nil, err
 	}
-	network, _ := z.getNetwork()
-	return tx.generalTx(network), nil
+	tip, err := z.GetChainTip()
+	if err != nil {
+		return nil, err
+	}
+	return tx.generalTx(tip), nil
+}
+
+// GetChainTip returns the latest block, the network does not give its time.
+func (z *ZcashExplorer) GetChainTip() (*blockexplorer.ChainTip, error) {
+	network, err := z.getNetwork()
+	if err != nil {
+		return nil, err
+	}
+	return &blockexplorer.ChainTip{
+		Height: network.BlockNumber,
+		Hash:   network.BlockHash,
+	}, nil
 }
 func (z *ZcashExplorer) GetTxsForAddress(address string, limit int, viewKey string) (account *blockexplorer.IRawAddrResponse, err error) {
 	if limit > 20 || limit < 1 {
@@ -88,13 +108,34 @@ func (z *ZcashExplorer) GetTxsForAddress(address string, limit int, viewKey stri
 	return account, nil
 }
 
+// GetAddressBalance returns the balance of the transparent address, zcha.in
+// does not give the unconfirmed balance.
+func (z *ZcashExplorer) GetAddressBalance(address string, viewKey string) (*blockexplorer.AddressBalance, error) {
+	r, err := z.client.Do("GET", fmt.Sprintf("mainnet/accounts/%s", address), "", false)
+	if err != nil {
+		return nil, err
+	}
+	var zcashAccount Account
+	if err = json.Unmarshal(r, &zcashAccount); err != nil {
+		return nil, err
+	}
+	confirmed, err := idaemon.NewAmount(zcashAccount.Balance)
+	if err != nil {
+		return nil, err
+	}
+	return &blockexplorer.AddressBalance{
+		Address:   address,
+		Confirmed: confirmed,
+	}, nil
+}
+
 // VerifyTransaction verifies transaction based on values passed in (params: txid, address (required), amount (required), createdAt(unix timestamp) )
 func (z *ZcashExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest) (tx *blockexplorer.ITransaction, err error) {
 	if verifier.Address == "" {
-		return nil, fmt.Errorf(LIBNAME + ":error: address is blank so tx cannot be verified")
+		return nil, blockexplorer.InvalidRequestError(LIBNAME, "address is blank so tx cannot be verified")
 	}
 	if verifier.Amount == 0 {
-		return nil, fmt.Errorf(LIBNAME+":error: amount is %.8f so tx cannot be verified", verifier.Amount)
+		return nil, blockexplorer.InvalidRequestError(LIBNAME, fmt.Sprintf("amount is %.8f so tx cannot be verified", verifier.Amount))
 	}
 	tx = new(blockexplorer.ITransaction)
 	if verifier.TxId != "" && verifier.Address != "" { //verify tx if txid is available
@@ -112,7 +153,7 @@ func (z *ZcashExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest
 					//tx has been seen on block explorer but still only has 0 confirmations
 					if txInfo.Confirmations < verifier.Confirms {
 						tx.Seen = true
-						return tx, fmt.Errorf("seen, waiting for confirms (%v/%v)", txInfo.Confirmations, verifier.Confirms)
+						return tx, blockexplorer.NotConfirmedError(txInfo.Confirmations, verifier.Confirms)
 					}
 
 					orderedAmount, err := idaemon.NewAmount(verifier.Amount)
@@ -156,7 +197,7 @@ func (z *ZcashExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest
 						//tx has been seen on block explorer but still only has 0 confirmations
 						if u.Confirmations < verifier.Confirms {
 							tx.Seen = true
-							return tx, fmt.Errorf("seen, waiting for confirms (%v/%v)", u.Confirmations, verifier.Confirms)
+							return tx, blockexplorer.NotConfirmedError(u.Confirmations, verifier.Confirms)
 						}
 
 						orderedAmount, err := idaemon.NewAmount(verifier.Amount)
@@ -235,3 +276,8 @@ func (z *ZcashExplorer) VerifyTransaction(verifier blockexplorer.TxVerifyRequest
 func (z *ZcashExplorer) PushTx(rawTxHash string) (result string, err error) {
 	return "", fmt.Errorf("%s:error: PushTx is not supported yet... ", LIBNAME)
 }
+
+// GetUTXOs is not supported, zcha.in does not list them
+func (z *ZcashExplorer) GetUTXOs(address string) ([]blockexplorer.UTXO, error) {
+	return nil, blockexplorer.NotSupportedError(LIBNAME, "GetUTXOs")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -31,26 +32,27 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	currencies, err := exchange.GetCurrencies()
+	ctx := context.Background()
+	currencies, err := exchange.GetCurrencies(ctx)
 	fmt.Println("currencies length: ", len(currencies), err)
-	currencies, err = exchange.GetCurrenciesToPair("USDC")
+	currencies, err = exchange.GetCurrenciesToPair(ctx, "USDC")
 	fmt.Println("GetCurrenciesToPair length: ", len(currencies), err)
-	res, err := exchange.GetExchangeRateInfo(instantswap.ExchangeRateRequest{
+	res, err := exchange.GetExchangeRateInfo(ctx, instantswap.ExchangeRateRequest{
 		From:        "USDC",
 		To:          "USDT",
 		FromNetwork: "BSC",
 		ToNetwork:   "BSC",
-		Amount:      50,
+		Amount:      instantswap.NewAmount(50, 0),
 	})
 	fmt.Printf("%+v \n %v \n", res, err)
-	order, err := exchange.CreateOrder(instantswap.CreateOrder{
+	order, err := exchange.CreateOrder(ctx, instantswap.CreateOrder{
 		RefundAddress:   "refund address",        // if the trading fail, the exchange will refund here
 		Destination:     "received usdt address", // your received usdt address
 		FromCurrency:    "USDC",
-		FromNetwork:     "BSC", // set from network (required)
-		ToNetwork:       "BSC", // set to network (required)
-		OrderedAmount:   0,     // use OrderedAmount or InvoicedAmount
-		InvoicedAmount:  50,
+		FromNetwork:     "BSC",                // set from network (required)
+		ToNetwork:       "BSC",                // set to network (required)
+		OrderedAmount:   instantswap.Amount{}, // use OrderedAmount or InvoicedAmount
+		InvoicedAmount:  instantswap.NewAmount(50, 0),
 		ToCurrency:      "USDT",
 		ExtraID:         "",
 		Signature:       res.Signature,
@@ -61,7 +63,7 @@ func main() {
 	// // the exchange will return the rate of exchange is: order.ExchangeRate
 	// // you will send btc to order.DepositAddress
 	// // use OrderInfo to get order status
-	orderInfo, err := exchange.OrderInfo(instantswap.TrackingRequest{OrderId: order.UUID})
+	orderInfo, err := exchange.OrderInfo(ctx, instantswap.TrackingRequest{OrderId: order.UUID})
 	fmt.Println("Order Info: ", orderInfo, err)
 	fmt.Println("Order Status: ", orderInfo.InternalStatus.String())
 	// when ever the trading is done, the exchange will return the transaction id in
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -33,22 +34,23 @@ func main() {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	currencies, err := exchange.GetCurrencies()
+	ctx := context.Background()
+	currencies, err := exchange.GetCurrencies(ctx)
 	fmt.Println("currencies length: ", len(currencies), err)
-	currencies, err = exchange.GetCurrenciesToPair("btc")
+	currencies, err = exchange.GetCurrenciesToPair(ctx, "btc")
 	fmt.Println("GetCurrenciesToPair length: ", len(currencies), err)
-	res, err := exchange.GetExchangeRateInfo(instantswap.ExchangeRateRequest{
+	res, err := exchange.GetExchangeRateInfo(ctx, instantswap.ExchangeRateRequest{
 		From:   "BTC",
 		To:     "DCR",
-		Amount: 5,
+		Amount: instantswap.NewAmount(5, 0),
 	})
 	fmt.Printf("%+v \n %v \n", res, err)
-	order, err := exchange.CreateOrder(instantswap.CreateOrder{
+	order, err := exchange.CreateOrder(ctx, instantswap.CreateOrder{
 		RefundAddress:   "your_btc_address", // if the trading fail, the exchange will refund here
 		Destination:     "your_dcr_address", // your received dcr address
 		FromCurrency:    "BTC",
-		OrderedAmount:   0, // use OrderedAmount or InvoicedAmount
-		InvoicedAmount:  0.5,
+		OrderedAmount:   instantswap.Amount{}, // use OrderedAmount or InvoicedAmount
+		InvoicedAmount:  instantswap.MustParseAmount("0.5"),
 		ToCurrency:      "DCR",
 		ExtraID:         "",
 		Signature:       res.Signature,
@@ -60,7 +62,7 @@ func main() {
 	// the exchange will return the rate of exchange is: order.ExchangeRate
 	// you will send btc to order.DepositAddress
 	// use OrderInfo to get order status
-	orderInfo, err := exchange.OrderInfo(order.UUID)
+	orderInfo, err := exchange.OrderInfo(ctx, instantswap.TrackingRequest{OrderId: order.UUID})
 	fmt.Println(orderInfo, err)
 
 	fmt.Println(orderInfo.InternalStatus.String())
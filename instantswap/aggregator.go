@@ -0,0 +1,514 @@
+package instantswap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AGGREGATOR_NAME = "aggregator"
+
+// DefaultSignatureTTL is how long the token of a rate routes CreateOrder when
+// the exchange does not tell the expiry of the rate.
+const DefaultSignatureTTL = 10 * time.Minute
+
+// RankedRate is the rate info returned by an exchange for an aggregated
+// request.
+type RankedRate struct {
+	Exchange string
+	ExchangeRateInfo
+	// InRange tells whether the requested amount is within the Min and Max
+	// limits of the exchange.
+	InRange bool
+	// Token is passed to CreateOrder as Signature to create the order on
+	// the exchange of the rate. It is the Signature of the signed rates, the
+	// aggregator issues one for the others.
+	Token string
+}
+
+// estimatedAmount is the amount received for the requested amount, it is
+// computed from the rate when the exchange does not estimate it.
+func (r *RankedRate) estimatedAmount(amount Amount) Amount {
+	if r.EstimatedAmount.Sign() > 0 {
+		return r.EstimatedAmount
+	}
+	return amount.Mul(r.ExchangeRate)
+}
+
+// RatesResult is the result of Aggregator.Rates.
+type RatesResult struct {
+	Request ExchangeRateRequest
+	// Rates are the rates of the exchanges which answered, best first. The
+	// rates accepting the requested amount are ranked before the others,
+	// then the rates are ranked by estimated amount.
+	Rates []RankedRate
+	// Errors are the errors of the exchanges which failed by exchange name.
+	Errors map[string]error
+	// Best is the recommended rate, it is nil when no exchange accepts the
+	// requested amount.
+	Best *RankedRate
+}
+
+// Aggregator fans out the rate requests to several exchanges and picks the
+// best rate. It implements IDExchange: CreateOrder is routed to the exchange
+// of the rate which token is passed as signature, and OrderInfo to the
+// exchange which created the order, as recorded in the order store.
+type Aggregator struct {
+	exchanges map[string]IDExchange
+	names     []string
+	// timeout bounds the requests sent to each exchange.
+	timeout time.Duration
+
+	mu sync.RWMutex
+	// signatures are the exchanges of the rate tokens until they expire.
+	signatures map[string]signedRate
+	store      OrderStore
+}
+
+type signedRate struct {
+	exchange   string
+	validUntil time.Time
+	// issued tells whether the token was issued by the aggregator, it is not
+	// passed to the exchange.
+	issued bool
+}
+
+// NewAggregator returns an aggregator of the exchanges. timeout bounds the
+// requests sent to each exchange, zero means the requests are only bound to
+// the context of the call.
+func NewAggregator(timeout time.Duration, exchanges ...IDExchange) *Aggregator {
+	a := &Aggregator{
+		exchanges:  make(map[string]IDExchange, len(exchanges)),
+		timeout:    timeout,
+		signatures: make(map[string]signedRate),
+		store:      NewMemoryOrderStore(),
+	}
+	for _, exchange := range exchanges {
+		if _, ok := a.exchanges[exchange.Name()]; !ok {
+			a.names = append(a.names, exchange.Name())
+		}
+		a.exchanges[exchange.Name()] = exchange
+	}
+	sort.Strings(a.names)
+	return a
+}
+
+// NewAggregatorFromConfigs creates the registered exchanges named in configs
+// and returns their aggregator.
+func NewAggregatorFromConfigs(configs map[string]ExchangeConfig, timeout time.Duration) (*Aggregator, error) {
+	exchanges := make([]IDExchange, 0, len(configs))
+	for name, config := range configs {
+		exchange, err := NewExchange(name, config)
+		if err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return NewAggregator(timeout, exchanges...), nil
+}
+
+// SetOrderStore sets the store recording the orders created by the
+// aggregator, the orders are kept in memory by default.
+func (a *Aggregator) SetOrderStore(store OrderStore) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.store = store
+}
+
+func (a *Aggregator) orderStore() OrderStore {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.store
+}
+
+func (a *Aggregator) Name() string {
+	return AGGREGATOR_NAME
+}
+
+// Exchanges returns the sorted names of the aggregated exchanges.
+func (a *Aggregator) Exchanges() []string {
+	return append([]string(nil), a.names...)
+}
+
+// each calls fn concurrently for every exchange, the context of each call is
+// bound to the timeout of the aggregator. It returns once all calls returned.
+func (a *Aggregator) each(ctx context.Context, fn func(ctx context.Context, name string, exchange IDExchange)) {
+	var wg sync.WaitGroup
+	for _, name := range a.names {
+		wg.Add(1)
+		go func(name string, exchange IDExchange) {
+			defer wg.Done()
+			ctx := ctx
+			if a.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, a.timeout)
+				defer cancel()
+			}
+			fn(ctx, name, exchange)
+		}(name, a.exchanges[name])
+	}
+	wg.Wait()
+}
+
+// Rates requests the rate of vars to every exchange concurrently and returns
+// the ranked rates. The tokens of the rates are remembered to route
+// CreateOrder until they expire.
+func (a *Aggregator) Rates(ctx context.Context, vars ExchangeRateRequest) RatesResult {
+	res := RatesResult{
+		Request: vars,
+		Errors:  make(map[string]error),
+	}
+	var mu sync.Mutex
+	a.each(ctx, func(ctx context.Context, name string, exchange IDExchange) {
+		info, err := exchange.GetExchangeRateInfo(ctx, vars)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			res.Errors[name] = err
+			return
+		}
+		// the limits of a reverse quote apply to the amount to send.
+		amount := vars.Amount
+		if vars.Reverse() {
+			amount = info.DepositAmount
+		}
+		res.Rates = append(res.Rates, RankedRate{
+			Exchange:         name,
+			ExchangeRateInfo: info,
+			InRange:          amount.Cmp(info.Min) >= 0 && (info.Max.IsZero() || amount.Cmp(info.Max) <= 0),
+		})
+	})
+	sort.SliceStable(res.Rates, func(i, j int) bool {
+		ri, rj := &res.Rates[i], &res.Rates[j]
+		if ri.InRange != rj.InRange {
+			return ri.InRange
+		}
+		if vars.Reverse() {
+			// the best reverse quote is the lowest amount to send
+			if c := ri.DepositAmount.Cmp(rj.DepositAmount); c != 0 {
+				return c < 0
+			}
+			return ri.Exchange < rj.Exchange
+		}
+		ai, aj := ri.estimatedAmount(vars.Amount), rj.estimatedAmount(vars.Amount)
+		if c := ai.Cmp(aj); c != 0 {
+			return c > 0
+		}
+		return ri.Exchange < rj.Exchange
+	})
+	if len(res.Rates) > 0 && res.Rates[0].InRange {
+		res.Best = &res.Rates[0]
+	}
+	a.sign(res.Rates, time.Now())
+	return res
+}
+
+// sign sets the tokens of the rates, remembers their exchanges and forgets
+// the expired ones.
+func (a *Aggregator) sign(rates []RankedRate, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for token, rate := range a.signatures {
+		if !now.Before(rate.validUntil) {
+			delete(a.signatures, token)
+		}
+	}
+	for i := range rates {
+		rate := &rates[i]
+		validUntil := rate.ValidUntil
+		if validUntil.IsZero() {
+			validUntil = now.Add(DefaultSignatureTTL)
+		}
+		if !now.Before(validUntil) {
+			continue
+		}
+		rate.Token = rate.Signature
+		if rate.Token == "" {
+			rate.Token = newToken()
+		}
+		a.signatures[rate.Token] = signedRate{
+			exchange:   rate.Exchange,
+			validUntil: validUntil,
+			issued:     rate.Signature == "",
+		}
+	}
+}
+
+// newToken returns a random token routing an unsigned rate.
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return AGGREGATOR_NAME + "-" + hex.EncodeToString(b)
+}
+
+// GetExchangeRateInfo returns the best rate of the exchanges, its Signature
+// is the token of the rate. The order of the rate is created by CreateOrder
+// with this signature.
+func (a *Aggregator) GetExchangeRateInfo(ctx context.Context, vars ExchangeRateRequest) (res ExchangeRateInfo, err error) {
+	rates := a.Rates(ctx, vars)
+	if rates.Best != nil {
+		res = rates.Best.ExchangeRateInfo
+		res.Signature = rates.Best.Token
+		return res, nil
+	}
+	if len(rates.Rates) > 0 && vars.Reverse() {
+		return res, NewError(AGGREGATOR_NAME, nil,
+			fmt.Sprintf("deposit of %v is out of the limits of every exchange", vars.ReceiveAmount))
+	}
+	if len(rates.Rates) > 0 {
+		return res, NewError(AGGREGATOR_NAME, limitsKind(vars.Amount, rates.Rates),
+			fmt.Sprintf("amount %v is out of the limits of every exchange", vars.Amount))
+	}
+	return res, a.failure("no exchange returned a rate", rates.Errors)
+}
+
+// limitsKind returns the kind of the error of an amount out of the limits of
+// all the rates, nil when it is below some limits and above others.
+func limitsKind(amount Amount, rates []RankedRate) error {
+	var below, above bool
+	for _, rate := range rates {
+		if amount.Cmp(rate.Min) < 0 {
+			below = true
+		} else {
+			above = true
+		}
+	}
+	switch {
+	case below && !above:
+		return ErrAmountBelowMin
+	case above && !below:
+		return ErrAmountAboveMax
+	}
+	return nil
+}
+
+// CreateOrder creates the order on the exchange of the rate which token is
+// vars.Signature. It fails with an error of kind ErrRateExpired when the
+// token is unknown or expired. The tokens issued by the aggregator are not
+// passed to the exchange.
+func (a *Aggregator) CreateOrder(ctx context.Context, vars CreateOrder) (res CreateResultInfo, err error) {
+	a.mu.RLock()
+	rate, ok := a.signatures[vars.Signature]
+	a.mu.RUnlock()
+	if vars.Signature == "" || !ok || !time.Now().Before(rate.validUntil) {
+		return res, NewError(AGGREGATOR_NAME, ErrRateExpired,
+			fmt.Sprintf("no valid rate for signature %q of %s-%s", vars.Signature, vars.FromCurrency, vars.ToCurrency))
+	}
+	if rate.issued {
+		vars.Signature = ""
+	}
+	return a.CreateOrderOn(ctx, rate.exchange, vars)
+}
+
+// CreateOrderOn creates the order on the named exchange and records it in the
+// order store. The order is returned along with the error when it could not
+// be recorded.
+func (a *Aggregator) CreateOrderOn(ctx context.Context, exchange string, vars CreateOrder) (res CreateResultInfo, err error) {
+	e, ok := a.exchanges[exchange]
+	if !ok {
+		return res, NewError(AGGREGATOR_NAME, nil, fmt.Sprintf("[%s] exchange is not aggregated", exchange))
+	}
+	res, err = e.CreateOrder(ctx, vars)
+	if err != nil {
+		return res, err
+	}
+	return res, a.record(exchange, vars, res)
+}
+
+// record saves the order created on exchange into the order store.
+func (a *Aggregator) record(exchange string, vars CreateOrder, res CreateResultInfo) error {
+	now := time.Now()
+	return a.orderStore().Save(StoredOrder{
+		Exchange:  exchange,
+		Request:   vars,
+		Result:    res,
+		Status:    OrderStatusNew,
+		History:   []StatusChange{{Status: OrderStatusNew, Time: now}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// OrderExchange returns the exchange which created the order, as recorded in
+// the order store.
+func (a *Aggregator) OrderExchange(orderID string) (string, bool) {
+	store := a.orderStore()
+	for _, name := range a.names {
+		if _, err := store.Get(name, orderID); err == nil {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (a *Aggregator) orderExchange(orderID string) (IDExchange, error) {
+	name, ok := a.OrderExchange(orderID)
+	if !ok {
+		return nil, NewError(AGGREGATOR_NAME, ErrOrderNotFound, fmt.Sprintf("order[%s] was not created by the aggregator", orderID))
+	}
+	return a.exchanges[name], nil
+}
+
+// OrderInfo returns the order info from the exchange which created the order.
+func (a *Aggregator) OrderInfo(ctx context.Context, req TrackingRequest) (res OrderInfoResult, err error) {
+	exchange, err := a.orderExchange(req.OrderId)
+	if err != nil {
+		return res, err
+	}
+	return exchange.OrderInfo(ctx, req)
+}
+
+// CancelOrder cancels the order on the exchange which created it.
+func (a *Aggregator) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	exchange, err := a.orderExchange(orderID)
+	if err != nil {
+		return res, err
+	}
+	return exchange.CancelOrder(ctx, orderID)
+}
+
+// RefundInfo returns the refund of the order from the exchange which created
+// it.
+func (a *Aggregator) RefundInfo(ctx context.Context, orderID string) (res RefundInfo, err error) {
+	exchange, err := a.orderExchange(orderID)
+	if err != nil {
+		return res, err
+	}
+	return exchange.RefundInfo(ctx, orderID)
+}
+
+// SubmitRefund submits the refund to the exchange which created the order.
+func (a *Aggregator) SubmitRefund(ctx context.Context, vars RefundRequest) (res RefundInfo, err error) {
+	exchange, err := a.orderExchange(vars.UUID)
+	if err != nil {
+		return res, err
+	}
+	return exchange.SubmitRefund(ctx, vars)
+}
+
+// UpdateOrder updates the order on the exchange which created it.
+func (a *Aggregator) UpdateOrder(ctx context.Context, vars UpdateOrderInfo) (res UpdateOrderResultInfo, err error) {
+	exchange, err := a.orderExchange(vars.UUID)
+	if err != nil {
+		return res, err
+	}
+	return exchange.UpdateOrder(ctx, vars)
+}
+
+// GetCurrencies returns the currencies of every exchange, each symbol and
+// network is listed once.
+func (a *Aggregator) GetCurrencies(ctx context.Context) (currencies []Currency, err error) {
+	return a.currencies(ctx, func(ctx context.Context, exchange IDExchange) ([]Currency, error) {
+		return exchange.GetCurrencies(ctx)
+	})
+}
+
+// GetCurrenciesToPair returns the currencies paired with from on any exchange.
+func (a *Aggregator) GetCurrenciesToPair(ctx context.Context, from string) (currencies []Currency, err error) {
+	return a.currencies(ctx, func(ctx context.Context, exchange IDExchange) ([]Currency, error) {
+		return exchange.GetCurrenciesToPair(ctx, from)
+	})
+}
+
+func (a *Aggregator) currencies(ctx context.Context, list func(ctx context.Context, exchange IDExchange) ([]Currency, error)) ([]Currency, error) {
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	errs := make(map[string]error)
+	var currencies []Currency
+	a.each(ctx, func(ctx context.Context, name string, exchange IDExchange) {
+		res, err := list(ctx, exchange)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[name] = err
+			return
+		}
+		for _, currency := range res {
+			key := strings.ToLower(currency.Symbol + "/" + currency.Network)
+			if !seen[key] {
+				seen[key] = true
+				currencies = append(currencies, currency)
+			}
+		}
+	})
+	if len(errs) == len(a.names) {
+		return nil, a.failure("no exchange returned currencies", errs)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		if currencies[i].Symbol != currencies[j].Symbol {
+			return currencies[i].Symbol < currencies[j].Symbol
+		}
+		return currencies[i].Network < currencies[j].Network
+	})
+	return currencies, nil
+}
+
+// QueryLimits returns the widest limits of the exchanges: the smallest Min
+// and the biggest Max, Max is 0 when an exchange has no maximum.
+func (a *Aggregator) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res QueryLimits, err error) {
+	var mu sync.Mutex
+	var found, unlimited bool
+	errs := make(map[string]error)
+	a.each(ctx, func(ctx context.Context, name string, exchange IDExchange) {
+		limits, err := exchange.QueryLimits(ctx, fromCurr, toCurr)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err != nil:
+			errs[name] = err
+			return
+		case limits == (QueryLimits{}):
+			return
+		}
+		if !found || limits.Min.Cmp(res.Min) < 0 {
+			res.Min = limits.Min
+		}
+		if limits.Max.IsZero() {
+			unlimited = true
+		} else if limits.Max.Cmp(res.Max) > 0 {
+			res.Max = limits.Max
+		}
+		found = true
+	})
+	if !found {
+		return res, a.failure("no exchange returned limits", errs)
+	}
+	if unlimited {
+		res.Max = Amount{}
+	}
+	return res, nil
+}
+
+// failure returns the error of a call which failed on every exchange, its
+// kind is the kind shared by all the errors if any.
+func (a *Aggregator) failure(message string, errs map[string]error) error {
+	var kind error
+	var details []string
+	for _, name := range a.names {
+		err, ok := errs[name]
+		if !ok {
+			continue
+		}
+		var errKind error
+		var exchangeErr *ExchangeError
+		if errors.As(err, &exchangeErr) {
+			errKind = exchangeErr.Kind
+		}
+		if len(details) == 0 {
+			kind = errKind
+		} else if kind != errKind {
+			kind = nil
+		}
+		details = append(details, name+": "+err.Error())
+	}
+	if len(details) > 0 {
+		message += ": " + strings.Join(details, "; ")
+	}
+	return NewError(AGGREGATOR_NAME, kind, message)
+}
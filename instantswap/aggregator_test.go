@@ -0,0 +1,257 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeExchange is an IDExchange answering with fixed values.
+type fakeExchange struct {
+	name   string
+	rate   ExchangeRateInfo
+	err    error
+	delay  time.Duration
+	orders []CreateOrder
+}
+
+func (f *fakeExchange) Name() string { return f.name }
+
+func (f *fakeExchange) GetCurrencies(ctx context.Context) ([]Currency, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []Currency{{Symbol: "BTC"}, {Symbol: f.name}}, nil
+}
+
+func (f *fakeExchange) GetCurrenciesToPair(ctx context.Context, from string) ([]Currency, error) {
+	return f.GetCurrencies(ctx)
+}
+
+func (f *fakeExchange) QueryLimits(ctx context.Context, fromCurr, toCurr string) (QueryLimits, error) {
+	return QueryLimits{Min: f.rate.Min, Max: f.rate.Max}, f.err
+}
+
+func (f *fakeExchange) CreateOrder(ctx context.Context, vars CreateOrder) (CreateResultInfo, error) {
+	f.orders = append(f.orders, vars)
+	return CreateResultInfo{UUID: f.name + "-order"}, nil
+}
+
+func (f *fakeExchange) UpdateOrder(ctx context.Context, vars UpdateOrderInfo) (UpdateOrderResultInfo, error) {
+	return UpdateOrderResultInfo{UUID: vars.UUID, Destination: f.name}, nil
+}
+
+func (f *fakeExchange) CancelOrder(ctx context.Context, orderID string) (string, error) {
+	return f.name, nil
+}
+
+func (f *fakeExchange) OrderInfo(ctx context.Context, req TrackingRequest) (OrderInfoResult, error) {
+	return OrderInfoResult{Status: f.name}, nil
+}
+
+func (f *fakeExchange) RefundInfo(ctx context.Context, orderID string) (RefundInfo, error) {
+	return RefundInfo{UUID: orderID, Address: f.name}, nil
+}
+
+func (f *fakeExchange) SubmitRefund(ctx context.Context, vars RefundRequest) (RefundInfo, error) {
+	return RefundInfo{UUID: vars.UUID, Address: f.name, Status: RefundStatusPending}, nil
+}
+
+func (f *fakeExchange) GetExchangeRateInfo(ctx context.Context, vars ExchangeRateRequest) (ExchangeRateInfo, error) {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return ExchangeRateInfo{}, ctx.Err()
+		}
+	}
+	return f.rate, f.err
+}
+
+func TestAggregatorRates(t *testing.T) {
+	low := &fakeExchange{name: "low", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(90, 0), Min: MustParseAmount("0.1")}}
+	high := &fakeExchange{name: "high", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(110, 0), Min: MustParseAmount("0.1"), Signature: "quote"}}
+	limited := &fakeExchange{name: "limited", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(200, 0), Min: NewAmount(5, 0)}}
+	failing := &fakeExchange{name: "failing", err: NewError("failing", ErrPairNotSupported, "pair not supported")}
+	slow := &fakeExchange{name: "slow", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(300, 0)}, delay: time.Second}
+	a := NewAggregator(50*time.Millisecond, low, high, limited, failing, slow)
+
+	vars := ExchangeRateRequest{From: "BTC", To: "DCR", Amount: NewAmount(1, 0)}
+	res := a.Rates(context.Background(), vars)
+	var ranked []string
+	for _, rate := range res.Rates {
+		ranked = append(ranked, rate.Exchange)
+	}
+	expected := []string{"high", "low", "limited"}
+	if len(ranked) != len(expected) {
+		t.Fatalf("ranked = %v, expected: %v", ranked, expected)
+	}
+	for i := range expected {
+		if ranked[i] != expected[i] {
+			t.Fatalf("ranked = %v, expected: %v", ranked, expected)
+		}
+	}
+	if res.Best == nil || res.Best.Exchange != "high" {
+		t.Fatalf("best = %v, expected: high", res.Best)
+	}
+	if !errors.Is(res.Errors["failing"], ErrPairNotSupported) {
+		t.Errorf("failing error = %v", res.Errors["failing"])
+	}
+	if !errors.Is(res.Errors["slow"], context.DeadlineExceeded) {
+		t.Errorf("slow error = %v, expected a timeout", res.Errors["slow"])
+	}
+
+	info, err := a.GetExchangeRateInfo(context.Background(), vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.EstimatedAmount.Equal(NewAmount(110, 0)) {
+		t.Errorf("EstimatedAmount = %v, expected: 110", info.EstimatedAmount)
+	}
+}
+
+func TestAggregatorReverseRates(t *testing.T) {
+	cheap := &fakeExchange{name: "cheap", rate: ExchangeRateInfo{DepositAmount: MustParseAmount("0.4"), Min: MustParseAmount("0.1")}}
+	costly := &fakeExchange{name: "costly", rate: ExchangeRateInfo{DepositAmount: MustParseAmount("0.5"), Min: MustParseAmount("0.1")}}
+	limited := &fakeExchange{name: "limited", rate: ExchangeRateInfo{DepositAmount: MustParseAmount("0.3"), Min: NewAmount(1, 0)}}
+	a := NewAggregator(time.Second, costly, cheap, limited)
+
+	vars := ExchangeRateRequest{From: "BTC", To: "DCR", ReceiveAmount: NewAmount(1000, 0)}
+	res := a.Rates(context.Background(), vars)
+	var ranked []string
+	for _, rate := range res.Rates {
+		ranked = append(ranked, rate.Exchange)
+	}
+	if len(ranked) != 3 || ranked[0] != "cheap" || ranked[1] != "costly" || ranked[2] != "limited" {
+		t.Fatalf("ranked = %v, expected: [cheap costly limited]", ranked)
+	}
+}
+
+func TestAggregatorRouting(t *testing.T) {
+	low := &fakeExchange{name: "low", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(90, 0), Signature: "low-quote"}}
+	high := &fakeExchange{name: "high", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(110, 0), Signature: "high-quote"}}
+	a := NewAggregator(0, low, high)
+	store := NewMemoryOrderStore()
+	a.SetOrderStore(store)
+	ctx := context.Background()
+
+	order := CreateOrder{FromCurrency: "BTC", ToCurrency: "DCR", InvoicedAmount: NewAmount(1, 0), Signature: "high-quote"}
+	if _, err := a.CreateOrder(ctx, order); !errors.Is(err, ErrRateExpired) {
+		t.Fatalf("CreateOrder without rate error = %v", err)
+	}
+	rate, err := a.GetExchangeRateInfo(ctx, ExchangeRateRequest{From: "btc", To: "dcr", Amount: NewAmount(1, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.Signature != "high-quote" {
+		t.Fatalf("Signature = %s, expected: high-quote", rate.Signature)
+	}
+	// the order is not routed by the last best rate of the pair
+	if _, err = a.CreateOrder(ctx, CreateOrder{FromCurrency: "BTC", ToCurrency: "DCR"}); !errors.Is(err, ErrRateExpired) {
+		t.Fatalf("CreateOrder without signature error = %v, expected: %v", err, ErrRateExpired)
+	}
+	res, err := a.CreateOrder(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(high.orders) != 1 || len(low.orders) != 0 {
+		t.Fatalf("order was not routed to the exchange of the signature")
+	}
+	if _, err = store.Get("high", res.UUID); err != nil {
+		t.Errorf("order is not recorded: %v", err)
+	}
+	if _, err = a.CreateOrderOn(ctx, "low", CreateOrder{FromCurrency: "BTC", ToCurrency: "DCR"}); err != nil || len(low.orders) != 1 {
+		t.Fatalf("CreateOrderOn(low) = %v, %d orders", err, len(low.orders))
+	}
+	info, err := a.OrderInfo(ctx, TrackingRequest{OrderId: res.UUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != "high" {
+		t.Errorf("OrderInfo routed to %s, expected: high", info.Status)
+	}
+	if _, err = a.OrderInfo(ctx, TrackingRequest{OrderId: "unknown"}); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("OrderInfo of unknown order error = %v", err)
+	}
+	update, err := a.UpdateOrder(ctx, UpdateOrderInfo{UUID: res.UUID, RefundAddress: "refund"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Destination != "high" {
+		t.Errorf("UpdateOrder routed to %s, expected: high", update.Destination)
+	}
+	if _, err = a.UpdateOrder(ctx, UpdateOrderInfo{UUID: "unknown"}); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("UpdateOrder of unknown order error = %v", err)
+	}
+	refund, err := a.SubmitRefund(ctx, RefundRequest{UUID: res.UUID, Address: "refund"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.Address != "high" {
+		t.Errorf("SubmitRefund routed to %s, expected: high", refund.Address)
+	}
+	if _, err = a.RefundInfo(ctx, "unknown"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("RefundInfo of unknown order error = %v", err)
+	}
+}
+
+func TestAggregatorFailure(t *testing.T) {
+	a := NewAggregator(0,
+		&fakeExchange{name: "a", err: NewError("a", ErrPairNotSupported, "no pair")},
+		&fakeExchange{name: "b", err: NewError("b", ErrPairNotSupported, "unknown pair")},
+	)
+	_, err := a.GetExchangeRateInfo(context.Background(), ExchangeRateRequest{From: "BTC", To: "XMR", Amount: NewAmount(1, 0)})
+	if !errors.Is(err, ErrPairNotSupported) {
+		t.Fatalf("error = %v, expected kind: %v", err, ErrPairNotSupported)
+	}
+
+	a = NewAggregator(0, &fakeExchange{name: "a", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(1, 0), Min: NewAmount(2, 0)}})
+	_, err = a.GetExchangeRateInfo(context.Background(), ExchangeRateRequest{From: "BTC", To: "XMR", Amount: NewAmount(1, 0)})
+	if !errors.Is(err, ErrAmountBelowMin) {
+		t.Fatalf("error = %v, expected kind: %v", err, ErrAmountBelowMin)
+	}
+}
+
+func TestAggregatorSignatureExpiry(t *testing.T) {
+	expired := &fakeExchange{name: "expired", rate: ExchangeRateInfo{
+		EstimatedAmount: NewAmount(110, 0),
+		Signature:       "expired-quote",
+		ValidUntil:      time.Now().Add(-time.Second),
+	}}
+	a := NewAggregator(0, expired)
+	ctx := context.Background()
+	a.Rates(ctx, ExchangeRateRequest{From: "BTC", To: "DCR", Amount: NewAmount(1, 0)})
+	if _, err := a.CreateOrder(ctx, CreateOrder{Signature: "expired-quote"}); !errors.Is(err, ErrRateExpired) {
+		t.Fatalf("CreateOrder of an expired rate error = %v, expected: %v", err, ErrRateExpired)
+	}
+
+	a.sign([]RankedRate{{Exchange: "expired", ExchangeRateInfo: ExchangeRateInfo{Signature: "old"}}}, time.Now().Add(-2*DefaultSignatureTTL))
+	a.sign(nil, time.Now())
+	if len(a.signatures) != 0 {
+		t.Errorf("signatures = %v, expected the expired ones to be forgotten", a.signatures)
+	}
+}
+
+func TestAggregatorUnsignedRouting(t *testing.T) {
+	unsigned := &fakeExchange{name: "unsigned", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(110, 0)}}
+	signed := &fakeExchange{name: "signed", rate: ExchangeRateInfo{EstimatedAmount: NewAmount(90, 0), Signature: "signed-quote"}}
+	a := NewAggregator(0, unsigned, signed)
+	ctx := context.Background()
+	rate, err := a.GetExchangeRateInfo(ctx, ExchangeRateRequest{From: "btc", To: "dcr", Amount: NewAmount(1, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate.Signature == "" {
+		t.Fatal("the unsigned best rate has no token")
+	}
+	if _, err = a.CreateOrder(ctx, CreateOrder{FromCurrency: "BTC", ToCurrency: "DCR", Signature: rate.Signature}); err != nil {
+		t.Fatal(err)
+	}
+	if len(unsigned.orders) != 1 || len(signed.orders) != 0 {
+		t.Fatalf("order was not routed to the exchange of the unsigned rate")
+	}
+	if unsigned.orders[0].Signature != "" {
+		t.Errorf("Signature = %q, expected the token to be stripped", unsigned.orders[0].Signature)
+	}
+}
@@ -0,0 +1,299 @@
+package instantswap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RatePrecision is the number of decimals of the exchange rates computed
+	// from two amounts.
+	RatePrecision = 18
+	// maxAmountExponent bounds the exponent of the parsed amounts.
+	maxAmountExponent = 1000
+)
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// Amount is an exact decimal amount, its value is coef * 10^-scale. The zero
+// value is 0. Amounts are immutable, the arithmetic methods return new
+// amounts. Two amounts must be compared with Cmp or Equal.
+type Amount struct {
+	// coef is nil for zero and never modified once set.
+	coef  *big.Int
+	scale int32
+}
+
+// NewAmount returns coef * 10^-scale, NewAmount(15, 1) is 1.5.
+func NewAmount(coef int64, scale int) Amount {
+	return newAmount(big.NewInt(coef), scale)
+}
+
+func newAmount(coef *big.Int, scale int) Amount {
+	if coef.Sign() == 0 {
+		return Amount{}
+	}
+	if scale < 0 {
+		coef = new(big.Int).Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return Amount{coef: coef, scale: int32(scale)}
+}
+
+// ParseAmount parses a decimal number like "12.5", "-0.001" or "1e-18".
+func ParseAmount(s string) (Amount, error) {
+	str := strings.TrimSpace(s)
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.Atoi(str[i+1:])
+		if err != nil || exp > maxAmountExponent || exp < -maxAmountExponent {
+			return Amount{}, errors.New("instantswap:error: invalid amount " + strconv.Quote(s))
+		}
+		str = str[:i]
+	}
+	sign := ""
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		sign, str = str[:1], str[1:]
+	}
+	integer, fraction := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		integer, fraction = str[:i], str[i+1:]
+	}
+	digits := integer + fraction
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Amount{}, errors.New("instantswap:error: invalid amount " + strconv.Quote(s))
+	}
+	coef, _ := new(big.Int).SetString(sign+digits, 10)
+	return newAmount(coef, len(fraction)-exp), nil
+}
+
+// MustParseAmount is like ParseAmount but panics on an invalid amount. It is
+// intended for constants.
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// AmountFromFloat returns the shortest decimal amount representing f. It is
+// zero for NaN and infinite values.
+func AmountFromFloat(f float64) Amount {
+	a, _ := ParseAmount(strconv.FormatFloat(f, 'g', -1, 64))
+	return a
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// coefAt returns the coefficient of a at the scale, which is not lower than
+// the scale of a.
+func (a Amount) coefAt(scale int32) *big.Int {
+	if a.coef == nil {
+		return new(big.Int)
+	}
+	if scale == a.scale {
+		return a.coef
+	}
+	return new(big.Int).Mul(a.coef, pow10(int(scale-a.scale)))
+}
+
+func maxScale(a, b Amount) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+func (a Amount) IsZero() bool {
+	return a.coef == nil
+}
+
+// Sign returns -1, 0 or +1.
+func (a Amount) Sign() int {
+	if a.coef == nil {
+		return 0
+	}
+	return a.coef.Sign()
+}
+
+// Cmp returns -1, 0 or +1 when a is lower, equal or greater than b.
+func (a Amount) Cmp(b Amount) int {
+	scale := maxScale(a, b)
+	return a.coefAt(scale).Cmp(b.coefAt(scale))
+}
+
+func (a Amount) Equal(b Amount) bool {
+	return a.Cmp(b) == 0
+}
+
+func (a Amount) Add(b Amount) Amount {
+	scale := maxScale(a, b)
+	return newAmount(new(big.Int).Add(a.coefAt(scale), b.coefAt(scale)), int(scale))
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	scale := maxScale(a, b)
+	return newAmount(new(big.Int).Sub(a.coefAt(scale), b.coefAt(scale)), int(scale))
+}
+
+func (a Amount) Mul(b Amount) Amount {
+	if a.coef == nil || b.coef == nil {
+		return Amount{}
+	}
+	return newAmount(new(big.Int).Mul(a.coef, b.coef), int(a.scale+b.scale))
+}
+
+func (a Amount) Neg() Amount {
+	if a.coef == nil {
+		return a
+	}
+	return Amount{coef: new(big.Int).Neg(a.coef), scale: a.scale}
+}
+
+func (a Amount) Abs() Amount {
+	if a.Sign() < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Div returns a / b rounded half away from zero to places decimals, it is
+// zero when b is zero.
+func (a Amount) Div(b Amount, places int) Amount {
+	if a.coef == nil || b.coef == nil {
+		return Amount{}
+	}
+	// a / b = (a.coef * 10^b.scale) / (b.coef * 10^a.scale)
+	num := new(big.Int).Mul(a.coef, pow10(int(b.scale)))
+	den := new(big.Int).Mul(b.coef, pow10(int(a.scale)))
+	return divRound(num.Mul(num, pow10(places)), den, places)
+}
+
+// divRound returns num / den * 10^-places rounded half away from zero.
+func divRound(num, den *big.Int, places int) Amount {
+	if den.Sign() < 0 {
+		num, den = new(big.Int).Neg(num), new(big.Int).Neg(den)
+	}
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	rem.Abs(rem).Lsh(rem, 1)
+	if rem.Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, bigOne)
+		} else {
+			quo.Add(quo, bigOne)
+		}
+	}
+	return newAmount(quo, places)
+}
+
+// Round returns a rounded half away from zero to places decimals.
+func (a Amount) Round(places int) Amount {
+	if a.coef == nil || int(a.scale) <= places {
+		return a
+	}
+	return divRound(a.coef, pow10(int(a.scale)-places), places)
+}
+
+// Truncate returns a rounded toward zero to places decimals.
+func (a Amount) Truncate(places int) Amount {
+	if a.coef == nil || int(a.scale) <= places {
+		return a
+	}
+	return newAmount(new(big.Int).Quo(a.coef, pow10(int(a.scale)-places)), places)
+}
+
+// Float64 returns the nearest float64 of a.
+func (a Amount) Float64() float64 {
+	if a.coef == nil {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(a.coef, pow10(int(a.scale))).Float64()
+	return f
+}
+
+// String returns a in decimal notation without trailing zeros.
+func (a Amount) String() string {
+	if a.coef == nil {
+		return "0"
+	}
+	s := a.format(int(a.scale))
+	if strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// StringFixed returns a rounded to places decimals with exactly places
+// decimals.
+func (a Amount) StringFixed(places int) string {
+	if places < 0 {
+		places = 0
+	}
+	return a.Round(places).format(places)
+}
+
+// format returns a with places decimals, places is not lower than its scale.
+func (a Amount) format(places int) string {
+	digits := new(big.Int).Abs(a.coefAt(int32(places))).String()
+	if places > 0 {
+		if len(digits) <= places {
+			digits = strings.Repeat("0", places-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-places] + "." + digits[len(digits)-places:]
+	}
+	if a.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalJSON encodes a as a JSON string so no precision is lost.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON decodes a JSON string or number, null and "" are zero.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*a = Amount{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return a.UnmarshalText([]byte(s))
+	}
+	return a.UnmarshalText(data)
+}
+
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes a decimal number, "" is zero.
+func (a *Amount) UnmarshalText(text []byte) error {
+	if len(bytes.TrimSpace(text)) == 0 {
+		*a = Amount{}
+		return nil
+	}
+	parsed, err := ParseAmount(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
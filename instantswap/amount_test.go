@@ -0,0 +1,103 @@
+package instantswap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"0", "0"},
+		{"12.5", "12.5"},
+		{"-0.001", "-0.001"},
+		{"+7", "7"},
+		{".5", "0.5"},
+		{"1.", "1"},
+		{"0.500000000000000000", "0.5"},
+		{"1e-18", "0.000000000000000001"},
+		{"1.5E3", "1500"},
+		{"123456789012345678.123456789012345678", "123456789012345678.123456789012345678"},
+	}
+	for _, test := range tests {
+		a, err := ParseAmount(test.in)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) error: %v", test.in, err)
+			continue
+		}
+		if got := a.String(); got != test.out {
+			t.Errorf("ParseAmount(%q) = %s, expected: %s", test.in, got, test.out)
+		}
+	}
+	for _, in := range []string{"", ".", "-", "1.2.3", "abc", "1e", "1e5000", "0x10"} {
+		if _, err := ParseAmount(in); err == nil {
+			t.Errorf("ParseAmount(%q) expected an error", in)
+		}
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a := MustParseAmount("0.1")
+	b := MustParseAmount("0.2")
+	if sum := a.Add(b); !sum.Equal(MustParseAmount("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	if diff := a.Sub(b); diff.String() != "-0.1" || diff.Sign() != -1 {
+		t.Errorf("0.1 - 0.2 = %s", diff)
+	}
+	if prod := MustParseAmount("1.5").Mul(MustParseAmount("-2.25")); prod.String() != "-3.375" {
+		t.Errorf("1.5 * -2.25 = %s", prod)
+	}
+	if zero := a.Sub(a); !zero.IsZero() || zero != (Amount{}) {
+		t.Errorf("0.1 - 0.1 = %#v, expected the zero value", zero)
+	}
+	if quo := NewAmount(2, 0).Div(NewAmount(3, 0), 8); quo.String() != "0.66666667" {
+		t.Errorf("2 / 3 = %s", quo)
+	}
+	if quo := NewAmount(-1, 0).Div(NewAmount(8, 0), 2); quo.String() != "-0.13" {
+		t.Errorf("-1 / 8 = %s", quo)
+	}
+	if quo := NewAmount(1, 0).Div(Amount{}, 8); !quo.IsZero() {
+		t.Errorf("1 / 0 = %s", quo)
+	}
+	if a.Cmp(b) >= 0 || b.Cmp(a) <= 0 || !NewAmount(10, 1).Equal(NewAmount(1, 0)) {
+		t.Error("wrong comparison")
+	}
+	if s := MustParseAmount("1.005").StringFixed(2); s != "1.01" {
+		t.Errorf("StringFixed(2) = %s", s)
+	}
+	if s := MustParseAmount("-1.239").Truncate(2).StringFixed(4); s != "-1.2300" {
+		t.Errorf("Truncate(2) = %s", s)
+	}
+	if s := AmountFromFloat(0.1).String(); s != "0.1" {
+		t.Errorf("AmountFromFloat(0.1) = %s", s)
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	var v struct {
+		A Amount `json:"a"`
+		B Amount `json:"b"`
+		C Amount `json:"c"`
+		D Amount `json:"d"`
+	}
+	data := []byte(`{"a": "1.000000000000000001", "b": 0.00000001, "c": null, "d": ""}`)
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "1.000000000000000001" || v.B.String() != "0.00000001" || !v.C.IsZero() || !v.D.IsZero() {
+		t.Fatalf("decoded = %v %v %v %v", v.A, v.B, v.C, v.D)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"a":"1.000000000000000001","b":"0.00000001","c":"0","d":"0"}`
+	if string(out) != expected {
+		t.Errorf("encoded = %s, expected: %s", out, expected)
+	}
+	if err := json.Unmarshal([]byte(`{"a": "1,5"}`), &v); err == nil {
+		t.Error("expected an error for an invalid amount")
+	}
+}
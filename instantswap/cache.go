@@ -0,0 +1,246 @@
+package instantswap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheTTL            = 10 * time.Minute
+	DefaultCacheRefreshTimeout = 30 * time.Second
+)
+
+// CacheConfig configures a Cache.
+type CacheConfig struct {
+	// TTL is the age after which an entry is stale. A stale entry is still
+	// returned while it is refreshed in the background. It defaults to
+	// DefaultCacheTTL.
+	TTL time.Duration
+	// RefreshTimeout bounds the background refreshes. It defaults to
+	// DefaultCacheRefreshTimeout.
+	RefreshTimeout time.Duration
+}
+
+// Cache memoizes the values fetched from the exchanges by key. The missing
+// entries are fetched by the caller, once for all the concurrent callers. The
+// stale entries are returned and refreshed in the background, an entry which
+// fails to refresh keeps its last good value.
+type Cache struct {
+	conf   CacheConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// now is replaced by the tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	stopped bool
+}
+
+type cacheEntry struct {
+	value   interface{}
+	fetched time.Time
+	// ok tells whether value was fetched once.
+	ok bool
+	// err is the error of the last fetch.
+	err error
+	// loading is closed when the running fetch is done, it is nil when no
+	// fetch is running.
+	loading chan struct{}
+}
+
+// NewCache returns a cache, Stop must be called to stop its background
+// refreshes.
+func NewCache(conf CacheConfig) *Cache {
+	if conf.TTL <= 0 {
+		conf.TTL = DefaultCacheTTL
+	}
+	if conf.RefreshTimeout <= 0 {
+		conf.RefreshTimeout = DefaultCacheRefreshTimeout
+	}
+	c := &Cache{
+		conf:    conf,
+		now:     time.Now,
+		entries: make(map[string]*cacheEntry),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	return c
+}
+
+// Get returns the value of key, fetch is called when the entry is missing or
+// stale. It returns the error of fetch only when there is no value to serve.
+func (c *Cache) Get(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[key] = e
+	}
+	if e.ok {
+		if e.loading == nil && !c.stopped && c.now().Sub(e.fetched) >= c.conf.TTL {
+			c.refresh(e, fetch)
+		}
+		value := e.value
+		c.mu.Unlock()
+		return value, nil
+	}
+	if loading := e.loading; loading != nil {
+		c.mu.Unlock()
+		select {
+		case <-loading:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if e.ok {
+			return e.value, nil
+		}
+		return nil, e.err
+	}
+	e.loading = make(chan struct{})
+	c.mu.Unlock()
+
+	value, err := fetch(ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store(e, value, err)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// refresh fetches the stale entry e in the background, c.mu must be held.
+func (c *Cache) refresh(e *cacheEntry, fetch func(ctx context.Context) (interface{}, error)) {
+	e.loading = make(chan struct{})
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ctx, cancel := context.WithTimeout(c.ctx, c.conf.RefreshTimeout)
+		defer cancel()
+		value, err := fetch(ctx)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.store(e, value, err)
+	}()
+}
+
+// store records the result of a fetch of e, c.mu must be held.
+func (c *Cache) store(e *cacheEntry, value interface{}, err error) {
+	e.err = err
+	if err == nil {
+		e.value = value
+		e.fetched = c.now()
+		e.ok = true
+	}
+	close(e.loading)
+	e.loading = nil
+}
+
+// Err returns the error of the last fetch of key, it is set while a stale
+// value is served because the exchange fails.
+func (c *Cache) Err(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		return e.err
+	}
+	return nil
+}
+
+// Invalidate removes the entry of key, the next Get fetches it again.
+func (c *Cache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && e.loading == nil {
+		delete(c.entries, key)
+	}
+}
+
+// Stop cancels the background refreshes and waits for them. The cache keeps
+// serving its entries and fetches the missing ones, but no longer refreshes
+// the stale ones.
+func (c *Cache) Stop() {
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.cancel()
+	c.wg.Wait()
+}
+
+type cacheKey struct{}
+
+// WithCache attaches cache to ctx, the exchanges memoize the metadata they
+// fetch while serving a request, like the limits of a coin, with Cached.
+func WithCache(ctx context.Context, cache *Cache) context.Context {
+	return context.WithValue(ctx, cacheKey{}, cache)
+}
+
+// Cached returns the value of key from the cache attached to ctx, it calls
+// fetch directly when ctx has no cache.
+func Cached(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if cache, ok := ctx.Value(cacheKey{}).(*Cache); ok && cache != nil {
+		return cache.Get(ctx, key, fetch)
+	}
+	return fetch(ctx)
+}
+
+// CachedExchange is an IDExchange which memoizes the currency and pair lists
+// of an exchange, and the metadata the exchange fetches with Cached.
+type CachedExchange struct {
+	IDExchange
+	cache *Cache
+}
+
+// NewCachedExchange wraps exchange with a cache, Stop must be called to
+// release it.
+func NewCachedExchange(exchange IDExchange, conf CacheConfig) *CachedExchange {
+	return &CachedExchange{IDExchange: exchange, cache: NewCache(conf)}
+}
+
+// Capabilities returns the capabilities of the wrapped exchange.
+func (c *CachedExchange) Capabilities() Capabilities {
+	return ExchangeCapabilities(c.IDExchange)
+}
+
+// Cache returns the cache of the exchange.
+func (c *CachedExchange) Cache() *Cache {
+	return c.cache
+}
+
+// Stop stops the background refreshes of the cache.
+func (c *CachedExchange) Stop() {
+	c.cache.Stop()
+}
+
+func (c *CachedExchange) GetCurrencies(ctx context.Context) ([]Currency, error) {
+	return c.currencies(ctx, "currencies", func(ctx context.Context) ([]Currency, error) {
+		return c.IDExchange.GetCurrencies(ctx)
+	})
+}
+
+func (c *CachedExchange) GetCurrenciesToPair(ctx context.Context, from string) ([]Currency, error) {
+	return c.currencies(ctx, "pairs/"+CanonicalSymbol(from), func(ctx context.Context) ([]Currency, error) {
+		return c.IDExchange.GetCurrenciesToPair(ctx, from)
+	})
+}
+
+func (c *CachedExchange) GetExchangeRateInfo(ctx context.Context, vars ExchangeRateRequest) (ExchangeRateInfo, error) {
+	return c.IDExchange.GetExchangeRateInfo(WithCache(ctx, c.cache), vars)
+}
+
+// currencies returns a copy of the cached list so the callers can not modify
+// it.
+func (c *CachedExchange) currencies(ctx context.Context, key string, fetch func(ctx context.Context) ([]Currency, error)) ([]Currency, error) {
+	value, err := c.cache.Get(WithCache(ctx, c.cache), key, func(ctx context.Context) (interface{}, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	currencies := value.([]Currency)
+	return append([]Currency(nil), currencies...), nil
+}
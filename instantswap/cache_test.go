@@ -0,0 +1,119 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingExchange is a fakeExchange counting the currency requests.
+type countingExchange struct {
+	fakeExchange
+	calls int32
+}
+
+func (c *countingExchange) GetCurrencies(ctx context.Context) ([]Currency, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.fakeExchange.GetCurrencies(ctx)
+}
+
+func TestCacheGet(t *testing.T) {
+	c := NewCache(CacheConfig{TTL: time.Minute})
+	defer c.Stop()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	var calls int32
+	fetch := func(ctx context.Context) (interface{}, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Get(context.Background(), "key", fetch); err != nil || v != 1 {
+				t.Errorf("Get = %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("concurrent misses fetched %d times", calls)
+	}
+
+	// a stale entry is served while it is refreshed
+	now = now.Add(2 * time.Minute)
+	if v, _ := c.Get(context.Background(), "key", fetch); v != 1 {
+		t.Errorf("stale Get = %v, expected: 1", v)
+	}
+	c.wg.Wait()
+	if v, _ := c.Get(context.Background(), "key", fetch); v != 2 {
+		t.Errorf("refreshed Get = %v, expected: 2", v)
+	}
+
+	// the last good value is kept when the exchange fails
+	down := errors.New("down")
+	failing := func(ctx context.Context) (interface{}, error) {
+		return nil, down
+	}
+	now = now.Add(2 * time.Minute)
+	c.Get(context.Background(), "key", failing)
+	c.wg.Wait()
+	if v, err := c.Get(context.Background(), "key", failing); err != nil || v != 2 {
+		t.Errorf("Get = %v, %v, expected the last good value", v, err)
+	}
+	if !errors.Is(c.Err("key"), down) {
+		t.Errorf("Err = %v, expected: %v", c.Err("key"), down)
+	}
+	if _, err := c.Get(context.Background(), "missing", failing); !errors.Is(err, down) {
+		t.Errorf("Get missing = %v, expected: %v", err, down)
+	}
+}
+
+func TestCached(t *testing.T) {
+	var calls int
+	fetch := func(ctx context.Context) (interface{}, error) {
+		calls++
+		return "coin", nil
+	}
+	Cached(context.Background(), "coin", fetch)
+	Cached(context.Background(), "coin", fetch)
+	if calls != 2 {
+		t.Errorf("fetched %d times without cache, expected: 2", calls)
+	}
+	c := NewCache(CacheConfig{})
+	defer c.Stop()
+	ctx := WithCache(context.Background(), c)
+	Cached(ctx, "coin", fetch)
+	Cached(ctx, "coin", fetch)
+	if calls != 3 {
+		t.Errorf("fetched %d times with cache, expected: 3", calls)
+	}
+}
+
+func TestCachedExchange(t *testing.T) {
+	inner := &countingExchange{fakeExchange: fakeExchange{name: "inner"}}
+	exchange := NewCachedExchange(inner, CacheConfig{})
+	defer exchange.Stop()
+	for i := 0; i < 3; i++ {
+		currencies, err := exchange.GetCurrencies(context.Background())
+		if err != nil || len(currencies) != 2 {
+			t.Fatalf("GetCurrencies = %v, %v", currencies, err)
+		}
+		currencies[0].Symbol = "modified"
+	}
+	if inner.calls != 1 {
+		t.Errorf("GetCurrencies fetched %d times, expected: 1", inner.calls)
+	}
+	currencies, _ := exchange.GetCurrencies(context.Background())
+	if currencies[0].Symbol != "BTC" {
+		t.Errorf("cached list was modified by a caller: %v", currencies)
+	}
+	if exchange.Name() != "inner" {
+		t.Errorf("Name = %s, expected: inner", exchange.Name())
+	}
+}
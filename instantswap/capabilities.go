@@ -0,0 +1,90 @@
+package instantswap
+
+import "errors"
+
+// ErrNotSupported is the kind of the errors returned by the methods an
+// exchange does not support, as declared by its Capabilities.
+var ErrNotSupported = errors.New("not supported")
+
+// NotSupportedError returns the error of a method the exchange does not
+// support.
+func NotSupportedError(exchange, method string) error {
+	return NewError(exchange, ErrNotSupported, method+" is not supported")
+}
+
+// KYCPolicy tells when an exchange asks its users to verify their identity.
+type KYCPolicy int
+
+const (
+	KYCUnknown KYCPolicy = iota
+	// KYCNotRequired exchanges never ask for an identity verification.
+	KYCNotRequired
+	// KYCOnRisk exchanges may hold the orders flagged by their AML checks
+	// until the identity is verified.
+	KYCOnRisk
+	// KYCRequired exchanges verify the identity before any order.
+	KYCRequired
+)
+
+func (k KYCPolicy) String() string {
+	switch k {
+	case KYCNotRequired:
+		return "Not required"
+	case KYCOnRisk:
+		return "On risk"
+	case KYCRequired:
+		return "Required"
+	default:
+		return "Unknown"
+	}
+}
+
+// Capabilities are the features of an exchange implementation. The methods
+// of IDExchange which are not supported return an error of kind
+// ErrNotSupported.
+type Capabilities struct {
+	// FixedRate exchanges guarantee the rate of the created orders.
+	FixedRate bool
+	// FloatingRate exchanges create orders at the market rate when the
+	// deposit is received.
+	FloatingRate bool
+	// ReverseQuote exchanges accept ExchangeRateRequest.ReceiveAmount and
+	// the reverse orders of CreateOrder.
+	ReverseQuote bool
+	// Networks exchanges accept ExchangeRateRequest.FromNetwork and
+	// ToNetwork.
+	Networks bool
+	// Limits exchanges implement QueryLimits.
+	Limits bool
+	// CurrenciesToPair exchanges implement GetCurrenciesToPair.
+	CurrenciesToPair bool
+	// Cancel exchanges implement CancelOrder.
+	Cancel bool
+	// Update exchanges implement UpdateOrder.
+	Update bool
+	// Refund exchanges implement RefundInfo and SubmitRefund.
+	Refund bool
+	// RefundAddress exchanges use CreateOrder.RefundAddress.
+	RefundAddress bool
+	// ExtraID exchanges use the memo or destination tag of the orders.
+	ExtraID bool
+	// Affiliate exchanges use ExchangeConfig.AffiliateId.
+	Affiliate bool
+	// RequiresApiKey exchanges fail without ExchangeConfig.ApiKey.
+	RequiresApiKey bool
+	KYC            KYCPolicy
+}
+
+// Capable is implemented by the exchanges declaring their capabilities.
+type Capable interface {
+	Capabilities() Capabilities
+}
+
+// ExchangeCapabilities returns the capabilities of exchange, the zero value
+// when it does not declare them.
+func ExchangeCapabilities(exchange IDExchange) Capabilities {
+	if capable, ok := exchange.(Capable); ok {
+		return capable.Capabilities()
+	}
+	return Capabilities{}
+}
@@ -1,15 +1,16 @@
 package instantswap
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httputil"
 	"strings"
+	"sync"
 	"time"
-
-	"github.com/vibros68/instantswap/instantswap/utils"
 )
 
 const (
@@ -23,48 +24,85 @@ type Client struct {
 	httpClient    *http.Client
 	conf          *ExchangeConfig
 	handleRequest CustomReqFunc
+	parseError    ErrorFunc
+	apiBase       string
+	rateLimit     RateLimit
+	limiterOnce   sync.Once
+	limiter       *limiter
 }
 
 // NewClient return a new HTTP client
 func NewClient(exchange string, conf *ExchangeConfig, handleRequests ...CustomReqFunc) (c *Client) {
+	httpClient := conf.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: conf.Transport}
+	}
 	client := &Client{
 		exchange:   exchange,
 		conf:       conf,
-		httpClient: &http.Client{},
+		httpClient: httpClient,
 	}
+	client.SetDefaultRateLimit(DefaultRateLimit)
 	if len(handleRequests) >= 1 {
 		client.handleRequest = handleRequests[0]
 	}
 	return client
 }
 
-func (c *Client) doTimeoutRequest(timer *time.Timer, req *http.Request) (*http.Response, error) {
-	// Do the request in the background so we can check the timeout
-	type result struct {
-		resp *http.Response
-		err  error
-	}
-	done := make(chan result, 1)
-	go func() {
-		if c.conf.Debug {
-			c.dumpRequest(req)
-		}
-		resp, err := c.httpClient.Do(req)
-		if c.conf.Debug {
-			c.dumpResponse(resp)
+// HTTPClient returns the http client used to send requests.
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
+}
+
+// SetErrorFunc sets the function reading the error payloads of the exchange,
+// it is used to build the ExchangeError of a failed response.
+func (c *Client) SetErrorFunc(parseError ErrorFunc) {
+	c.parseError = parseError
+}
+
+// SetDefaultApiBase sets the default api endpoint of the exchange, only this
+// endpoint is replaced by ExchangeConfig.ApiBase. The exchanges using several
+// endpoints set it to keep the other ones.
+func (c *Client) SetDefaultApiBase(apibase string) {
+	c.apiBase = apibase
+}
+
+// SetDefaultRateLimit sets the rate limit of the exchange, it is used when
+// ExchangeConfig.RateLimit is not set. The limiter is shared by all the
+// clients of the exchange using the same api key, it is created with the
+// limit of the first client sending a request.
+func (c *Client) SetDefaultRateLimit(limit RateLimit) {
+	c.rateLimit = limit
+}
+
+// rateLimiter returns the shared limiter of the client, nil when the limiter
+// is disabled.
+func (c *Client) rateLimiter() *limiter {
+	c.limiterOnce.Do(func() {
+		limit := c.rateLimit
+		if c.conf.RateLimit != (RateLimit{}) {
+			limit = c.conf.RateLimit
 		}
-		done <- result{resp, err}
-	}()
-	// Wait for the read or the timeout
-	select {
-	case r := <-done:
-		return r.resp, r.err
-	case <-timer.C:
-		return nil, fmt.Errorf("timeout on reading data from [%s] api", c.exchange)
+		c.limiter = sharedLimiter(c.exchange, c.conf.ApiKey, limit)
+	})
+	return c.limiter
+}
+
+func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
+	if c.conf.Debug {
+		c.dumpRequest(req)
+	}
+	resp, err := c.httpClient.Do(req)
+	if c.conf.Debug {
+		c.dumpResponse(resp)
 	}
+	if err != nil && errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timeout on reading data from [%s] api: %w", c.exchange, req.Context().Err())
+	}
+	return resp, err
 }
 
-func (c Client) dumpRequest(r *http.Request) {
+func (c *Client) dumpRequest(r *http.Request) {
 	if r == nil {
 		log.Print("dumpReq ok: <nil>")
 		return
@@ -77,7 +115,7 @@ func (c Client) dumpRequest(r *http.Request) {
 	}
 }
 
-func (c Client) dumpResponse(r *http.Response) {
+func (c *Client) dumpResponse(r *http.Response) {
 	if r == nil {
 		log.Print("dumpResponse ok: <nil>")
 		return
@@ -90,17 +128,73 @@ func (c Client) dumpResponse(r *http.Response) {
 	}
 }
 
-// Do do prepare and process HTTP request to API
-func (c *Client) Do(apibase, method, resource string, payload string, authNeeded bool) (response []byte, err error) {
-	var connectTimer = time.NewTimer(defaultHttpClientTimeout * time.Second)
+// Do do prepare and process HTTP request to API. The request is bound to ctx,
+// when ctx has no deadline the default client timeout is applied.
+// apibase is replaced by ExchangeConfig.ApiBase when it is set, unless it is
+// not the default api base set with SetDefaultApiBase.
+// The failed requests are retried following ExchangeConfig.Retry, every
+// attempt waits for the rate limiter of the client.
+func (c *Client) Do(ctx context.Context, apibase, method, resource string, payload string, authNeeded bool) (response []byte, err error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultHttpClientTimeout*time.Second)
+		defer cancel()
+	}
+	if c.conf.ApiBase != "" && (c.apiBase == "" || apibase == c.apiBase) {
+		apibase = c.conf.ApiBase
+		if !strings.HasSuffix(apibase, "/") {
+			apibase += "/"
+		}
+	}
 	var rawurl string
 	if strings.HasPrefix(resource, "http") {
 		rawurl = resource
 	} else {
 		rawurl = fmt.Sprintf("%s%s", apibase, resource)
 	}
-	var req *http.Request
-	req, err = http.NewRequest(method, rawurl, strings.NewReader(payload))
+	policy := c.conf.Retry.withDefaults()
+	for attempt := 1; ; attempt++ {
+		if limiter := c.rateLimiter(); limiter != nil {
+			if waitErr := limiter.Wait(ctx); waitErr != nil {
+				if err == nil {
+					err = waitErr
+				}
+				return response, err
+			}
+		}
+		var req *http.Request
+		req, err = c.newRequest(ctx, method, rawurl, payload)
+		if err != nil {
+			return nil, err
+		}
+		var resp *http.Response
+		resp, response, err = c.send(req)
+		if err == nil && resp.StatusCode >= 300 {
+			err = ResponseError(c.exchange, resp.StatusCode, response, c.parseError)
+		}
+		if err == nil || attempt >= policy.MaxAttempts || !shouldRetry(ctx, method, resp) {
+			return response, err
+		}
+		delay := policy.backoff(attempt)
+		if after, ok := retryAfter(resp); ok {
+			if after > policy.MaxBackoff {
+				return response, err
+			}
+			delay = after
+		}
+		if sleep(ctx, delay) != nil {
+			return response, err
+		}
+	}
+}
+
+// newRequest returns a new request of every attempt, the custom request
+// function is called again so the requests may be signed with a new nonce.
+func (c *Client) newRequest(ctx context.Context, method, rawurl, payload string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawurl, strings.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
 	if method == "POST" || method == "PUT" {
 		req.Header.Add("Content-Type", "application/json;charset=utf-8")
 		req.Header.Set("Accept", "application/json")
@@ -111,10 +205,15 @@ func (c *Client) Do(apibase, method, resource string, payload string, authNeeded
 			return nil, err
 		}
 	}
+	return req, nil
+}
 
-	resp, err := c.doTimeoutRequest(connectTimer, req)
+// send sends the request and reads its response. The returned response is
+// nil when the request failed, its body is already read and closed.
+func (c *Client) send(req *http.Request) (resp *http.Response, response []byte, err error) {
+	resp, err = c.doRequest(req)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
 
 	defer resp.Body.Close()
@@ -130,24 +229,8 @@ func (c *Client) Do(apibase, method, resource string, payload string, authNeeded
 		}
 		fmt.Printf("\n|*** END RESPONSE ***|\n")
 	}
-
 	if err != nil {
-		return response, err
-	}
-	if resp.StatusCode >= 300 {
-		if resp.StatusCode == http.StatusTooManyRequests {
-			return response, TooManyRequestsError
-		}
-		var errStr string
-		responseStr := string(response)
-		if responseStr != "" {
-			if strings.Contains(strings.ToLower(responseStr), "<body>") {
-				responseStr = utils.GetStringBefore(responseStr, "<body>")
-			}
-		}
-		res := "'" + responseStr + "'"
-		errStr = "\nexchangeclient:error:" + resp.Status + ":" + res
-		err = fmt.Errorf(errStr)
+		return nil, response, err
 	}
-	return response, err
+	return resp, response, nil
 }
@@ -0,0 +1,94 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		idempotent    bool
+		notIdempotent bool
+		status        int
+		retryAfter    string
+		policy        RetryPolicy
+		calls         int32
+		kind          error
+	}{
+		{name: "get unavailable", method: http.MethodGet, status: http.StatusServiceUnavailable, calls: 3},
+		{name: "post unavailable", method: http.MethodPost, status: http.StatusServiceUnavailable, calls: 1, kind: ErrExchangeUnavailable},
+		{name: "idempotent post unavailable", method: http.MethodPost, idempotent: true, status: http.StatusServiceUnavailable, calls: 3},
+		{name: "not idempotent get unavailable", method: http.MethodGet, notIdempotent: true, status: http.StatusServiceUnavailable, calls: 1, kind: ErrExchangeUnavailable},
+		{name: "post too many requests", method: http.MethodPost, status: http.StatusTooManyRequests, retryAfter: "0", calls: 3},
+		{name: "retry after too long", method: http.MethodGet, status: http.StatusTooManyRequests, retryAfter: "3600", calls: 1, kind: TooManyRequestsError},
+		{name: "bad request", method: http.MethodGet, status: http.StatusBadRequest, calls: 1},
+		{name: "no retry", method: http.MethodGet, status: http.StatusServiceUnavailable, policy: RetryPolicy{MaxAttempts: 1}, calls: 1, kind: ErrExchangeUnavailable},
+		{name: "attempts exhausted", method: http.MethodGet, status: http.StatusServiceUnavailable, policy: RetryPolicy{MaxAttempts: 2}, calls: 2, kind: ErrExchangeUnavailable},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// the third attempt succeeds
+				if atomic.AddInt32(&calls, 1) < 3 {
+					if test.retryAfter != "" {
+						w.Header().Set("Retry-After", test.retryAfter)
+					}
+					w.WriteHeader(test.status)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+			policy := test.policy
+			policy.MinBackoff = time.Millisecond
+			client := NewClient("test", &ExchangeConfig{Retry: policy, RateLimit: RateLimit{Rate: -1}})
+			ctx := context.Background()
+			if test.idempotent {
+				ctx = WithIdempotent(ctx)
+			}
+			if test.notIdempotent {
+				ctx = WithNotIdempotent(ctx)
+			}
+			body, err := client.Do(ctx, server.URL+"/", test.method, "resource", "{}", false)
+			if calls != test.calls {
+				t.Errorf("calls = %d, expected: %d", calls, test.calls)
+			}
+			if test.calls == 3 {
+				if err != nil || string(body) != "ok" {
+					t.Errorf("body = %s, err = %v", body, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if test.kind != nil && !errors.Is(err, test.kind) {
+				t.Errorf("error = %v, expected kind: %v", err, test.kind)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if _, ok := retryAfter(resp); ok {
+		t.Error("retryAfter without header")
+	}
+	resp.Header.Set("Retry-After", "2")
+	if delay, ok := retryAfter(resp); !ok || delay != 2*time.Second {
+		t.Errorf("retryAfter = %v, %v", delay, ok)
+	}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	if delay, ok := retryAfter(resp); !ok || delay <= 58*time.Second || delay > time.Minute {
+		t.Errorf("retryAfter = %v, %v", delay, ok)
+	}
+}
@@ -1,7 +1,141 @@
 package instantswap
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vibros68/instantswap/instantswap/utils"
+)
 
 var (
 	TooManyRequestsError = fmt.Errorf("exchangeclient:error:429 Too Many Requests")
+
+	ErrPairNotSupported    = errors.New("pair not supported")
+	ErrAmountBelowMin      = errors.New("amount below minimum")
+	ErrAmountAboveMax      = errors.New("amount above maximum")
+	ErrInvalidAddress      = errors.New("invalid address")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrAuth                = errors.New("authentication failed")
+	ErrRateExpired         = errors.New("rate expired")
+	ErrSlippageExceeded    = errors.New("slippage exceeded")
+	ErrRefundNotEligible   = errors.New("refund not eligible")
+	ErrExchangeUnavailable = errors.New("exchange unavailable")
 )
+
+// ExchangeError is an error returned by an exchange. Kind is one of the Err
+// values of this package, or nil when the error could not be classified, so
+// errors.Is can be used to test it.
+type ExchangeError struct {
+	Exchange   string
+	Kind       error
+	StatusCode int
+	Body       []byte
+	Message    string
+}
+
+func (e *ExchangeError) Error() string {
+	message := e.Message
+	if message == "" && e.Kind != nil {
+		message = e.Kind.Error()
+	}
+	if e.StatusCode >= 300 {
+		return fmt.Sprintf("%s:error:%d %s: %s", e.Exchange, e.StatusCode, http.StatusText(e.StatusCode), message)
+	}
+	return fmt.Sprintf("%s:error: %s", e.Exchange, message)
+}
+
+func (e *ExchangeError) Unwrap() error {
+	return e.Kind
+}
+
+// NewError returns an error of kind for the exchange.
+func NewError(exchange string, kind error, message string) error {
+	return &ExchangeError{Exchange: exchange, Kind: kind, Message: message}
+}
+
+// ErrorFunc reads the kind and the message of an error payload returned by an
+// exchange. It returns an empty message when body is not an error payload.
+type ErrorFunc func(statusCode int, body []byte) (kind error, message string)
+
+// ResponseError returns the error of an exchange response, the kind and the
+// message are read from body with parse. When parse does not know the kind it
+// is guessed from the message and then from the status code. It returns nil
+// for a succeeded response without an error payload.
+func ResponseError(exchange string, statusCode int, body []byte, parse ErrorFunc) error {
+	var kind error
+	var message string
+	if parse != nil {
+		kind, message = parse(statusCode, body)
+	}
+	if message == "" {
+		if statusCode < 300 {
+			return nil
+		}
+		message = string(body)
+		if strings.Contains(strings.ToLower(message), "<body>") {
+			message = utils.GetStringBefore(message, "<body>")
+		}
+		message = "'" + message + "'"
+	} else if kind == nil {
+		kind = ErrorKind(message)
+	}
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		kind = TooManyRequestsError
+	case kind == nil:
+		kind = statusKind(statusCode)
+	}
+	return &ExchangeError{
+		Exchange:   exchange,
+		Kind:       kind,
+		StatusCode: statusCode,
+		Body:       body,
+		Message:    message,
+	}
+}
+
+// ErrorKind guesses the kind of an error from the message returned by an
+// exchange, it returns nil when the message is not known.
+func ErrorKind(message string) error {
+	message = strings.ToLower(message)
+	switch {
+	case containsAny(message, "api key", "apikey", "api_key", "unauthorized", "forbidden", "authenticat"):
+		return ErrAuth
+	case containsAny(message, "expired"):
+		return ErrRateExpired
+	case containsAny(message, "address"):
+		return ErrInvalidAddress
+	case containsAny(message, "minimum", "min amount", "too small", "too low", "less than"):
+		return ErrAmountBelowMin
+	case containsAny(message, "maximum", "max amount", "too big", "too large", "too high", "greater than", "exceeds"):
+		return ErrAmountAboveMax
+	case containsAny(message, "pair", "currency", "coin", "not supported", "unsupported"):
+		return ErrPairNotSupported
+	case containsAny(message, "not found", "does not exist"):
+		return ErrOrderNotFound
+	case containsAny(message, "maintenance", "unavailable", "temporarily"):
+		return ErrExchangeUnavailable
+	}
+	return nil
+}
+
+func statusKind(statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrAuth
+	case statusCode >= http.StatusInternalServerError:
+		return ErrExchangeUnavailable
+	}
+	return nil
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
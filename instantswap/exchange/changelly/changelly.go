@@ -1,9 +1,9 @@
 package changelly
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +25,8 @@ func init() {
 // New return a Changelly api client
 func New(conf instantswap.ExchangeConfig) (*Changelly, error) {
 	client := instantswap.NewClient(LIBNAME, &conf)
+	client.SetErrorFunc(parseError)
+	client.SetDefaultRateLimit(instantswap.RateLimit{Rate: 1, Burst: 1})
 	return &Changelly{
 		client: client,
 		conf:   &conf,
@@ -42,6 +44,20 @@ func (c *Changelly) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by changelly.
+func (c *Changelly) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:      true,
+		FloatingRate:   true,
+		ReverseQuote:   true,
+		Limits:         true,
+		RefundAddress:  true,
+		ExtraID:        true,
+		RequiresApiKey: true,
+		KYC:            instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (c *Changelly) SetDebug(enable bool) {
 	c.conf.Debug = enable
@@ -52,12 +68,30 @@ func handleErr(r json.RawMessage) (err error) {
 		return err
 	}
 	if errorVal.Message != "" {
-		return fmt.Errorf("%s:error: %s", LIBNAME, errorVal.Message)
+		return &instantswap.ExchangeError{
+			Exchange: LIBNAME,
+			Kind:     instantswap.ErrorKind(errorVal.Message),
+			Body:     r,
+			Message:  errorVal.Message,
+		}
 	}
 	return nil
 }
 
-func (c *Changelly) GetCurrencies() (currencies []instantswap.Currency, err error) {
+// parseError reads the json-rpc error of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var response jsonResponse
+	if json.Unmarshal(body, &response) != nil || response.Error == nil {
+		return nil, ""
+	}
+	var errorVal jsonError
+	if json.Unmarshal(response.Error, &errorVal) != nil {
+		return nil, ""
+	}
+	return instantswap.ErrorKind(errorVal.Message), errorVal.Message
+}
+
+func (c *Changelly) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	nonce := strconv.FormatInt(time.Now().Unix(), 10)
 	tmpPayload := jsonRequest{
 		ID:      "queryLimits" + nonce,
@@ -70,9 +104,8 @@ func (c *Changelly) GetCurrencies() (currencies []instantswap.Currency, err erro
 	if err != nil {
 		return nil, err
 	}
-	r, err := c.client.Do(API_BASE, "POST", "", string(payload), true)
+	r, err := c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "", string(payload), true)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var response jsonResponse
@@ -93,46 +126,124 @@ func (c *Changelly) GetCurrencies() (currencies []instantswap.Currency, err erro
 	}
 	currencies = make([]instantswap.Currency, len(resCurrencies))
 	for i, resCurr := range resCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:   resCurr,
-			Symbol: resCurr,
-		}
+		currencies[i] = instantswap.NewCurrency(resCurr, resCurr, "")
 	}
 	return currencies, err
 }
 
-func (c *Changelly) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	return
+func (c *Changelly) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	return currencies, instantswap.NotSupportedError(LIBNAME, "GetCurrenciesToPair")
 }
 
 // GetExchangeRateInfo get estimate on the amount for the exchange.
-func (c *Changelly) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	limits, err := c.QueryLimits(vars.From, vars.To)
+func (c *Changelly) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	rateType, err := c.rateType(vars.Reverse(), vars.RateType)
 	if err != nil {
-		err = errors.New(err.Error())
 		return
 	}
-	time.Sleep(time.Second * 1)
-	estimate, err := c.EstimateAmount(vars)
+	if rateType == instantswap.RateTypeFixed {
+		fix, err := c.fixRate(ctx, vars)
+		if err != nil {
+			return res, err
+		}
+		res = instantswap.ExchangeRateInfo{
+			ExchangeRate:    fix.AmountTo.Div(fix.AmountFrom, instantswap.RatePrecision),
+			Min:             fix.MinFrom,
+			Max:             fix.MaxFrom,
+			EstimatedAmount: fix.AmountTo,
+			Signature:       fix.ID,
+			RateType:        instantswap.RateTypeFixed,
+			ValidUntil:      time.Unix(fix.ExpiredAt, 0),
+		}
+		if vars.Reverse() {
+			res.DepositAmount = fix.AmountFrom
+		}
+		return res, nil
+	}
+	limits, err := c.QueryLimits(ctx, vars.From, vars.To)
+	if err != nil {
+		return
+	}
+	estimate, err := c.EstimateAmount(ctx, vars)
 	if err != nil {
-		err = errors.New(err.Error())
 		return
 	}
 
-	rate := vars.Amount / estimate.EstimatedAmount
+	rate := estimate.EstimatedAmount.Div(vars.Amount, instantswap.RatePrecision)
 
 	res = instantswap.ExchangeRateInfo{
 		ExchangeRate:    rate,
 		Min:             limits.Min,
 		Max:             limits.Max,
 		EstimatedAmount: estimate.EstimatedAmount,
+		RateType:        instantswap.RateTypeFloat,
 	}
 	return
 }
 
+// rateType returns the rate mode of a request, the reverse ones are only
+// supported at fixed rate.
+func (c *Changelly) rateType(reverse bool, requested instantswap.RateType) (instantswap.RateType, error) {
+	if reverse {
+		return instantswap.ChooseReverseRateType(LIBNAME, c.Capabilities(), requested)
+	}
+	return instantswap.ChooseRateType(LIBNAME, c.Capabilities(), requested, instantswap.RateTypeFloat)
+}
+
+// fixRate requests the fixed rate of vars, for the amount to receive of the
+// reverse requests.
+func (c *Changelly) fixRate(ctx context.Context, vars instantswap.ExchangeRateRequest) (res FixRate, err error) {
+	nonce := strconv.FormatInt(time.Now().Unix(), 10)
+	params := []map[string]string{{
+		"from": strings.ToLower(vars.From),
+		"to":   strings.ToLower(vars.To),
+	}}
+	if vars.Reverse() {
+		params[0]["amountTo"] = vars.ReceiveAmount.String()
+	} else {
+		params[0]["amountFrom"] = vars.Amount.String()
+	}
+	tmpPayload := jsonRequest{
+		ID:      "fixRate" + nonce,
+		JSONRPC: "2.0",
+		Method:  "getFixRateForAmount",
+		Params:  params,
+	}
+	payload, err := json.Marshal(tmpPayload)
+	if err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+		return
+	}
+	r, err := c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "", string(payload), true)
+	if err != nil {
+		return
+	}
+	var response jsonResponse
+	if err = json.Unmarshal(r, &response); err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+		return
+	}
+	if response.Error != nil {
+		err = handleErr(response.Error)
+		if err != nil {
+			return
+		}
+	}
+	var rates []FixRate
+	if err = json.Unmarshal(response.Result, &rates); err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+		return
+	}
+	if len(rates) == 0 {
+		err = instantswap.NewError(LIBNAME, instantswap.ErrPairNotSupported, "no fixed rate for "+vars.From+"-"+vars.To)
+		return
+	}
+	return rates[0], nil
+}
+
 // EstimateAmount get estimate on the amount for the exchange.
-func (c *Changelly) EstimateAmount(vars instantswap.ExchangeRateRequest) (res instantswap.EstimateAmount, err error) {
-	amountStr := strconv.FormatFloat(vars.Amount, 'f', 8, 64)
+func (c *Changelly) EstimateAmount(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.EstimateAmount, err error) {
+	amountStr := vars.Amount.String()
 	nonce := strconv.FormatInt(time.Now().Unix(), 10)
 	params := map[string]string{"from": strings.ToLower(vars.From), "to": strings.ToLower(vars.To), "amount": amountStr}
 	tmpPayload := jsonRequest{
@@ -148,9 +259,8 @@ func (c *Changelly) EstimateAmount(vars instantswap.ExchangeRateRequest) (res in
 		return
 	}
 
-	r, err := c.client.Do(API_BASE, "POST", "", string(payload), true)
+	r, err := c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "", string(payload), true)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var response jsonResponse
@@ -172,7 +282,7 @@ func (c *Changelly) EstimateAmount(vars instantswap.ExchangeRateRequest) (res in
 		return
 	}
 
-	exchangeAmount, err := strconv.ParseFloat(tmpAmountStr, 64)
+	exchangeAmount, err := instantswap.ParseAmount(tmpAmountStr)
 	if err != nil {
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
@@ -186,14 +296,14 @@ func (c *Changelly) EstimateAmount(vars instantswap.ExchangeRateRequest) (res in
 }
 
 // QueryRates (list of pairs LTC-BTC, BTC-LTC, etc).
-func (c *Changelly) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
+func (c *Changelly) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
 	//vars not used here
-	err = errors.New(LIBNAME + ":error: not available for this exchange")
+	err = instantswap.NotSupportedError(LIBNAME, "QueryRates")
 	return
 }
 
 // QueryLimits Get Exchange Rates (from, to).
-func (c *Changelly) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+func (c *Changelly) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
 	nonce := strconv.FormatInt(time.Now().Unix(), 10)
 	params := map[string]string{"from": strings.ToLower(fromCurr), "to": strings.ToLower(toCurr)}
 	tmpPayload := jsonRequest{
@@ -208,9 +318,8 @@ func (c *Changelly) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryL
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	r, err := c.client.Do(API_BASE, "POST", "", string(payload), true)
+	r, err := c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "", string(payload), true)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var response jsonResponse
@@ -232,7 +341,7 @@ func (c *Changelly) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryL
 		return
 	}
 
-	minAmount, err := strconv.ParseFloat(tmpMinAmountStr, 64)
+	minAmount, err := instantswap.ParseAmount(tmpMinAmountStr)
 	if err != nil {
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
@@ -244,9 +353,13 @@ func (c *Changelly) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryL
 }
 
 // CreateOrder create an instant exchange order.
-func (c *Changelly) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *Changelly) CreateOrder(ctx context.Context, orderInfo instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	rateType, err := c.rateType(orderInfo.Reverse(), orderInfo.RateType)
+	if err != nil {
+		return
+	}
 	nonce := strconv.FormatInt(time.Now().Unix(), 10)
-	amountStr := strconv.FormatFloat(orderInfo.InvoicedAmount, 'f', 8, 64)
+	amountStr := orderInfo.InvoicedAmount.String()
 	params := map[string]string{
 		"from":          strings.ToLower(orderInfo.FromCurrency),
 		"to":            strings.ToLower(orderInfo.ToCurrency),
@@ -262,10 +375,32 @@ func (c *Changelly) CreateOrder(orderInfo instantswap.CreateOrder) (res instants
 		Method:  "createTransaction",
 		Params:  params,
 	}
-	if orderInfo.InvoicedAmount == 0.0 {
-		err = errors.New(LIBNAME + ":error:createorder invoiced amount is 0")
+	if orderInfo.InvoicedAmount.IsZero() && !orderInfo.Reverse() {
+		err = instantswap.NewError(LIBNAME, instantswap.ErrAmountBelowMin, "createorder invoiced amount is 0")
 		return
 	}
+	if rateType == instantswap.RateTypeFixed {
+		if orderInfo.Signature == "" {
+			fix, err := c.fixRate(ctx, instantswap.ExchangeRateRequest{
+				From:          orderInfo.FromCurrency,
+				To:            orderInfo.ToCurrency,
+				Amount:        orderInfo.InvoicedAmount,
+				ReceiveAmount: orderInfo.OrderedAmount,
+			})
+			if err != nil {
+				return res, err
+			}
+			orderInfo.Signature = fix.ID
+		}
+		delete(params, "amount")
+		if orderInfo.Reverse() {
+			params["amountTo"] = orderInfo.OrderedAmount.String()
+		} else {
+			params["amountFrom"] = amountStr
+		}
+		params["rateId"] = orderInfo.Signature
+		tmpPayload.Method = "createFixTransaction"
+	}
 	payload, err := json.Marshal(tmpPayload)
 	if err != nil {
 		err = errors.New(LIBNAME + ":error: " + err.Error())
@@ -273,12 +408,12 @@ func (c *Changelly) CreateOrder(orderInfo instantswap.CreateOrder) (res instants
 	}
 
 	if c.conf.ApiKey == "" {
-		err = errors.New(LIBNAME + ":error: APIKEY is blank")
+		err = instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
+		return
 	}
 
-	r, err := c.client.Do(API_BASE, "POST", "", string(payload), true)
+	r, err := c.client.Do(ctx, API_BASE, "POST", "", string(payload), true)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 
@@ -310,24 +445,33 @@ func (c *Changelly) CreateOrder(orderInfo instantswap.CreateOrder) (res instants
 		ChargedFee:     tmp.ChangellyFee,
 		ExtraID:        tmp.PayinExtraID,
 		PayoutExtraID:  tmp.PayoutExtraID,
+		InvoicedAmount: tmp.AmountExpectedFrom,
+		OrderedAmount:  tmp.AmountExpectedTo,
+		RateType:       rateType,
 	}
 	return
 }
 
 // UpdateOrder not available for this exchange.
-func (c *Changelly) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	err = errors.New(LIBNAME + ":error:update not available for this exchange")
+func (c *Changelly) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	err = instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 	return
 }
 
 // CancelOrder not available for this exchange.
-func (c *Changelly) CancelOrder(oId string) (res string, err error) {
-	err = errors.New(LIBNAME + ":error:cancel not available for this exchange")
+func (c *Changelly) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (c *Changelly) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (c *Changelly) CancelOrder(ctx context.Context, oId string) (res string, err error) {
+	err = instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 	return
 }
 
 // OrderInfo get information on orderid/uuid.
-func (c *Changelly) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (c *Changelly) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	nonce := strconv.FormatInt(time.Now().Unix(), 10)
 	params := map[string]string{"id": req.OrderId}
 	tmpPayload := jsonRequest{
@@ -344,11 +488,11 @@ func (c *Changelly) OrderInfo(req instantswap.TrackingRequest) (res instantswap.
 	}
 
 	if c.conf.ApiKey == "" {
-		err = errors.New(LIBNAME + ":error: APIKEY is blank")
+		err = instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
+		return
 	}
-	r, err := c.client.Do(API_BASE, "POST", "", string(payload), true)
+	r, err := c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "", string(payload), true)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var response jsonResponse
@@ -376,7 +520,7 @@ func (c *Changelly) OrderInfo(req instantswap.TrackingRequest) (res instantswap.
 		}
 	}
 	if finalOrderInfo == (OrderInfoResult{}) {
-		err = errors.New(LIBNAME + ":error: order info could not be found")
+		err = instantswap.NewError(LIBNAME, instantswap.ErrOrderNotFound, "order info could not be found")
 		return
 	}
 	res = instantswap.OrderInfoResult{
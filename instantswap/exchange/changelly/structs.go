@@ -2,6 +2,7 @@ package changelly
 
 import (
 	"encoding/json"
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 // base json structure
@@ -42,32 +43,36 @@ type ActiveCurr struct {
 
 type QueryLimits struct {
 	//Max string `json:"max"`
-	Min float64 `json:"minAmount"`
+	Min instantswap.Amount `json:"minAmount"`
 }
 
 // CREATE
 type CreateOrder struct {
-	FromCurrency      string  `json:"from"`
-	ToCurrency        string  `json:"to"`
-	ToCurrencyAddress string  `json:"address"`
-	InvoicedAmount    float64 `json:"amount"`            //amount in "from" currency
-	ExtraID           string  `json:"extraID,omitempty"` //optional for some coins
+	FromCurrency      string      `json:"from"`
+	ToCurrency        string      `json:"to"`
+	ToCurrencyAddress string      `json:"address"`
+	InvoicedAmount    json.Number `json:"amount"`            //amount in "from" currency
+	ExtraID           string      `json:"extraID,omitempty"` //optional for some coins
 }
 type CreateResult struct {
-	UUID          string  `json:"id"`
-	AmountTo      float64 `json:"amountTo"` //0 until amount has been deposited based on api docs
-	APIExtraFee   float64 `json:"apiExtraFee,string"`
-	ChangellyFee  float64 `json:"changellyFee,string"`
-	CreatedAt     string  `json:"createdAt"`
-	CurrencyFrom  string  `json:"currencyFrom"`
-	CurrencyTo    string  `json:"currencyTo"`
-	PayinAddress  string  `json:"payinAddress"`
-	PayinExtraID  string  `json:"payinExtraId"`
-	PayoutAddress string  `json:"payoutAddress"`
-	PayoutExtraID string  `json:"payoutExtraId"`
-	RefundAddress string  `json:"refundAddress"`
-	RefundExtraID string  `json:"refundExtraId"`
-	Status        string  `json:"status"`
+	UUID     string             `json:"id"`
+	AmountTo instantswap.Amount `json:"amountTo"` //0 until amount has been deposited based on api docs
+	// AmountExpectedFrom and AmountExpectedTo are the amounts of the fixed
+	// rate transactions.
+	AmountExpectedFrom instantswap.Amount `json:"amountExpectedFrom"`
+	AmountExpectedTo   instantswap.Amount `json:"amountExpectedTo"`
+	APIExtraFee        instantswap.Amount `json:"apiExtraFee"`
+	ChangellyFee       instantswap.Amount `json:"changellyFee"`
+	CreatedAt          string             `json:"createdAt"`
+	CurrencyFrom       string             `json:"currencyFrom"`
+	CurrencyTo         string             `json:"currencyTo"`
+	PayinAddress       string             `json:"payinAddress"`
+	PayinExtraID       string             `json:"payinExtraId"`
+	PayoutAddress      string             `json:"payoutAddress"`
+	PayoutExtraID      string             `json:"payoutExtraId"`
+	RefundAddress      string             `json:"refundAddress"`
+	RefundExtraID      string             `json:"refundExtraId"`
+	Status             string             `json:"status"`
 }
 
 //INFO
@@ -78,28 +83,41 @@ type UUID struct {
 }
 
 type OrderInfoResult struct {
-	AmountFrom         string      `json:"amountFrom"`
-	AmountTo           float64     `json:"amountTo,string"`
-	APIExtraFee        float64     `json:"apiExtraFee,string"`
-	ChangellyFee       float64     `json:"changellyFee,string"`
-	CreatedAt          int         `json:"createdAt"`
-	CurrencyFrom       string      `json:"currencyFrom"`
-	CurrencyTo         string      `json:"currencyTo"`
-	UUID               string      `json:"id"`
-	NetworkFee         interface{} `json:"networkFee"`
-	PayinAddress       string      `json:"payinAddress"`
-	PayinConfirmations string      `json:"payinConfirmations"`
-	PayinExtraID       string      `json:"payinExtraId"`
-	PayinHash          string      `json:"payinHash"`
-	PayoutAddress      string      `json:"payoutAddress"`
-	PayoutExtraID      string      `json:"payoutExtraId"`
-	PayoutHash         string      `json:"payoutHash"`
-	Status             string      `json:"status"`
+	AmountFrom         string             `json:"amountFrom"`
+	AmountTo           instantswap.Amount `json:"amountTo"`
+	APIExtraFee        instantswap.Amount `json:"apiExtraFee"`
+	ChangellyFee       instantswap.Amount `json:"changellyFee"`
+	CreatedAt          int                `json:"createdAt"`
+	CurrencyFrom       string             `json:"currencyFrom"`
+	CurrencyTo         string             `json:"currencyTo"`
+	UUID               string             `json:"id"`
+	NetworkFee         interface{}        `json:"networkFee"`
+	PayinAddress       string             `json:"payinAddress"`
+	PayinConfirmations string             `json:"payinConfirmations"`
+	PayinExtraID       string             `json:"payinExtraId"`
+	PayinHash          string             `json:"payinHash"`
+	PayoutAddress      string             `json:"payoutAddress"`
+	PayoutExtraID      string             `json:"payoutExtraId"`
+	PayoutHash         string             `json:"payoutHash"`
+	Status             string             `json:"status"`
 }
+
+// FixRate is a fixed rate, its id is passed to createFixTransaction.
+type FixRate struct {
+	ID         string             `json:"id"`
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	AmountFrom instantswap.Amount `json:"amountFrom"`
+	AmountTo   instantswap.Amount `json:"amountTo"`
+	MinFrom    instantswap.Amount `json:"minFrom"`
+	MaxFrom    instantswap.Amount `json:"maxFrom"`
+	ExpiredAt  int64              `json:"expiredAt"`
+}
+
 type EstimateAmount struct {
-	EstimatedAmount          float64     `json:"estimatedAmount"` //destinationCurrency
-	NetworkFee               float64     `json:"networkFee"`
-	ServiceCommission        float64     `json:"serviceCommission"`
-	TransactionSpeedForecast string      `json:"transactionSpeedForecast"`
-	WarningMessage           interface{} `json:"warningMessage"`
+	EstimatedAmount          instantswap.Amount `json:"estimatedAmount"` //destinationCurrency
+	NetworkFee               instantswap.Amount `json:"networkFee"`
+	ServiceCommission        instantswap.Amount `json:"serviceCommission"`
+	TransactionSpeedForecast string             `json:"transactionSpeedForecast"`
+	WarningMessage           interface{}        `json:"warningMessage"`
 }
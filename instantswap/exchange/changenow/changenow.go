@@ -1,21 +1,34 @@
 package changenow
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
+	"net/http"
 	"strings"
-	"time"
 
 	"github.com/vibros68/instantswap/instantswap"
 )
 
 const (
 	API_BASE = "https://api.changenow.io/v1/" // API endpoint
-	LIBNAME  = "changenow"
+	// API_BASE_V2 is the endpoint of the refunds, authenticated by header.
+	API_BASE_V2 = "https://api.changenow.io/v2/"
+	LIBNAME     = "changenow"
 )
 
+// codes maps the changenow tickers of the tokens issued on several networks.
+var codes = instantswap.CodeMap{Currencies: map[string]instantswap.CurrencyKey{
+	"usdterc20": {Symbol: "USDT", Network: instantswap.NetworkEthereum},
+	"usdttrc20": {Symbol: "USDT", Network: instantswap.NetworkTron},
+	"usdtbsc":   {Symbol: "USDT", Network: instantswap.NetworkBSC},
+	"usdtsol":   {Symbol: "USDT", Network: instantswap.NetworkSolana},
+	"usdc":      {Symbol: "USDC", Network: instantswap.NetworkEthereum},
+	"usdcbsc":   {Symbol: "USDC", Network: instantswap.NetworkBSC},
+	"usdcsol":   {Symbol: "USDC", Network: instantswap.NetworkSolana},
+}}
+
 func init() {
 	instantswap.RegisterExchange(LIBNAME, func(config instantswap.ExchangeConfig) (instantswap.IDExchange, error) {
 		return New(config)
@@ -25,10 +38,15 @@ func init() {
 // New return an ChangeNow client struct with IDExchange implement.
 func New(conf instantswap.ExchangeConfig) (*ChangeNow, error) {
 	if conf.ApiKey == "" {
-		err := fmt.Errorf("APIKEY is blank")
-		return nil, err
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
-	client := instantswap.NewClient(LIBNAME, &conf)
+	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
+		r.Header.Set("x-changenow-api-key", conf.ApiKey)
+		return nil
+	})
+	client.SetErrorFunc(parseError)
+	client.SetDefaultApiBase(API_BASE)
+	client.SetDefaultRateLimit(instantswap.RateLimit{Rate: 1, Burst: 1})
 	return &ChangeNow{client: client, conf: &conf}, nil
 }
 
@@ -43,13 +61,29 @@ func (c *ChangeNow) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by changenow.
+func (c *ChangeNow) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		ReverseQuote:     true,
+		Limits:           true,
+		CurrenciesToPair: true,
+		Refund:           true,
+		RefundAddress:    true,
+		ExtraID:          true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (c *ChangeNow) SetDebug(enable bool) {
 	c.conf.Debug = enable
 }
 
-func (c *ChangeNow) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "currencies?active=true", "", false)
+func (c *ChangeNow) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencies?active=true", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -60,18 +94,13 @@ func (c *ChangeNow) GetCurrencies() (currencies []instantswap.Currency, err erro
 	}
 	currencies = make([]instantswap.Currency, len(cnCurrencies))
 	for i, currency := range cnCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:     currency.Name,
-			Symbol:   currency.Ticker,
-			IsFiat:   currency.IsFiat,
-			IsStable: currency.IsStable,
-		}
+		currencies[i] = codes.NewCurrency(currency.Name, currency.Ticker, "")
 	}
 	return currencies, nil
 }
 
-func (c *ChangeNow) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, "GET",
+func (c *ChangeNow) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET",
 		fmt.Sprintf("currencies-to/%s", strings.ToLower(from)), "", false)
 	if err != nil {
 		return nil, err
@@ -83,53 +112,82 @@ func (c *ChangeNow) GetCurrenciesToPair(from string) (currencies []instantswap.C
 	}
 	currencies = make([]instantswap.Currency, len(cnCurrencies))
 	for i, currency := range cnCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:     currency.Name,
-			Symbol:   currency.Ticker,
-			IsFiat:   currency.IsFiat,
-			IsStable: currency.IsStable,
-		}
+		currencies[i] = codes.NewCurrency(currency.Name, currency.Ticker, "")
 	}
 	return currencies, nil
 }
 
 // GetExchangeRateInfo get estimate on the amount for the exchange.
-func (c *ChangeNow) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	limits, err := c.QueryLimits(vars.From, vars.To)
+func (c *ChangeNow) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	rateType, err := c.rateType(vars.Reverse(), vars.RateType)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	time.Sleep(time.Second * 1)
-	estimate, err := c.EstimateAmount(vars)
+	from, _ := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.To, vars.ToNetwork)
+	limits, err := c.limits(ctx, strings.ToLower(from), strings.ToLower(to), rateType)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	rate := estimate.EstimatedAmount / vars.Amount
+	estimate, err := c.estimate(ctx, vars, rateType)
+	if err != nil {
+		return
+	}
+	if vars.Reverse() {
+		return instantswap.ExchangeRateInfo{
+			ExchangeRate:    vars.ReceiveAmount.Div(estimate.EstimatedDeposit, instantswap.RatePrecision),
+			Min:             limits.Min,
+			Max:             limits.Max,
+			EstimatedAmount: vars.ReceiveAmount,
+			DepositAmount:   estimate.EstimatedDeposit,
+			Signature:       estimate.RateId,
+			RateType:        rateType,
+			ValidUntil:      estimate.ValidUntil,
+		}, nil
+	}
+	rate := estimate.EstimatedAmount.Div(vars.Amount, instantswap.RatePrecision)
 
 	res = instantswap.ExchangeRateInfo{
 		ExchangeRate:    rate,
 		Min:             limits.Min,
 		Max:             limits.Max,
 		EstimatedAmount: estimate.EstimatedAmount,
+		Signature:       estimate.RateId,
+		RateType:        rateType,
+		ValidUntil:      estimate.ValidUntil,
 	}
 
 	return
 }
 
+// rateType returns the rate mode of a request, the reverse ones are only
+// supported at fixed rate.
+func (c *ChangeNow) rateType(reverse bool, requested instantswap.RateType) (instantswap.RateType, error) {
+	if reverse {
+		return instantswap.ChooseReverseRateType(LIBNAME, c.Capabilities(), requested)
+	}
+	return instantswap.ChooseRateType(LIBNAME, c.Capabilities(), requested, instantswap.RateTypeFloat)
+}
+
+// ratePath returns the path prefix of the endpoints of rateType.
+func ratePath(rateType instantswap.RateType) string {
+	if rateType == instantswap.RateTypeFixed {
+		return "fixed-rate/"
+	}
+	return ""
+}
+
 // EstimateAmount get estimate on the amount for the exchange.
-func (c *ChangeNow) EstimateAmount(vars instantswap.ExchangeRateRequest) (res instantswap.EstimateAmount, err error) {
-	amountStr := strconv.FormatFloat(vars.Amount, 'f', 8, 64)
-	r, err := c.client.Do(API_BASE, "GET",
-		fmt.Sprintf("exchange-amount/%s/%s_%s?api_key=%s", amountStr, vars.From, vars.To, c.conf.ApiKey), "", false)
+func (c *ChangeNow) EstimateAmount(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.EstimateAmount, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "EstimateAmount of a reverse quote")
+	}
+	rateType, err := c.rateType(false, vars.RateType)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	var tmpRes EstimateAmount
-	if err = json.Unmarshal(r, &tmpRes); err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
+	tmpRes, err := c.estimate(ctx, vars, rateType)
+	if err != nil {
 		return
 	}
 
@@ -144,18 +202,37 @@ func (c *ChangeNow) EstimateAmount(vars instantswap.ExchangeRateRequest) (res in
 	return
 }
 
+func (c *ChangeNow) estimate(ctx context.Context, vars instantswap.ExchangeRateRequest, rateType instantswap.RateType) (res EstimateAmount, err error) {
+	from, _ := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.To, vars.ToNetwork)
+	// the reverse estimates return the deposit of the amount to receive.
+	endpoint, amount := "exchange-amount", vars.Amount
+	if vars.Reverse() {
+		endpoint, amount = "exchange-deposit", vars.ReceiveAmount
+	}
+	r, err := c.client.Do(ctx, API_BASE, "GET",
+		fmt.Sprintf("%s/%s%s/%s_%s?api_key=%s", endpoint, ratePath(rateType), amount,
+			strings.ToLower(from), strings.ToLower(to), c.conf.ApiKey), "", false)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(r, &res); err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+	}
+	return
+}
+
 // QueryRates (list of pairs LTC-BTC, BTC-LTC, etc).
-func (c *ChangeNow) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
+func (c *ChangeNow) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
 	//vars not used here
-	err = errors.New(LIBNAME + ":error: not available for this exchange")
+	err = instantswap.NotSupportedError(LIBNAME, "QueryRates")
 	return
 }
 
 // QueryActiveCurrencies get all active currencies.
-func (c *ChangeNow) QueryActiveCurrencies(vars interface{}) (res []instantswap.ActiveCurr, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "currencies?active=true", "", false)
+func (c *ChangeNow) QueryActiveCurrencies(ctx context.Context, vars interface{}) (res []instantswap.ActiveCurr, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencies?active=true", "", false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var tmpArr []ActiveCurr
@@ -179,10 +256,17 @@ func (c *ChangeNow) QueryActiveCurrencies(vars interface{}) (res []instantswap.A
 }
 
 // QueryLimits Get Exchange Rates (from, to).
-func (c *ChangeNow) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "exchange-range/"+fromCurr+"_"+toCurr, "", false)
+func (c *ChangeNow) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return c.limits(ctx, fromCurr, toCurr, instantswap.RateTypeFloat)
+}
+
+func (c *ChangeNow) limits(ctx context.Context, fromCurr, toCurr string, rateType instantswap.RateType) (res instantswap.QueryLimits, err error) {
+	path := "exchange-range/" + fromCurr + "_" + toCurr
+	if rateType == instantswap.RateTypeFixed {
+		path = "exchange-range/" + ratePath(rateType) + fromCurr + "_" + toCurr + "?api_key=" + c.conf.ApiKey
+	}
+	r, err := c.client.Do(ctx, API_BASE, "GET", path, "", false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var tmp QueryLimits
@@ -198,15 +282,43 @@ func (c *ChangeNow) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryL
 }
 
 // CreateOrder create an instant exchange order.
-func (c *ChangeNow) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *ChangeNow) CreateOrder(ctx context.Context, orderInfo instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	rateType, err := c.rateType(orderInfo.Reverse(), orderInfo.RateType)
+	if err != nil {
+		return
+	}
+	from, _ := codes.ProviderCurrency(orderInfo.FromCurrency, orderInfo.FromNetwork)
+	to, _ := codes.ProviderCurrency(orderInfo.ToCurrency, orderInfo.ToNetwork)
 	tmpOrderInfo := CreateOrder{
-		FromCurrency:      orderInfo.FromCurrency,
-		ToCurrency:        orderInfo.ToCurrency,
+		FromCurrency:      strings.ToLower(from),
+		ToCurrency:        strings.ToLower(to),
 		ToCurrencyAddress: orderInfo.Destination,
 		RefundAddress:     orderInfo.RefundAddress,
-		InvoicedAmount:    strconv.FormatFloat(orderInfo.InvoicedAmount, 'f', 8, 64),
+		InvoicedAmount:    orderInfo.InvoicedAmount.String(),
 		ExtraID:           orderInfo.ExtraID,
 	}
+	if orderInfo.Reverse() {
+		tmpOrderInfo.InvoicedAmount = ""
+		tmpOrderInfo.OrderedAmount = orderInfo.OrderedAmount.String()
+	}
+	if rateType == instantswap.RateTypeFixed {
+		if orderInfo.Signature == "" {
+			vars := instantswap.ExchangeRateRequest{
+				From:          orderInfo.FromCurrency,
+				FromNetwork:   orderInfo.FromNetwork,
+				To:            orderInfo.ToCurrency,
+				ToNetwork:     orderInfo.ToNetwork,
+				Amount:        orderInfo.InvoicedAmount,
+				ReceiveAmount: orderInfo.OrderedAmount,
+			}
+			estimate, err := c.estimate(ctx, vars, rateType)
+			if err != nil {
+				return res, err
+			}
+			orderInfo.Signature = estimate.RateId
+		}
+		tmpOrderInfo.RateId = orderInfo.Signature
+	}
 
 	payload, err := json.Marshal(tmpOrderInfo)
 	if err != nil {
@@ -214,9 +326,8 @@ func (c *ChangeNow) CreateOrder(orderInfo instantswap.CreateOrder) (res instants
 		return
 	}
 
-	r, err := c.client.Do(API_BASE, "POST", "transactions/"+c.conf.ApiKey, string(payload), false)
+	r, err := c.client.Do(ctx, API_BASE, "POST", "transactions/"+ratePath(rateType)+c.conf.ApiKey, string(payload), false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 
@@ -235,35 +346,34 @@ func (c *ChangeNow) CreateOrder(orderInfo instantswap.CreateOrder) (res instants
 		OrderedAmount:  tmp.InvoicedAmount,       // amount you get
 		ToCurrency:     tmp.ToCurrency,
 		DepositAddress: tmp.DepositAddress,
+		RateType:       rateType,
+	}
+	if orderInfo.Reverse() {
+		res.InvoicedAmount = tmp.ExpectedSendAmount
+		res.OrderedAmount = orderInfo.OrderedAmount
 	}
 	return
 }
 
 // UpdateOrder not available for this exchange.
-func (c *ChangeNow) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	err = errors.New(LIBNAME + ":error:update not available for this exchange")
+func (c *ChangeNow) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	err = instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 	return
 }
 
 // CancelOrder not available for this exchange.
-func (c *ChangeNow) CancelOrder(oId string) (res string, err error) {
-	err = errors.New(LIBNAME + ":error:cancel not available for this exchange")
+func (c *ChangeNow) CancelOrder(ctx context.Context, oId string) (res string, err error) {
+	err = instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 	return
 }
 
 // OrderInfo get information on orderid/uuid.
-func (c *ChangeNow) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "transactions/"+req.OrderId+"/"+c.conf.ApiKey, "", false)
+func (c *ChangeNow) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	tmp, err := c.transaction(ctx, req.OrderId)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	var tmp OrderInfoResult
-	if err = json.Unmarshal(r, &tmp); err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
-		return
-	}
-	var amountRecv float64
+	var amountRecv instantswap.Amount
 	if tmp.Status != "finished" {
 		amountRecv = tmp.ExpectedAmountReceive
 	} else {
@@ -278,12 +388,122 @@ func (c *ChangeNow) OrderInfo(req instantswap.TrackingRequest) (res instantswap.
 		LastUpdate:     tmp.UpdatedAt,
 		ReceiveAmount:  amountRecv,
 		TxID:           hash,
+		RefundTx:       tmp.RefundHash,
 		Status:         tmp.Status,
 		InternalStatus: GetLocalStatus(tmp.Status),
 	}
 	return
 }
 
+func (c *ChangeNow) transaction(ctx context.Context, id string) (res OrderInfoResult, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "transactions/"+id+"/"+c.conf.ApiKey, "", false)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(r, &res); err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+	}
+	return
+}
+
+// RefundInfo returns the refund of a transaction, the refund action of the
+// failed or held transactions tells whether they can be refunded.
+func (c *ChangeNow) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	tx, err := c.transaction(ctx, orderID)
+	if err != nil {
+		return
+	}
+	res = instantswap.RefundInfo{
+		UUID:    orderID,
+		Status:  instantswap.RefundStatusNotEligible,
+		Address: tx.RefundAddress,
+		ExtraID: tx.RefundExtraID,
+		Amount:  tx.AmountSend,
+		TxID:    tx.RefundHash,
+	}
+	switch strings.ToLower(tx.Status) {
+	case "refunded":
+		res.Status = instantswap.RefundStatusCompleted
+		return
+	case "finished", "new", "waiting":
+		return
+	}
+	r, err := c.client.Do(ctx, API_BASE_V2, "GET", "exchange/actions?id="+orderID, "", false)
+	if err != nil {
+		return
+	}
+	var actions ExchangeActions
+	if err = json.Unmarshal(r, &actions); err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+		return
+	}
+	if actions.Refund {
+		res.Status = instantswap.RefundStatusEligible
+	}
+	return
+}
+
+// SubmitRefund refunds an eligible transaction to the address.
+func (c *ChangeNow) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	res, err = c.RefundInfo(ctx, vars.UUID)
+	if err != nil {
+		return
+	}
+	if !res.Eligible() {
+		err = instantswap.RefundNotEligibleError(LIBNAME, res)
+		return
+	}
+	payload, err := json.Marshal(Refund{
+		ID:      vars.UUID,
+		Address: vars.Address,
+		ExtraID: vars.ExtraID,
+	})
+	if err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+		return
+	}
+	r, err := c.client.Do(ctx, API_BASE_V2, "POST", "exchange/refund", string(payload), false)
+	if err != nil {
+		return
+	}
+	var tmp RefundResult
+	if err = json.Unmarshal(r, &tmp); err != nil {
+		err = errors.New(LIBNAME + ":error: " + err.Error())
+		return
+	}
+	if !tmp.Result {
+		err = errors.New(LIBNAME + ":error: refund was not accepted")
+		return
+	}
+	res.Status = instantswap.RefundStatusPending
+	res.Address = vars.Address
+	res.ExtraID = vars.ExtraID
+	return
+}
+
+// errorKinds maps the changenow error codes to the error kinds, the kind of
+// the other codes is guessed from the message.
+var errorKinds = map[string]error{
+	"pair_is_inactive":             instantswap.ErrPairNotSupported,
+	"deposit_too_small":            instantswap.ErrAmountBelowMin,
+	"not_valid_address":            instantswap.ErrInvalidAddress,
+	"not_found":                    instantswap.ErrOrderNotFound,
+	"rate_id_not_found_or_expired": instantswap.ErrRateExpired,
+}
+
+// parseError reads the {"error", "message"} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var e Error
+	if json.Unmarshal(body, &e) != nil || e.Error == "" {
+		return nil, ""
+	}
+	message := e.Message
+	if message == "" {
+		message = e.Error
+	}
+	return errorKinds[e.Error], message
+}
+
 // GetLocalStatus translate local status to idexchange status id.
 // Possible transaction statuses:
 // new waiting confirming exchanging sending finished failed refunded expired
@@ -2,6 +2,9 @@ package changenow
 
 import (
 	"encoding/json"
+	"time"
+
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 // base json structure
@@ -11,6 +14,12 @@ type jsonResponse struct {
 	Status string          `json:"status"`
 }
 
+// Error is the payload of a failed request.
+type Error struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
 //QUERY
 
 type QueryRate struct {
@@ -30,8 +39,8 @@ type ActiveCurr struct {
 }
 
 type QueryLimits struct {
-	Max float64 `json:"maxAmount"`
-	Min float64 `json:"minAmount"`
+	Max instantswap.Amount `json:"maxAmount"`
+	Min instantswap.Amount `json:"minAmount"`
 }
 
 // CREATE
@@ -40,18 +49,21 @@ type CreateOrder struct {
 	ToCurrency        string `json:"to"`
 	ToCurrencyAddress string `json:"address"`
 	RefundAddress     string `json:"refundAddress"`
-	InvoicedAmount    string `json:"amount"`            //amount in "from" currency
+	InvoicedAmount    string `json:"amount,omitempty"`  //amount in "from" currency
+	OrderedAmount     string `json:"result,omitempty"`  //amount in "to" currency, reverse orders only
 	ExtraID           string `json:"extraID,omitempty"` //optional for some coins
+	RateId            string `json:"rateId,omitempty"`  //required for fixed rate
 }
 
 type CreateResult struct {
-	UUID               string  `json:"id"`
-	DepositAddress     string  `json:"payinAddress"`
-	DestinationAddress string  `json:"payoutAddress"`
-	PayinExtraID       string  `json:"payinExtraId"`
-	FromCurrency       string  `json:"fromCurrency"`
-	InvoicedAmount     float64 `json:"amount"`
-	ToCurrency         string  `json:"toCurrency"`
+	UUID               string             `json:"id"`
+	DepositAddress     string             `json:"payinAddress"`
+	DestinationAddress string             `json:"payoutAddress"`
+	PayinExtraID       string             `json:"payinExtraId"`
+	FromCurrency       string             `json:"fromCurrency"`
+	InvoicedAmount     instantswap.Amount `json:"amount"`
+	ExpectedSendAmount instantswap.Amount `json:"expectedSendAmount"`
+	ToCurrency         string             `json:"toCurrency"`
 }
 
 //INFO
@@ -62,30 +74,55 @@ type UUID struct {
 }
 
 type OrderInfoResult struct {
-	AmountReceive         float64 `json:"amountReceive"`
-	AmountSend            float64 `json:"amountSend"`
-	ExpectedAmountReceive float64 `json:"expectedReceiveAmount"`
-	ExpectedAmountSend    float64 `json:"expectedSendAmount"`
-	FromCurrency          string  `json:"fromCurrency"`
-	Hash                  string  `json:"hash"`
-	ID                    string  `json:"id"`
-	NetworkFee            float64 `json:"networkFee,string"`
-	PayinAddress          string  `json:"payinAddress"`
-	PayinExtraID          string  `json:"payinExtraId"`
-	PayinHash             string  `json:"payinHash"`
-	PayoutAddress         string  `json:"payoutAddress"`
-	PayoutExtraID         string  `json:"payoutExtraId"`
-	PayoutHash            string  `json:"payoutHash"`
-	Status                string  `json:"status"`
-	ToCurrency            string  `json:"toCurrency"`
-	UpdatedAt             string  `json:"updatedAt"`
+	AmountReceive         instantswap.Amount `json:"amountReceive"`
+	AmountSend            instantswap.Amount `json:"amountSend"`
+	ExpectedAmountReceive instantswap.Amount `json:"expectedReceiveAmount"`
+	ExpectedAmountSend    instantswap.Amount `json:"expectedSendAmount"`
+	FromCurrency          string             `json:"fromCurrency"`
+	Hash                  string             `json:"hash"`
+	ID                    string             `json:"id"`
+	NetworkFee            instantswap.Amount `json:"networkFee"`
+	PayinAddress          string             `json:"payinAddress"`
+	PayinExtraID          string             `json:"payinExtraId"`
+	PayinHash             string             `json:"payinHash"`
+	PayoutAddress         string             `json:"payoutAddress"`
+	PayoutExtraID         string             `json:"payoutExtraId"`
+	PayoutHash            string             `json:"payoutHash"`
+	RefundAddress         string             `json:"refundAddress"`
+	RefundExtraID         string             `json:"refundExtraId"`
+	RefundHash            string             `json:"refundHash"`
+	Status                string             `json:"status"`
+	ToCurrency            string             `json:"toCurrency"`
+	UpdatedAt             string             `json:"updatedAt"`
+}
+
+// ExchangeActions are the actions available for a failed or held transaction.
+type ExchangeActions struct {
+	ID       string `json:"id"`
+	Refund   bool   `json:"refund"`
+	Continue bool   `json:"continue"`
 }
+
+type Refund struct {
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	ExtraID string `json:"extraId,omitempty"`
+}
+type RefundResult struct {
+	Result bool `json:"result"`
+}
+
 type EstimateAmount struct {
-	EstimatedAmount          float64     `json:"estimatedAmount"` //destinationCurrency
-	NetworkFee               float64     `json:"networkFee"`
-	ServiceCommission        float64     `json:"serviceCommission"`
-	TransactionSpeedForecast string      `json:"transactionSpeedForecast"`
-	WarningMessage           interface{} `json:"warningMessage"`
+	EstimatedAmount          instantswap.Amount `json:"estimatedAmount"` //destinationCurrency
+	NetworkFee               instantswap.Amount `json:"networkFee"`
+	ServiceCommission        instantswap.Amount `json:"serviceCommission"`
+	TransactionSpeedForecast string             `json:"transactionSpeedForecast"`
+	WarningMessage           interface{}        `json:"warningMessage"`
+	// EstimatedDeposit is the amount to send of the reverse estimates.
+	EstimatedDeposit instantswap.Amount `json:"estimatedDeposit"`
+	// RateId and ValidUntil are set for the fixed rates.
+	RateId     string    `json:"rateId"`
+	ValidUntil time.Time `json:"validUntil"`
 }
 
 type Currency struct {
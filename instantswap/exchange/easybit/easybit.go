@@ -1,13 +1,13 @@
 package easybit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/vibros68/instantswap/instantswap"
-	"github.com/vibros68/instantswap/instantswap/utils"
 )
 
 const (
@@ -24,13 +24,13 @@ func init() {
 // New return an EasyBit api client
 func New(conf instantswap.ExchangeConfig) (*EasyBit, error) {
 	if conf.ApiKey == "" {
-		err := fmt.Errorf("APIKEY is blank")
-		return nil, err
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		r.Header.Set("API-KEY", conf.ApiKey)
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &EasyBit{
 		client: client,
 		conf:   &conf,
@@ -48,13 +48,23 @@ func (c *EasyBit) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by easybit.
+func (c *EasyBit) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FloatingRate:     true,
+		CurrenciesToPair: true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (c *EasyBit) SetDebug(enable bool) {
 	c.conf.Debug = enable
 }
 
-func (c *EasyBit) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "currencyList", "", false)
+func (c *EasyBit) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencyList", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -65,16 +75,13 @@ func (c *EasyBit) GetCurrencies() (currencies []instantswap.Currency, err error)
 	}
 	currencies = make([]instantswap.Currency, len(ebCurrencies))
 	for i, currency := range ebCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:   currency.Name,
-			Symbol: currency.Currency,
-		}
+		currencies[i] = instantswap.NewCurrency(currency.Name, currency.Currency, currency.defaultNetwork())
 	}
 	return currencies, nil
 }
 
-func (c *EasyBit) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "currencyList", "", false)
+func (c *EasyBit) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencyList", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -85,18 +92,22 @@ func (c *EasyBit) GetCurrenciesToPair(from string) (currencies []instantswap.Cur
 	}
 	for _, currency := range ebCurrencies {
 		if strings.ToLower(from) != strings.ToLower(currency.Currency) {
-			currencies = append(currencies, instantswap.Currency{
-				Name:   currency.Name,
-				Symbol: currency.Currency,
-			})
+			currencies = append(currencies, instantswap.NewCurrency(currency.Name, currency.Currency, currency.defaultNetwork()))
 		}
 	}
 	return currencies, nil
 }
 
-func (c *EasyBit) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	r, err := c.client.Do(API_BASE, "GET",
-		fmt.Sprintf("rate?send=%s&receive=%s&amount=%.8f", vars.From, vars.To, vars.Amount), "", false)
+func (c *EasyBit) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
+	r, err := c.client.Do(ctx, API_BASE, "GET",
+		fmt.Sprintf("rate?send=%s&receive=%s&amount=%s", vars.From, vars.To, vars.Amount), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -105,19 +116,20 @@ func (c *EasyBit) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res
 	if err != nil {
 		return res, err
 	}
-	pairInfo, _ := c.pairInfo(vars)
+	pairInfo, _ := c.pairInfo(ctx, vars)
 	return instantswap.ExchangeRateInfo{
-		Min:             utils.StrToFloat(pairInfo.MinimumAmount),
-		Max:             utils.StrToFloat(pairInfo.MaximumAmount),
-		ExchangeRate:    utils.StrToFloat(rate.Rate),
-		EstimatedAmount: utils.StrToFloat(rate.ReceiveAmount),
-		MaxOrder:        0,
+		Min:             pairInfo.MinimumAmount,
+		Max:             pairInfo.MaximumAmount,
+		ExchangeRate:    rate.Rate,
+		EstimatedAmount: rate.ReceiveAmount,
+		MaxOrder:        instantswap.Amount{},
 		Signature:       "",
+		RateType:        rateType,
 	}, nil
 }
 
-func (c *EasyBit) pairInfo(vars instantswap.ExchangeRateRequest) (PairInfo, error) {
-	r, err := c.client.Do(API_BASE, http.MethodGet,
+func (c *EasyBit) pairInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (PairInfo, error) {
+	r, err := c.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("pairInfo?send=%s&receive=%s", vars.From, vars.To), "", false)
 	if err != nil {
 		return PairInfo{}, err
@@ -127,52 +139,66 @@ func (c *EasyBit) pairInfo(vars instantswap.ExchangeRateRequest) (PairInfo, erro
 	return pair, err
 }
 
-func (c *EasyBit) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return
+func (c *EasyBit) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
-func (c *EasyBit) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *EasyBit) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
 	var orderRequest = map[string]string{
 		"send":           vars.FromCurrency,
 		"receive":        vars.ToCurrency,
-		"amount":         fmt.Sprintf("%.8f", vars.InvoicedAmount),
+		"amount":         vars.InvoicedAmount.String(),
 		"receiveAddress": vars.Destination,
 	}
 	payload, err := json.Marshal(orderRequest)
 	if err != nil {
 		return res, err
 	}
-	r, err := c.client.Do(API_BASE, http.MethodPost, "order", string(payload), false)
+	r, err := c.client.Do(ctx, API_BASE, http.MethodPost, "order", string(payload), false)
 	if err != nil {
 		return res, err
 	}
 	var order Order
 	err = parseDataResponse(r, &order)
 	return instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.ReceiveAddress,
-		ExchangeRate:   0,
+		ExchangeRate:   instantswap.Amount{},
 		FromCurrency:   order.Send,
-		InvoicedAmount: utils.StrToFloat(order.SendAmount),
-		OrderedAmount:  utils.StrToFloat(order.ReceiveAmount),
+		InvoicedAmount: order.SendAmount,
+		OrderedAmount:  order.ReceiveAmount,
 		ToCurrency:     order.Receive,
 		UUID:           order.Id,
 		DepositAddress: order.SendAddress,
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}, err
 }
 
 // UpdateOrder accepts orderID value and more if needed per lib
-func (c *EasyBit) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (c *EasyBit) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
+}
+func (c *EasyBit) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (c *EasyBit) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
 }
-func (c *EasyBit) CancelOrder(orderID string) (res string, err error) {
-	return
+func (c *EasyBit) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
-func (c *EasyBit) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	r, err := c.client.Do(API_BASE, http.MethodGet,
+func (c *EasyBit) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	r, err := c.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("orders?id=%s", req.OrderId), "", false)
 	if err != nil {
 		return res, err
@@ -191,7 +217,7 @@ func (c *EasyBit) OrderInfo(req instantswap.TrackingRequest) (res instantswap.Or
 			return instantswap.OrderInfoResult{
 				Expires:        0,
 				LastUpdate:     "",
-				ReceiveAmount:  utils.StrToFloat(order.ReceiveAmount),
+				ReceiveAmount:  order.ReceiveAmount,
 				TxID:           txId,
 				Status:         order.Status,
 				InternalStatus: mapOrderStatus(order.Status),
@@ -199,7 +225,7 @@ func (c *EasyBit) OrderInfo(req instantswap.TrackingRequest) (res instantswap.Or
 			}, nil
 		}
 	}
-	return res, fmt.Errorf("order[%s] not found", req.OrderId)
+	return res, instantswap.NewError(LIBNAME, instantswap.ErrOrderNotFound, fmt.Sprintf("order[%s] not found", req.OrderId))
 }
 
 // "Refund" or "Failed" or "Volatility Protection" or "Action Request" or "Request Overdue"
@@ -3,6 +3,8 @@ package easybit
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 type general struct {
@@ -19,11 +21,25 @@ func parseDataResponse(r []byte, obj interface{}) error {
 		return err
 	}
 	if res.Success == 0 {
-		return fmt.Errorf("error[%d]: %s", res.ErrorCode, res.ErrorMessage)
+		return &instantswap.ExchangeError{
+			Exchange: LIBNAME,
+			Kind:     instantswap.ErrorKind(res.ErrorMessage),
+			Body:     r,
+			Message:  fmt.Sprintf("error[%d]: %s", res.ErrorCode, res.ErrorMessage),
+		}
 	}
 	return json.Unmarshal(res.Data, obj)
 }
 
+// parseError reads the error message of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var res general
+	if json.Unmarshal(body, &res) != nil || res.ErrorMessage == "" {
+		return nil, ""
+	}
+	return instantswap.ErrorKind(res.ErrorMessage), fmt.Sprintf("error[%d]: %s", res.ErrorCode, res.ErrorMessage)
+}
+
 type Currency struct {
 	Currency         string    `json:"currency"`
 	Name             string    `json:"name"`
@@ -32,6 +48,16 @@ type Currency struct {
 	NetworkList      []Network `json:"networkList"`
 }
 
+// defaultNetwork returns the network flagged as default by easybit.
+func (c Currency) defaultNetwork() string {
+	for _, network := range c.NetworkList {
+		if network.IsDefault {
+			return network.Network
+		}
+	}
+	return ""
+}
+
 type Network struct {
 	Network              string      `json:"network"`
 	Name                 string      `json:"name"`
@@ -49,42 +75,42 @@ type Network struct {
 }
 
 type ExchangeRate struct {
-	Rate           string `json:"rate"`
-	SendAmount     string `json:"sendAmount"`
-	ReceiveAmount  string `json:"receiveAmount"`
-	NetworkFee     string `json:"networkFee"`
-	Confirmations  int    `json:"confirmations"`
-	ProcessingTime string `json:"processingTime"`
+	Rate           instantswap.Amount `json:"rate"`
+	SendAmount     instantswap.Amount `json:"sendAmount"`
+	ReceiveAmount  instantswap.Amount `json:"receiveAmount"`
+	NetworkFee     string             `json:"networkFee"`
+	Confirmations  int                `json:"confirmations"`
+	ProcessingTime string             `json:"processingTime"`
 }
 
 type PairInfo struct {
-	MinimumAmount  string `json:"minimumAmount"`
-	MaximumAmount  string `json:"maximumAmount"`
-	NetworkFee     string `json:"networkFee"`
-	Confirmations  int    `json:"confirmations"`
-	ProcessingTime string `json:"processingTime"`
+	MinimumAmount  instantswap.Amount `json:"minimumAmount"`
+	MaximumAmount  instantswap.Amount `json:"maximumAmount"`
+	NetworkFee     string             `json:"networkFee"`
+	Confirmations  int                `json:"confirmations"`
+	ProcessingTime string             `json:"processingTime"`
 }
 
 type Order struct {
-	Id             string      `json:"id"`
-	Send           string      `json:"send"`
-	Receive        string      `json:"receive"`
-	SendNetwork    string      `json:"sendNetwork"`
-	ReceiveNetwork string      `json:"receiveNetwork"`
-	SendAmount     string      `json:"sendAmount"`
-	ReceiveAmount  string      `json:"receiveAmount"`
-	SendAddress    string      `json:"sendAddress"`
-	SendTag        interface{} `json:"sendTag"`
-	ReceiveAddress string      `json:"receiveAddress"`
-	ReceiveTag     interface{} `json:"receiveTag"`
-	RefundAddress  interface{} `json:"refundAddress"`
-	RefundTag      interface{} `json:"refundTag"`
-	Vpm            string      `json:"vpm"`
-	CreatedAt      int64       `json:"createdAt"`
-	Status         string      `json:"status"`
-	HashIn         interface{} `json:"hashIn"`
-	HashOut        interface{} `json:"hashOut"`
-	NetworkFee     string      `json:"networkFee"`
-	Earned         string      `json:"earned"`
-	UpdatedAt      int64       `json:"updatedAt"`
+	Id             string             `json:"id"`
+	Send           string             `json:"send"`
+	Receive        string             `json:"receive"`
+	SendNetwork    string             `json:"sendNetwork"`
+	ReceiveNetwork string             `json:"receiveNetwork"`
+	SendAmount     instantswap.Amount `json:"sendAmount"`
+	ReceiveAmount  instantswap.Amount `json:"receiveAmount"`
+	SendAddress    string             `json:"sendAddress"`
+	SendTag        interface{}        `json:"sendTag"`
+	ReceiveAddress string             `json:"receiveAddress"`
+	ReceiveTag     interface{}        `json:"receiveTag"`
+	RefundAddress  interface{}        `json:"refundAddress"`
+	RefundTag      interface{}        `json:"refundTag"`
+	Vpm            string             `json:"vpm"`
+	CreatedAt      int64              `json:"createdAt"`
+	Status         string             `json:"status"`
+	HashIn         interface{}        `json:"hashIn"`
+	HashOut        interface{}        `json:"hashOut"`
+	NetworkFee     string             `json:"networkFee"`
+	Earned         string             `json:"earned"`
+	UpdatedAt      int64              `json:"updatedAt"`
 }
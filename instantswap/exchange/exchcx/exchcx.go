@@ -1,16 +1,18 @@
 package exchcx
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/vibros68/instantswap/instantswap"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
-const LIBNAME = "exchcx"
+const (
+	API_BASE = "https://exch.cx/api/"
+	LIBNAME  = "exchcx"
+)
 
 func init() {
 	instantswap.RegisterExchange(LIBNAME, func(config instantswap.ExchangeConfig) (instantswap.IDExchange, error) {
@@ -20,86 +22,99 @@ func init() {
 
 // New return a exchCx api client
 func New(conf instantswap.ExchangeConfig) (*ExchCx, error) {
-	return &ExchCx{}, nil
+	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
+		r.Header.Set("X-Requested-With", "XMLHttpRequest")
+		return nil
+	})
+	client.SetErrorFunc(parseError)
+	return &ExchCx{client: client, conf: &conf}, nil
 }
 
 type ExchCx struct {
+	client *instantswap.Client
+	conf   *instantswap.ExchangeConfig
 }
 
-func (e *ExchCx) Do(req *http.Request, resObj any) error {
-	client := &http.Client{}
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-	resp, err := client.Do(req)
+// Do sends a GET request to the resource and decodes the response to resObj.
+func (e *ExchCx) Do(ctx context.Context, resource string, resObj any) error {
+	body, err := e.client.Do(ctx, API_BASE, http.MethodGet, resource, "", false)
 	if err != nil {
 		return err
 	}
-	body, err := io.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
+	if err = instantswap.ResponseError(LIBNAME, http.StatusOK, body, parseError); err != nil {
 		return err
 	}
+	return json.Unmarshal(body, resObj)
+}
+
+// parseError reads the {"error"} payload returned on failure.
+func parseError(statusCode int, body []byte) (error, string) {
 	var exchErr Error
-	_ = json.Unmarshal(body, &exchErr)
-	if exchErr.Error != "" {
-		return fmt.Errorf(exchErr.Error)
+	if json.Unmarshal(body, &exchErr) != nil {
+		return nil, ""
 	}
-	return json.Unmarshal(body, resObj)
+	return nil, exchErr.Error
 }
 
 func (e *ExchCx) Name() string {
 	return LIBNAME
 }
 
-func (e *ExchCx) path(path string) string {
-	return fmt.Sprintf("https://exch.cx/api/%s", path)
+// Capabilities returns the features supported by exchcx.
+func (e *ExchCx) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		KYC:              instantswap.KYCNotRequired,
+	}
 }
 
-func (e *ExchCx) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	req, err := http.NewRequest(http.MethodGet, e.path("volume"), nil)
-	if err != nil {
-		return
-	}
+// SetDebug set enable/disable http request/response dump.
+func (e *ExchCx) SetDebug(enable bool) {
+	e.conf.Debug = enable
+}
+
+func (e *ExchCx) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	var volumnMap map[string]Volume
-	err = e.Do(req, &volumnMap)
+	err = e.Do(ctx, "volume", &volumnMap)
 	if err != nil {
 		return
 	}
 	for currency, _ := range volumnMap {
-		currencies = append(currencies, instantswap.Currency{
-			Symbol: currency,
-			Name:   currency,
-		})
+		currencies = append(currencies, instantswap.NewCurrency(currency, currency, ""))
 	}
 	return
 }
 
-func (e *ExchCx) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	req, err := http.NewRequest(http.MethodGet, e.path("rates"), nil)
-	if err != nil {
-		return
-	}
+func (e *ExchCx) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
 	from = strings.ToUpper(from)
 	var rateMap map[string]Rate
-	err = e.Do(req, &rateMap)
+	err = e.Do(ctx, "rates", &rateMap)
 	if err != nil {
 		return
 	}
 	for currencyPair, _ := range rateMap {
 		var pair = strings.Split(currencyPair, "_")
 		if len(pair) == 2 && pair[0] == from {
-			currencies = append(currencies, instantswap.Currency{
-				Symbol: pair[1],
-			})
+			currencies = append(currencies, instantswap.NewCurrency("", pair[1], ""))
 		}
 	}
 	return
 }
 
-func (e *ExchCx) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return
+func (e *ExchCx) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (e *ExchCx) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (e *ExchCx) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, e.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
 	var params = url.Values{}
 	params.Set("from_currency", vars.FromCurrency)
 	params.Set("to_currency", vars.ToCurrency)
@@ -107,93 +122,104 @@ func (e *ExchCx) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Crea
 	params.Set("refund_address", vars.RefundAddress)
 	params.Set("rate_mode", "flat")
 	params.Set("fee_option", "s")
-	req, err := http.NewRequest(http.MethodGet, e.path("create?"+params.Encode()), nil)
-	if err != nil {
-		return res, err
-	}
 	var createResponse struct {
 		OrderId string `json:"orderid"`
 	}
-	err = e.Do(req, &createResponse)
+	// the order is created by a GET request, it must not be retried.
+	err = e.Do(instantswap.WithNotIdempotent(ctx), "create?"+params.Encode(), &createResponse)
 	if err != nil {
 		return res, err
 	}
-	order, err := e.getOrder(createResponse.OrderId)
+	order, err := e.getOrder(ctx, createResponse.OrderId)
 	if err != nil {
 		return res, err
 	}
 	res = instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.ToAddress,
 		ExchangeRate:   order.Rate,
 		FromCurrency:   order.FromCurrency,
-		InvoicedAmount: 0,
-		OrderedAmount:  0,
+		InvoicedAmount: instantswap.Amount{},
+		OrderedAmount:  instantswap.Amount{},
 		ToCurrency:     order.ToCurrency,
 		UUID:           "",
 		DepositAddress: order.FromAddr,
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}
 	return
 }
 
-func (e *ExchCx) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (e *ExchCx) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
 
-func (e *ExchCx) CancelOrder(orderID string) (res string, err error) {
-	return
+func (e *ExchCx) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
 }
 
-func (e *ExchCx) getOrder(orderId string) (*Order, error) {
-	req, err := http.NewRequest(http.MethodGet, e.path("order?orderid="+orderId), nil)
-	if err != nil {
-		return nil, err
-	}
+func (e *ExchCx) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+
+func (e *ExchCx) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
+}
+
+func (e *ExchCx) getOrder(ctx context.Context, orderId string) (*Order, error) {
 	var order Order
-	err = e.Do(req, &order)
+	err := e.Do(ctx, "order?orderid="+orderId, &order)
 	if err != nil {
 		return nil, err
 	}
 	return &order, nil
 }
 
-func (e *ExchCx) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	order, err := e.getOrder(req.OrderId)
+func (e *ExchCx) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	order, err := e.getOrder(ctx, req.OrderId)
 	if err != nil {
 		return
 	}
 	res = instantswap.OrderInfoResult{
 		Expires:        0,
 		LastUpdate:     "",
-		ReceiveAmount:  *order.ToAmount,
-		TxID:           *order.TransactionIdSent,
 		Status:         order.State,
 		InternalStatus: statusMap[order.State],
 		Confirmations:  "",
 	}
+	// to_amount and transaction_id_sent are null until the order is sent
+	if order.ToAmount != nil {
+		res.ReceiveAmount = *order.ToAmount
+	}
+	if order.TransactionIdSent != nil {
+		res.TxID = *order.TransactionIdSent
+	}
 	return
 }
 
-func (e *ExchCx) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	req, err := http.NewRequest(http.MethodGet, e.path("rates"), nil)
+func (e *ExchCx) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, e.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
 	if err != nil {
-		return
+		return res, err
 	}
 	var rateMap map[string]Rate
-	err = e.Do(req, &rateMap)
+	err = e.Do(ctx, "rates", &rateMap)
 	if err != nil {
 		return
 	}
 	var pair = strings.ToUpper(vars.From) + "_" + strings.ToUpper(vars.To)
 	var rate, ok = rateMap[pair]
 	if !ok {
-		err = fmt.Errorf("exchange rate info not found")
+		err = instantswap.NewError(LIBNAME, instantswap.ErrPairNotSupported, "exchange rate info not found")
 		return
 	}
 	res.ExchangeRate = rate.Rate
+	res.RateType = rateType
 	return
 }
 
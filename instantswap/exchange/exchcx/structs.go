@@ -1,5 +1,7 @@
 package exchcx
 
+import "github.com/vibros68/instantswap/instantswap"
+
 type Error struct {
 	Error string `json:"error"`
 }
@@ -14,10 +16,10 @@ type T struct {
 		M string `json:"m"`
 		S string `json:"s"`
 	} `json:"network_fee"`
-	Rate     string  `json:"rate"`
-	RateMode string  `json:"rate_mode"`
-	Reserve  float64 `json:"reserve"`
-	SvcFee   string  `json:"svc_fee"`
+	Rate     string             `json:"rate"`
+	RateMode string             `json:"rate_mode"`
+	Reserve  instantswap.Amount `json:"reserve"`
+	SvcFee   string             `json:"svc_fee"`
 }
 
 type Rate struct {
@@ -26,28 +28,28 @@ type Rate struct {
 		M any `json:"m"`
 		S any `json:"s"`
 	} `json:"network_fee"`
-	Rate     float64 `json:"rate,string"`
-	RateMode string  `json:"rate_mode"`
-	Reserve  any     `json:"reserve"`
-	SvcFee   string  `json:"svc_fee"`
+	Rate     instantswap.Amount `json:"rate"`
+	RateMode string             `json:"rate_mode"`
+	Reserve  any                `json:"reserve"`
+	SvcFee   string             `json:"svc_fee"`
 }
 
 type Order struct {
-	Created               int      `json:"created"`
-	FromAddr              string   `json:"from_addr"`
-	FromAmountReceived    *float64 `json:"from_amount_received"`
-	FromCurrency          string   `json:"from_currency"`
-	MaxInput              string   `json:"max_input"`
-	MinInput              string   `json:"min_input"`
-	NetworkFee            float64  `json:"network_fee,string"`
-	OrderId               string   `json:"orderid"`
-	Rate                  float64  `json:"rate,string"`
-	RateMode              string   `json:"rate_mode"`
-	State                 string   `json:"state"`
-	SvcFee                string   `json:"svc_fee"`
-	ToAddress             string   `json:"to_address"`
-	ToAmount              *float64 `json:"to_amount"`
-	ToCurrency            string   `json:"to_currency"`
-	TransactionIdReceived *string  `json:"transaction_id_received"`
-	TransactionIdSent     *string  `json:"transaction_id_sent"`
+	Created               int                 `json:"created"`
+	FromAddr              string              `json:"from_addr"`
+	FromAmountReceived    *instantswap.Amount `json:"from_amount_received"`
+	FromCurrency          string              `json:"from_currency"`
+	MaxInput              string              `json:"max_input"`
+	MinInput              string              `json:"min_input"`
+	NetworkFee            instantswap.Amount  `json:"network_fee"`
+	OrderId               string              `json:"orderid"`
+	Rate                  instantswap.Amount  `json:"rate"`
+	RateMode              string              `json:"rate_mode"`
+	State                 string              `json:"state"`
+	SvcFee                string              `json:"svc_fee"`
+	ToAddress             string              `json:"to_address"`
+	ToAmount              *instantswap.Amount `json:"to_amount"`
+	ToCurrency            string              `json:"to_currency"`
+	TransactionIdReceived *string             `json:"transaction_id_received"`
+	TransactionIdSent     *string             `json:"transaction_id_sent"`
 }
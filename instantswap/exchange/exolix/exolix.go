@@ -1,6 +1,7 @@
 package exolix
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -18,6 +19,35 @@ const (
 	LIBNAME  = "exolix"
 )
 
+// codes maps the exolix network codes, the ticker of the network coin.
+var codes = instantswap.CodeMap{Networks: map[string]string{
+	"BTC":      instantswap.NetworkBitcoin,
+	"ETH":      instantswap.NetworkEthereum,
+	"TRX":      instantswap.NetworkTron,
+	"BSC":      instantswap.NetworkBSC,
+	"SOL":      instantswap.NetworkSolana,
+	"MATIC":    instantswap.NetworkPolygon,
+	"ARBITRUM": instantswap.NetworkArbitrum,
+	"OPTIMISM": instantswap.NetworkOptimism,
+	"BASE":     instantswap.NetworkBase,
+	"AVAXC":    instantswap.NetworkAvalanche,
+	"TON":      instantswap.NetworkTON,
+	"LTC":      instantswap.NetworkLitecoin,
+	"DCR":      instantswap.NetworkDecred,
+	"XMR":      instantswap.NetworkMonero,
+	"DOGE":     instantswap.NetworkDogecoin,
+	"BCH":      instantswap.NetworkBitcoinCash,
+	"DASH":     instantswap.NetworkDash,
+	"ZEC":      instantswap.NetworkZcash,
+	"ETC":      instantswap.NetworkEthereumClassic,
+	"XRP":      instantswap.NetworkRipple,
+	"XLM":      instantswap.NetworkStellar,
+	"ADA":      instantswap.NetworkCardano,
+	"DOT":      instantswap.NetworkPolkadot,
+	"ATOM":     instantswap.NetworkCosmos,
+	"ALGO":     instantswap.NetworkAlgorand,
+}}
+
 type Exolix struct {
 	client *instantswap.Client
 	conf   *instantswap.ExchangeConfig
@@ -41,6 +71,22 @@ func (e *Exolix) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by exolix.
+func (e *Exolix) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		ReverseQuote:     true,
+		Networks:         true,
+		CurrenciesToPair: true,
+		Refund:           true,
+		RefundAddress:    true,
+		ExtraID:          true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (e *Exolix) SetDebug(enable bool) {
 	e.conf.Debug = enable
@@ -49,19 +95,20 @@ func (e *Exolix) SetDebug(enable bool) {
 // New return a exolix client.
 func New(conf instantswap.ExchangeConfig) (*Exolix, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		r.Header.Set("Authorization", conf.ApiKey)
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	exolixObj := &Exolix{client: client, conf: &conf}
 	// Set the currencies cache validity time to 30 days
 	exolixObj.cache.effectivePeriod = 720 * time.Hour
 	return exolixObj, nil
 }
 
-func (e *Exolix) fetchCurrencies() (currencies []instantswap.Currency, err error) {
+func (e *Exolix) fetchCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	pageSize := "100" // maximum allowable of pagesize
 	currentPage := 1
 	var exoCurrencies []Currency
@@ -72,7 +119,7 @@ func (e *Exolix) fetchCurrencies() (currencies []instantswap.Currency, err error
 		params.Add("withNetworks", "true")
 		query := params.Encode()
 		// handler get currencies
-		r, err := e.client.Do(API_BASE, http.MethodGet,
+		r, err := e.client.Do(ctx, API_BASE, http.MethodGet,
 			fmt.Sprintf("currencies?%s", query), "", false)
 		if err != nil {
 			return nil, err
@@ -93,23 +140,16 @@ func (e *Exolix) fetchCurrencies() (currencies []instantswap.Currency, err error
 	for _, curr := range exoCurrencies {
 		if len(curr.Networks) > 0 {
 			for _, net := range curr.Networks {
-				currencies = append(currencies, instantswap.Currency{
-					Name:    curr.Name,
-					Symbol:  strings.ToLower(curr.Code),
-					Network: net.Network,
-				})
+				currencies = append(currencies, codes.NewCurrency(curr.Name, curr.Code, net.Network))
 			}
 		} else {
-			currencies = append(currencies, instantswap.Currency{
-				Name:   curr.Name,
-				Symbol: strings.ToLower(curr.Code),
-			})
+			currencies = append(currencies, codes.NewCurrency(curr.Name, curr.Code, ""))
 		}
 	}
 	return currencies, nil
 }
 
-func (e *Exolix) GetCurrencies() (currencies []instantswap.Currency, err error) {
+func (e *Exolix) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	e.cache.mu.RLock()
 	// if cache is valid and element exists in array
 	if time.Since(e.cache.lastUpdate) < e.cache.effectivePeriod && len(e.cache.currencies) > 0 {
@@ -119,7 +159,7 @@ func (e *Exolix) GetCurrencies() (currencies []instantswap.Currency, err error)
 	e.cache.mu.RUnlock()
 
 	// Fetch currencies
-	currencies, err = e.fetchCurrencies()
+	currencies, err = e.fetchCurrencies(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -133,9 +173,9 @@ func (e *Exolix) GetCurrencies() (currencies []instantswap.Currency, err error)
 	return currencies, nil
 }
 
-func (e *Exolix) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
+func (e *Exolix) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
 	// get all currencies
-	allCurrencies, err := e.GetCurrencies()
+	allCurrencies, err := e.GetCurrencies(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -147,22 +187,32 @@ func (e *Exolix) GetCurrenciesToPair(from string) (currencies []instantswap.Curr
 	return currencies, nil
 }
 
-func (e *Exolix) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (e *Exolix) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	rateType, err := e.rateType(vars.Reverse(), vars.RateType)
+	if err != nil {
+		return res, err
+	}
 	var rateResponse RateResponse
+	from, fromNetwork := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, toNetwork := codes.ProviderCurrency(vars.To, vars.ToNetwork)
 	params := url.Values{}
-	params.Add("coinFrom", vars.From)
-	params.Add("coinTo", vars.To)
-	params.Add("amount", fmt.Sprintf("%f", vars.Amount))
-	params.Add("rateType", "fixed")
-	if vars.FromNetwork != "" {
-		params.Add("networkFrom", vars.FromNetwork)
+	params.Add("coinFrom", from)
+	params.Add("coinTo", to)
+	if vars.Reverse() {
+		params.Add("withdrawalAmount", vars.ReceiveAmount.String())
+	} else {
+		params.Add("amount", vars.Amount.String())
 	}
-	if vars.ToNetwork != "" {
-		params.Add("networkTo", vars.ToNetwork)
+	params.Add("rateType", string(rateType))
+	if fromNetwork != "" {
+		params.Add("networkFrom", fromNetwork)
+	}
+	if toNetwork != "" {
+		params.Add("networkTo", toNetwork)
 	}
 	query := params.Encode()
 	endpoint := fmt.Sprintf("rate?%s", query)
-	r, rerr := e.client.Do(API_BASE, http.MethodGet, endpoint, "", false)
+	r, rerr := e.client.Do(ctx, API_BASE, http.MethodGet, endpoint, "", false)
 	if rerr != nil {
 		err = rerr
 		return
@@ -176,29 +226,53 @@ func (e *Exolix) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res
 		Min:             rateResponse.MinAmount,
 		Max:             rateResponse.MaxAmount,
 		EstimatedAmount: rateResponse.ToAmount,
+		RateType:        rateType,
+	}
+	if vars.Reverse() {
+		res.DepositAmount = rateResponse.FromAmount
 	}
 	return
 }
 
-func (e *Exolix) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return res, fmt.Errorf("not supported")
+// rateType returns the rate mode of a request, the reverse ones are only
+// supported at fixed rate.
+func (e *Exolix) rateType(reverse bool, requested instantswap.RateType) (instantswap.RateType, error) {
+	if reverse {
+		return instantswap.ChooseReverseRateType(LIBNAME, e.Capabilities(), requested)
+	}
+	return instantswap.ChooseRateType(LIBNAME, e.Capabilities(), requested, instantswap.RateTypeFixed)
+}
+
+func (e *Exolix) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (e *Exolix) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (e *Exolix) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	rateType, err := e.rateType(vars.Reverse(), vars.RateType)
+	if err != nil {
+		return res, err
+	}
+	from, fromNetwork := codes.ProviderCurrency(vars.FromCurrency, vars.FromNetwork)
+	to, toNetwork := codes.ProviderCurrency(vars.ToCurrency, vars.ToNetwork)
 	var req = OrderRequest{
-		CoinFrom:          vars.FromCurrency,
-		CoinTo:            vars.ToCurrency,
-		NetworkFrom:       vars.FromNetwork,
-		NetworkTo:         vars.ToNetwork,
-		Amount:            vars.InvoicedAmount,
+		CoinFrom:          from,
+		CoinTo:            to,
+		NetworkFrom:       fromNetwork,
+		NetworkTo:         toNetwork,
+		Amount:            json.Number(vars.InvoicedAmount.String()),
 		WithdrawalAddress: vars.Destination,
-		WithdrawalAmount:  vars.OrderedAmount,
 		WithdrawalExtraId: vars.ExtraID,
 		RefundAddress:     vars.RefundAddress,
 		RefundExtraId:     vars.RefundExtraID,
+		RateType:          string(rateType),
+	}
+	// the reverse orders are for the amount to receive.
+	if vars.Reverse() {
+		req.Amount = ""
+		req.WithdrawalAmount = json.Number(vars.OrderedAmount.String())
 	}
 	body, _ := json.Marshal(req)
-	r, err := e.client.Do(API_BASE, http.MethodPost, "transactions", string(body), false)
+	r, err := e.client.Do(ctx, API_BASE, http.MethodPost, "transactions", string(body), false)
 	if err != nil {
 		return res, err
 	}
@@ -208,7 +282,7 @@ func (e *Exolix) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Crea
 		return res, err
 	}
 	res = instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.WithdrawalAddress,
 		ExchangeRate:   order.Rate,
 		FromCurrency:   order.CoinFrom.CoinCode,
@@ -220,24 +294,23 @@ func (e *Exolix) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Crea
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
+	}
+	if order.RateType != "" {
+		res.RateType = instantswap.RateType(order.RateType)
 	}
 	return res, nil
 }
 
-func (e *Exolix) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (e *Exolix) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
-func (e *Exolix) CancelOrder(orderID string) (res string, err error) {
-	return
+func (e *Exolix) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
-func (e *Exolix) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	r, err := e.client.Do(API_BASE, http.MethodGet, fmt.Sprintf("transactions/%s", req.OrderId), "", false)
-	if err != nil {
-		return res, err
-	}
-	var order Order
-	err = parseResponseData(r, &order)
+func (e *Exolix) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	order, err := e.transaction(ctx, req.OrderId)
 	if err != nil {
 		return res, err
 	}
@@ -257,10 +330,104 @@ func (e *Exolix) OrderInfo(req instantswap.TrackingRequest) (res instantswap.Ord
 	return
 }
 
+func (e *Exolix) transaction(ctx context.Context, id string) (order Order, err error) {
+	r, err := e.client.Do(ctx, API_BASE, http.MethodGet, fmt.Sprintf("transactions/%s", id), "", false)
+	if err != nil {
+		return order, err
+	}
+	err = parseResponseData(r, &order)
+	return order, err
+}
+
+// RefundInfo returns the refund of a transaction, the overdue transactions
+// with a deposit wait for a refund address.
+func (e *Exolix) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	order, err := e.transaction(ctx, orderID)
+	if err != nil {
+		return res, err
+	}
+	return refundInfo(order), nil
+}
+
+// SubmitRefund gives the refund address of an overdue transaction.
+func (e *Exolix) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	res, err = e.RefundInfo(ctx, vars.UUID)
+	if err != nil {
+		return res, err
+	}
+	if !res.Eligible() {
+		return res, instantswap.RefundNotEligibleError(LIBNAME, res)
+	}
+	body, err := json.Marshal(RefundRequest{
+		RefundAddress: vars.Address,
+		RefundExtraId: vars.ExtraID,
+	})
+	if err != nil {
+		return res, err
+	}
+	r, err := e.client.Do(ctx, API_BASE, http.MethodPost, fmt.Sprintf("transactions/%s/refund", vars.UUID), string(body), false)
+	if err != nil {
+		return res, err
+	}
+	var order Order
+	err = parseResponseData(r, &order)
+	if err != nil {
+		return res, err
+	}
+	return refundInfo(order), nil
+}
+
+func refundInfo(order Order) instantswap.RefundInfo {
+	info := instantswap.RefundInfo{
+		UUID:   order.Id,
+		Status: instantswap.RefundStatusNotEligible,
+		Amount: order.Amount,
+	}
+	if order.RefundAddress != nil {
+		info.Address = *order.RefundAddress
+	}
+	if order.RefundExtraId != nil {
+		info.ExtraID = *order.RefundExtraId
+	}
+	switch order.Status {
+	case "overdue":
+		// the overdue transactions without a deposit have nothing to refund.
+		if order.HashIn.Hash == nil {
+			break
+		}
+		info.Status = instantswap.RefundStatusEligible
+		if info.Address != "" {
+			info.Status = instantswap.RefundStatusPending
+		}
+	case "refunded":
+		info.Status = instantswap.RefundStatusCompleted
+		if order.HashOut.Hash != nil {
+			info.TxID = *order.HashOut.Hash
+		}
+	}
+	return info
+}
+
 func parseResponseData(data []byte, obj interface{}) error {
 	return json.Unmarshal(data, obj)
 }
 
+// parseError reads the message of a failed response. The limits of a failed
+// rate response tell when the amount is out of range.
+func parseError(statusCode int, body []byte) (error, string) {
+	var rate RateResponse
+	if json.Unmarshal(body, &rate) != nil || rate.Message == nil {
+		return nil, ""
+	}
+	switch {
+	case rate.MinAmount.Sign() > 0 && rate.FromAmount.Cmp(rate.MinAmount) < 0:
+		return instantswap.ErrAmountBelowMin, *rate.Message
+	case rate.MaxAmount.Sign() > 0 && rate.FromAmount.Cmp(rate.MaxAmount) > 0:
+		return instantswap.ErrAmountAboveMax, *rate.Message
+	}
+	return nil, *rate.Message
+}
+
 // wait, confirmation, confirmed, exchanging, sending, success, overdue, refunded
 func parseStatus(status string) instantswap.Status {
 	switch status {
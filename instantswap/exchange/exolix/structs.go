@@ -1,6 +1,10 @@
 package exolix
 
-import "time"
+import (
+	"encoding/json"
+	"github.com/vibros68/instantswap/instantswap"
+	"time"
+)
 
 // struct for get currencies
 type CurrencyResponse struct {
@@ -35,51 +39,57 @@ type Network struct {
 
 // struct for get exchange rate
 type RateResponse struct {
-	FromAmount  float64 `json:"fromAmount"`
-	ToAmount    float64 `json:"toAmount"`
-	Rate        float64 `json:"rate"`
-	Message     *string `json:"message"`
-	MinAmount   float64 `json:"minAmount"`
-	WithdrawMin float64 `json:"withdrawMin"`
-	MaxAmount   float64 `json:"maxAmount"`
+	FromAmount  instantswap.Amount `json:"fromAmount"`
+	ToAmount    instantswap.Amount `json:"toAmount"`
+	Rate        instantswap.Amount `json:"rate"`
+	Message     *string            `json:"message"`
+	MinAmount   instantswap.Amount `json:"minAmount"`
+	WithdrawMin instantswap.Amount `json:"withdrawMin"`
+	MaxAmount   instantswap.Amount `json:"maxAmount"`
 }
 
 // struct for create order request
 type OrderRequest struct {
-	CoinFrom          string  `json:"coinFrom"`
-	CoinTo            string  `json:"coinTo"`
-	NetworkFrom       string  `json:"networkFrom"`
-	NetworkTo         string  `json:"networkTo"`
-	Amount            float64 `json:"amount"`
-	WithdrawalAmount  float64 `json:"withdrawalAmount,omitempty"`
-	WithdrawalAddress string  `json:"withdrawalAddress"`
-	WithdrawalExtraId string  `json:"withdrawalExtraId,omitempty"`
-	RateType          string  `json:"rateType,omitempty"`
-	RefundAddress     string  `json:"refundAddress,omitempty"`
-	RefundExtraId     string  `json:"refundExtraId,omitempty"`
-	Slippage          float64 `json:"slippage,omitempty"`
+	CoinFrom          string      `json:"coinFrom"`
+	CoinTo            string      `json:"coinTo"`
+	NetworkFrom       string      `json:"networkFrom"`
+	NetworkTo         string      `json:"networkTo"`
+	Amount            json.Number `json:"amount,omitempty"`
+	WithdrawalAmount  json.Number `json:"withdrawalAmount,omitempty"`
+	WithdrawalAddress string      `json:"withdrawalAddress"`
+	WithdrawalExtraId string      `json:"withdrawalExtraId,omitempty"`
+	RateType          string      `json:"rateType,omitempty"`
+	RefundAddress     string      `json:"refundAddress,omitempty"`
+	RefundExtraId     string      `json:"refundExtraId,omitempty"`
+	Slippage          float64     `json:"slippage,omitempty"`
 }
 
 // struct for order info
 type Order struct {
-	Id                string       `json:"id"`
-	Amount            float64      `json:"amount"`
-	AmountTo          float64      `json:"amountTo"`
-	CoinFrom          CoinContract `json:"coinFrom"`
-	CoinTo            CoinContract `json:"coinTo"`
-	Comment           *string      `json:"comment"`
-	CreatedAt         time.Time    `json:"createdAt"`
-	DepositAddress    string       `json:"depositAddress"`
-	DepositExtraId    *string      `json:"depositExtraId"`
-	WithdrawalAddress string       `json:"withdrawalAddress"`
-	WithdrawalExtraId string       `json:"withdrawalExtraId"`
-	HashIn            HashLink     `json:"hashIn"`
-	HashOut           HashLink     `json:"hashOut"`
-	Rate              float64      `json:"rate"`
-	RateType          string       `json:"rateType"`
-	RefundAddress     *string      `json:"refundAddress"`
-	RefundExtraId     *string      `json:"refundExtraId"`
-	Status            string       `json:"status"`
+	Id                string             `json:"id"`
+	Amount            instantswap.Amount `json:"amount"`
+	AmountTo          instantswap.Amount `json:"amountTo"`
+	CoinFrom          CoinContract       `json:"coinFrom"`
+	CoinTo            CoinContract       `json:"coinTo"`
+	Comment           *string            `json:"comment"`
+	CreatedAt         time.Time          `json:"createdAt"`
+	DepositAddress    string             `json:"depositAddress"`
+	DepositExtraId    *string            `json:"depositExtraId"`
+	WithdrawalAddress string             `json:"withdrawalAddress"`
+	WithdrawalExtraId string             `json:"withdrawalExtraId"`
+	HashIn            HashLink           `json:"hashIn"`
+	HashOut           HashLink           `json:"hashOut"`
+	Rate              instantswap.Amount `json:"rate"`
+	RateType          string             `json:"rateType"`
+	RefundAddress     *string            `json:"refundAddress"`
+	RefundExtraId     *string            `json:"refundExtraId"`
+	Status            string             `json:"status"`
+}
+
+// RefundRequest is the refund address of an overdue transaction.
+type RefundRequest struct {
+	RefundAddress string `json:"refundAddress"`
+	RefundExtraId string `json:"refundExtraId,omitempty"`
 }
 
 type CoinContract struct {
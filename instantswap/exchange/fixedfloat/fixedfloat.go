@@ -1,11 +1,11 @@
 package fixedfloat
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strings"
 
@@ -17,6 +17,18 @@ const (
 	LIBNAME  = "fixedfloat"
 )
 
+// codes maps the fixedfloat codes of the tokens issued on several networks,
+// the currency list gives the coin and network of the others.
+var codes = instantswap.CodeMap{Currencies: map[string]instantswap.CurrencyKey{
+	"USDT":    {Symbol: "USDT", Network: instantswap.NetworkEthereum},
+	"USDTTRC": {Symbol: "USDT", Network: instantswap.NetworkTron},
+	"USDTBSC": {Symbol: "USDT", Network: instantswap.NetworkBSC},
+	"USDTSOL": {Symbol: "USDT", Network: instantswap.NetworkSolana},
+	"USDC":    {Symbol: "USDC", Network: instantswap.NetworkEthereum},
+	"USDCBSC": {Symbol: "USDC", Network: instantswap.NetworkBSC},
+	"USDCSOL": {Symbol: "USDC", Network: instantswap.NetworkSolana},
+}}
+
 // The work on fixedfloat is pending
 func init() {
 	instantswap.RegisterExchange(LIBNAME, func(config instantswap.ExchangeConfig) (instantswap.IDExchange, error) {
@@ -34,7 +46,7 @@ type FixedFloat struct {
 // New return FixedFloat client.
 func New(conf instantswap.ExchangeConfig) (*FixedFloat, error) {
 	if conf.ApiKey == "" || conf.ApiSecret == "" {
-		return nil, fmt.Errorf("%s:error: api key and api secret must be provided", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "api key and api secret must be provided")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		key := []byte(conf.ApiSecret)
@@ -45,6 +57,7 @@ func New(conf instantswap.ExchangeConfig) (*FixedFloat, error) {
 		r.Header.Set("X-API-KEY", conf.ApiKey)
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &FixedFloat{client: client, conf: &conf}, nil
 }
 
@@ -52,14 +65,26 @@ func (c *FixedFloat) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by fixedfloat.
+func (c *FixedFloat) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		Limits:           true,
+		CurrenciesToPair: true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump
 func (c *FixedFloat) SetDebug(enable bool) {
 	c.conf.Debug = enable
 }
 
-func (c *FixedFloat) GetCurrencies() (currencies []instantswap.Currency, err error) {
+func (c *FixedFloat) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, http.MethodPost, "ccies", "", false)
+	r, err = c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, http.MethodPost, "ccies", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -67,28 +92,22 @@ func (c *FixedFloat) GetCurrencies() (currencies []instantswap.Currency, err err
 	err = parseResponseData(r, &ffCurrs)
 	currencies = make([]instantswap.Currency, len(ffCurrs))
 	for i, ffCurr := range ffCurrs {
-		currencies[i] = instantswap.Currency{
-			Name:   ffCurr.Name,
-			Symbol: ffCurr.Code,
-		}
+		currencies[i] = ffCurr.currency()
 	}
 	return currencies, err
 }
 
-func (c *FixedFloat) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
+func (c *FixedFloat) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, http.MethodPost, "ccies", "", false)
+	r, err = c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, http.MethodPost, "ccies", "", false)
 	if err != nil {
 		return nil, err
 	}
 	var ffCurrs []Currency
 	err = parseResponseData(r, &ffCurrs)
 	for _, ffCurr := range ffCurrs {
-		if strings.ToLower(from) != strings.ToLower(ffCurr.Code) {
-			currencies = append(currencies, instantswap.Currency{
-				Name:   ffCurr.Name,
-				Symbol: ffCurr.Code,
-			})
+		if !strings.EqualFold(from, ffCurr.Code) && !strings.EqualFold(from, ffCurr.Coin) {
+			currencies = append(currencies, ffCurr.currency())
 		}
 	}
 	return currencies, err
@@ -103,16 +122,25 @@ func buildBody(data interface{}) string {
 }
 
 // GetExchangeRateInfo get estimate on the amount for the exchange.
-func (c *FixedFloat) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (c *FixedFloat) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
+	from, _ := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.To, vars.ToNetwork)
 	f := PriceReq{
-		FromCcy:   vars.From,
-		ToCcy:     vars.To,
-		Amount:    vars.Amount,
+		FromCcy:   strings.ToUpper(from),
+		ToCcy:     strings.ToUpper(to),
+		Amount:    json.Number(vars.Amount.String()),
 		Direction: "from",
-		Type:      "fixed",
+		Type:      string(rateType),
 	}
 	var r []byte
-	r, err = c.client.Do(API_BASE, http.MethodPost, "price", buildBody(f), false)
+	r, err = c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, http.MethodPost, "price", buildBody(f), false)
 	if err != nil {
 		return res, err
 	}
@@ -126,22 +154,32 @@ func (c *FixedFloat) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (
 		Max:             priceRes.From.Max,
 		ExchangeRate:    priceRes.From.Rate,
 		EstimatedAmount: priceRes.To.Amount,
-		MaxOrder:        0,
+		MaxOrder:        instantswap.Amount{},
 		Signature:       "",
+		RateType:        rateType,
 	}, nil
 }
 
-func (c *FixedFloat) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *FixedFloat) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
+	from, _ := codes.ProviderCurrency(vars.FromCurrency, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.ToCurrency, vars.ToNetwork)
 	var f = CreateOrderRequest{
-		FromCcy:   vars.FromCurrency,
-		ToCcy:     vars.ToCurrency,
-		Amount:    vars.InvoicedAmount,
+		FromCcy:   strings.ToUpper(from),
+		ToCcy:     strings.ToUpper(to),
+		Amount:    json.Number(vars.InvoicedAmount.String()),
 		Direction: "from",
-		Type:      "fixed",
+		Type:      string(rateType),
 		ToAddress: vars.Destination,
 	}
 	var r []byte
-	r, err = c.client.Do(API_BASE, http.MethodPost, "create", buildBody(f), false)
+	r, err = c.client.Do(ctx, API_BASE, http.MethodPost, "create", buildBody(f), false)
 	if err != nil {
 		return res, err
 	}
@@ -150,10 +188,13 @@ func (c *FixedFloat) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 	if err != nil {
 		return res, err
 	}
+	if orderRes.Type != "" {
+		rateType = instantswap.RateType(orderRes.Type)
+	}
 	return instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    orderRes.From.Address,
-		ExchangeRate:   orderRes.From.Amount / orderRes.To.Amount,
+		ExchangeRate:   orderRes.From.Amount.Div(orderRes.To.Amount, instantswap.RatePrecision),
 		FromCurrency:   orderRes.From.Code,
 		InvoicedAmount: orderRes.From.Amount,
 		OrderedAmount:  orderRes.To.Amount,
@@ -163,21 +204,28 @@ func (c *FixedFloat) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 		Expires:        0,
 		ExtraID:        orderRes.Token,
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}, nil
 }
 
 // UpdateOrder accepts orderID value and more if needed per lib.
-func (c *FixedFloat) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (c *FixedFloat) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
+}
+func (c *FixedFloat) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (c *FixedFloat) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
 }
-func (c *FixedFloat) CancelOrder(orderID string) (res string, err error) {
-	return
+func (c *FixedFloat) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
 // OrderInfo accepts string of orderID value.
-func (c *FixedFloat) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (c *FixedFloat) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	if len(req.ExtraId) == 0 {
-		return res, fmt.Errorf("fetching fixedfloat order require order token")
+		return res, instantswap.NewError(LIBNAME, nil, "fetching fixedfloat order require order token")
 	}
 	var f = struct {
 		Id    string `json:"id"`
@@ -187,7 +235,7 @@ func (c *FixedFloat) OrderInfo(req instantswap.TrackingRequest) (res instantswap
 		Token: req.ExtraId,
 	}
 	var r []byte
-	r, err = c.client.Do(API_BASE, http.MethodPost, "order", buildBody(f), false)
+	r, err = c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, http.MethodPost, "order", buildBody(f), false)
 	if err != nil {
 		return res, err
 	}
@@ -2,7 +2,8 @@ package fixedfloat
 
 import (
 	"encoding/json"
-	"fmt"
+
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 type response struct {
@@ -20,7 +21,21 @@ func parseResponseData(r []byte, obj interface{}) error {
 	if code, ok := res.Code.(float64); ok && code == 0 {
 		return json.Unmarshal(res.Data, obj)
 	}
-	return fmt.Errorf(res.Msg)
+	return &instantswap.ExchangeError{
+		Exchange: LIBNAME,
+		Kind:     instantswap.ErrorKind(res.Msg),
+		Body:     r,
+		Message:  res.Msg,
+	}
+}
+
+// parseError reads the {"code", "msg"} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var res response
+	if json.Unmarshal(body, &res) != nil {
+		return nil, ""
+	}
+	return nil, res.Msg
 }
 
 type Currency struct {
@@ -36,49 +51,58 @@ type Currency struct {
 	Priority int         `json:"priority"`
 }
 
+// currency returns the canonical currency, fixedfloat gives the coin and the
+// network of its codes.
+func (c Currency) currency() instantswap.Currency {
+	if _, ok := codes.Currencies[c.Code]; ok || c.Coin == "" {
+		return codes.NewCurrency(c.Name, c.Code, c.Network)
+	}
+	return codes.NewCurrency(c.Name, c.Coin, c.Network)
+}
+
 // {"fromCcy":"BTC","toCcy":"USDTTRC","amount":0.5,"direction":"from","type":"float"}
 type PriceReq struct {
-	FromCcy   string  `json:"fromCcy"`
-	ToCcy     string  `json:"toCcy"`
-	Amount    float64 `json:"amount"`
-	Direction string  `json:"direction"`
-	Type      string  `json:"type"`
+	FromCcy   string      `json:"fromCcy"`
+	ToCcy     string      `json:"toCcy"`
+	Amount    json.Number `json:"amount"`
+	Direction string      `json:"direction"`
+	Type      string      `json:"type"`
 }
 
 type PriceResult struct {
 	From struct {
-		Code      string  `json:"code"`
-		Network   string  `json:"network"`
-		Coin      string  `json:"coin"`
-		Amount    float64 `json:"amount,string"`
-		Rate      float64 `json:"rate,string"`
-		Precision int     `json:"precision"`
-		Min       float64 `json:"min,string"`
-		Max       float64 `json:"max,string"`
-		Usd       float64 `json:"usd,string"`
-		Btc       float64 `json:"btc,string"`
+		Code      string             `json:"code"`
+		Network   string             `json:"network"`
+		Coin      string             `json:"coin"`
+		Amount    instantswap.Amount `json:"amount"`
+		Rate      instantswap.Amount `json:"rate"`
+		Precision int                `json:"precision"`
+		Min       instantswap.Amount `json:"min"`
+		Max       instantswap.Amount `json:"max"`
+		Usd       instantswap.Amount `json:"usd"`
+		Btc       instantswap.Amount `json:"btc"`
 	} `json:"from"`
 	To struct {
-		Code      string  `json:"code"`
-		Network   string  `json:"network"`
-		Coin      string  `json:"coin"`
-		Amount    float64 `json:"amount,string"`
-		Rate      float64 `json:"rate,string"`
-		Precision int     `json:"precision"`
-		Min       float64 `json:"min,string"`
-		Max       float64 `json:"max,string"`
-		Usd       float64 `json:"usd,string"`
+		Code      string             `json:"code"`
+		Network   string             `json:"network"`
+		Coin      string             `json:"coin"`
+		Amount    instantswap.Amount `json:"amount"`
+		Rate      instantswap.Amount `json:"rate"`
+		Precision int                `json:"precision"`
+		Min       instantswap.Amount `json:"min"`
+		Max       instantswap.Amount `json:"max"`
+		Usd       instantswap.Amount `json:"usd"`
 	} `json:"to"`
 	Errors []interface{} `json:"errors"`
 }
 
 type CreateOrderRequest struct {
-	FromCcy   string  `json:"fromCcy"`
-	ToCcy     string  `json:"toCcy"`
-	Amount    float64 `json:"amount"`
-	Direction string  `json:"direction"`
-	Type      string  `json:"type"`
-	ToAddress string  `json:"toAddress"`
+	FromCcy   string      `json:"fromCcy"`
+	ToCcy     string      `json:"toCcy"`
+	Amount    json.Number `json:"amount"`
+	Direction string      `json:"direction"`
+	Type      string      `json:"type"`
+	ToAddress string      `json:"toAddress"`
 }
 
 type OrderResponse struct {
@@ -95,18 +119,18 @@ type OrderResponse struct {
 		Left       int         `json:"left"`
 	} `json:"time"`
 	From struct {
-		Code             string      `json:"code"`
-		Coin             string      `json:"coin"`
-		Network          string      `json:"network"`
-		Name             string      `json:"name"`
-		Alias            string      `json:"alias"`
-		Amount           float64     `json:"amount,string"`
-		Address          string      `json:"address"`
-		AddressAlt       interface{} `json:"addressAlt"`
-		Tag              interface{} `json:"tag"`
-		TagName          interface{} `json:"tagName"`
-		ReqConfirmations int         `json:"reqConfirmations"`
-		MaxConfirmations int         `json:"maxConfirmations"`
+		Code             string             `json:"code"`
+		Coin             string             `json:"coin"`
+		Network          string             `json:"network"`
+		Name             string             `json:"name"`
+		Alias            string             `json:"alias"`
+		Amount           instantswap.Amount `json:"amount"`
+		Address          string             `json:"address"`
+		AddressAlt       interface{}        `json:"addressAlt"`
+		Tag              interface{}        `json:"tag"`
+		TagName          interface{}        `json:"tagName"`
+		ReqConfirmations int                `json:"reqConfirmations"`
+		MaxConfirmations int                `json:"maxConfirmations"`
 		Tx               struct {
 			Id            interface{} `json:"id"`
 			Amount        interface{} `json:"amount"`
@@ -118,15 +142,15 @@ type OrderResponse struct {
 		} `json:"tx"`
 	} `json:"from"`
 	To struct {
-		Code    string      `json:"code"`
-		Coin    string      `json:"coin"`
-		Network string      `json:"network"`
-		Name    string      `json:"name"`
-		Alias   string      `json:"alias"`
-		Amount  float64     `json:"amount,string"`
-		Address string      `json:"address"`
-		Tag     interface{} `json:"tag"`
-		TagName interface{} `json:"tagName"`
+		Code    string             `json:"code"`
+		Coin    string             `json:"coin"`
+		Network string             `json:"network"`
+		Name    string             `json:"name"`
+		Alias   string             `json:"alias"`
+		Amount  instantswap.Amount `json:"amount"`
+		Address string             `json:"address"`
+		Tag     interface{}        `json:"tag"`
+		TagName interface{}        `json:"tagName"`
 		Tx      struct {
 			Id            interface{} `json:"id"`
 			Amount        interface{} `json:"amount"`
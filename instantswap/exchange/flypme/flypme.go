@@ -1,12 +1,11 @@
 package flypme
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/vibros68/instantswap/instantswap"
 )
@@ -25,6 +24,8 @@ func init() {
 // New return a FlypMe struct.
 func New(conf instantswap.ExchangeConfig) (*FlypMe, error) {
 	client := instantswap.NewClient(LIBNAME, &conf)
+	client.SetErrorFunc(parseError)
+	client.SetDefaultRateLimit(instantswap.RateLimit{Rate: 1, Burst: 1})
 	return &FlypMe{
 		client: client,
 		conf:   &conf,
@@ -42,6 +43,19 @@ func (c *FlypMe) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by flypme.
+func (c *FlypMe) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		Limits:           true,
+		CurrenciesToPair: true,
+		Cancel:           true,
+		Update:           true,
+		RefundAddress:    true,
+		KYC:              instantswap.KYCUnknown,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (c *FlypMe) SetDebug(enable bool) {
 	c.conf.Debug = enable
@@ -53,22 +67,56 @@ func handleErr(r json.RawMessage) (err error) {
 		return err
 	}
 	if len(errorVals) > 0 {
-		var errorStr string
-		errorStr = LIBNAME + " error(s): "
-		for k, v := range errorVals {
-			errorStr += k + ": "
-			for i := 0; i < len(v); i++ {
-				errorStr += v[i] + ", "
-			}
+		kind, errorStr := errorsKind(errorVals)
+		return &instantswap.ExchangeError{
+			Exchange: LIBNAME,
+			Kind:     kind,
+			Body:     r,
+			Message:  errorStr,
 		}
-		err = errors.New(errorStr)
-		return
 	}
 	return nil
 }
 
-func (c *FlypMe) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "currencies", "", false)
+// fieldKinds maps the fields of the flypme validation errors to the error kinds.
+var fieldKinds = map[string]error{
+	"from_currency":  instantswap.ErrPairNotSupported,
+	"to_currency":    instantswap.ErrPairNotSupported,
+	"destination":    instantswap.ErrInvalidAddress,
+	"refund_address": instantswap.ErrInvalidAddress,
+	"uuid":           instantswap.ErrOrderNotFound,
+}
+
+// errorsKind returns the kind and the message of the {"field": ["error"]}
+// validation errors.
+func errorsKind(errorVals map[string][]string) (kind error, errorStr string) {
+	for k, v := range errorVals {
+		errorStr += k + ": " + strings.Join(v, ", ") + "; "
+		if kind == nil {
+			kind = fieldKinds[k]
+		}
+		if kind == nil {
+			kind = instantswap.ErrorKind(strings.Join(v, " "))
+		}
+	}
+	return kind, strings.TrimSuffix(errorStr, "; ")
+}
+
+// parseError reads the validation errors of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var res jsonResponse
+	if json.Unmarshal(body, &res) != nil || len(res.Errors) == 0 {
+		return nil, ""
+	}
+	var errorVals map[string][]string
+	if json.Unmarshal(res.Errors, &errorVals) != nil || len(errorVals) == 0 {
+		return nil, ""
+	}
+	return errorsKind(errorVals)
+}
+
+func (c *FlypMe) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencies", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -78,18 +126,13 @@ func (c *FlypMe) GetCurrencies() (currencies []instantswap.Currency, err error)
 		return nil, err
 	}
 	for _, currency := range cnCurrencies {
-		currencies = append(currencies, instantswap.Currency{
-			Name:     currency.Name,
-			Symbol:   strings.ToLower(currency.Code),
-			IsFiat:   false,
-			IsStable: false,
-		})
+		currencies = append(currencies, instantswap.NewCurrency(currency.Name, currency.Code, ""))
 	}
 	return currencies, nil
 }
 
-func (c *FlypMe) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, "GET", "currencies", "", false)
+func (c *FlypMe) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencies", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -102,27 +145,26 @@ func (c *FlypMe) GetCurrenciesToPair(from string) (currencies []instantswap.Curr
 		if strings.ToLower(from) == strings.ToLower(currency.Code) {
 			continue
 		}
-		currencies = append(currencies, instantswap.Currency{
-			Name:     currency.Name,
-			Symbol:   strings.ToLower(currency.Code),
-			IsFiat:   false,
-			IsStable: false,
-		})
+		currencies = append(currencies, instantswap.NewCurrency(currency.Name, currency.Code, ""))
 	}
 	return currencies, nil
 }
 
 // GetExchangeRateInfo get estimate on the amount for the exchange.
-func (c *FlypMe) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	limits, err := c.QueryLimits(vars.From, vars.To)
+func (c *FlypMe) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
+	limits, err := c.QueryLimits(ctx, vars.From, vars.To)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	time.Sleep(time.Second * 1)
-	exchangeRates, err := c.QueryRates(nil)
+	exchangeRates, err := c.QueryRates(ctx, nil)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var rate instantswap.QueryRate
@@ -133,10 +175,10 @@ func (c *FlypMe) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res
 		}
 	}
 	if rate.Name == "" || rate.Value == "" {
-		err = errors.New(LIBNAME + ":error: rate not found for " + pair + " pair")
+		err = instantswap.NewError(LIBNAME, instantswap.ErrPairNotSupported, "rate not found for "+pair+" pair")
 		return
 	}
-	exchangeRate, err := strconv.ParseFloat(rate.Value, 64)
+	exchangeRate, err := instantswap.ParseAmount(rate.Value)
 	if err != nil {
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
@@ -144,27 +186,27 @@ func (c *FlypMe) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res
 
 	res = instantswap.ExchangeRateInfo{
 		ExchangeRate:    exchangeRate,
-		Min:             limits.Min / exchangeRate,
-		Max:             limits.Max / exchangeRate,
-		EstimatedAmount: vars.Amount * exchangeRate,
+		Min:             limits.Min.Div(exchangeRate, instantswap.RatePrecision),
+		Max:             limits.Max.Div(exchangeRate, instantswap.RatePrecision),
+		EstimatedAmount: vars.Amount.Mul(exchangeRate),
+		RateType:        rateType,
 	}
 
 	return
 }
 
 // EstimateAmount get estimate on the amount for the exchange.
-func (c *FlypMe) EstimateAmount(vars interface{}) (res instantswap.EstimateAmount, err error) {
+func (c *FlypMe) EstimateAmount(ctx context.Context, vars interface{}) (res instantswap.EstimateAmount, err error) {
 	//vars not used here
-	err = errors.New(LIBNAME + ":error: not available for this exchange")
+	err = instantswap.NotSupportedError(LIBNAME, "EstimateAmount")
 	return
 }
 
 // QueryRates (list of pairs LTC-BTC, BTC-LTC, etc).
-func (c *FlypMe) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
+func (c *FlypMe) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
 	//vars not used here
-	r, err := c.client.Do(API_BASE, "GET", "data/exchange_rates", "", false)
+	r, err := c.client.Do(ctx, API_BASE, "GET", "data/exchange_rates", "", false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 
@@ -184,11 +226,10 @@ func (c *FlypMe) QueryRates(vars interface{}) (res []instantswap.QueryRate, err
 }
 
 // QueryActiveCurrencies returns Flypme's supported currencies
-func (c *FlypMe) QueryActiveCurrencies(vars interface{}) (res []instantswap.ActiveCurr, err error) {
+func (c *FlypMe) QueryActiveCurrencies(ctx context.Context, vars interface{}) (res []instantswap.ActiveCurr, err error) {
 	//vars not used here
-	r, err := c.client.Do(API_BASE, "GET", "currencies", "", false)
+	r, err := c.client.Do(ctx, API_BASE, "GET", "currencies", "", false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 
@@ -222,11 +263,10 @@ func (c *FlypMe) QueryActiveCurrencies(vars interface{}) (res []instantswap.Acti
 }
 
 // QueryLimits Get Exchange Rates (from, to).
-func (c *FlypMe) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+func (c *FlypMe) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
 	// Get max and min limits in {to_currency}.
-	r, err := c.client.Do(API_BASE, "GET", "order/limits/"+fromCurr+"/"+toCurr, "", false)
+	r, err := c.client.Do(ctx, API_BASE, "GET", "order/limits/"+fromCurr+"/"+toCurr, "", false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var tmp QueryLimits
@@ -242,13 +282,20 @@ func (c *FlypMe) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimi
 	return
 }
 
-func (c *FlypMe) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *FlypMe) CreateOrder(ctx context.Context, orderInfo instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if orderInfo.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), orderInfo.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
 	newOrder := CreateOrder{
 		Order: CreateOrderInfo{
 			FromCurrency:   orderInfo.FromCurrency,
 			ToCurrency:     orderInfo.ToCurrency,
-			InvoicedAmount: strconv.FormatFloat(orderInfo.InvoicedAmount, 'f', 8, 64), //amount in "from" currency
-			OrderedAmount:  "",                                                        //amount in "to" currency (should be set to 0 for changenow, )
+			InvoicedAmount: orderInfo.InvoicedAmount.String(), //amount in "from" currency
+			OrderedAmount:  "",                                //amount in "to" currency (should be set to 0 for changenow, )
 			Destination:    orderInfo.Destination,
 			RefundAddress:  orderInfo.RefundAddress,
 		},
@@ -258,9 +305,8 @@ func (c *FlypMe) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	r, err := c.client.Do(API_BASE, "POST", "order/new", string(payload), false)
+	r, err := c.client.Do(ctx, API_BASE, "POST", "order/new", string(payload), false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var tmp CreateResult
@@ -271,7 +317,6 @@ func (c *FlypMe) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap
 	if len(tmp.Errors) > 0 {
 		err = handleErr(tmp.Errors)
 		if err != nil {
-			err = errors.New(LIBNAME + ":error: " + err.Error())
 			return
 		}
 	}
@@ -283,9 +328,8 @@ func (c *FlypMe) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	acceptRes, err := c.client.Do(API_BASE, "POST", "order/accept", string(acceptPayload), false)
+	acceptRes, err := c.client.Do(ctx, API_BASE, "POST", "order/accept", string(acceptPayload), false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var tmpAccept AcceptOrderResult
@@ -297,7 +341,6 @@ func (c *FlypMe) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap
 	if len(tmp.Errors) > 0 {
 		err = handleErr(tmp.Errors)
 		if err != nil {
-			err = errors.New(LIBNAME + ":error: " + err.Error())
 			return
 		}
 	}
@@ -313,22 +356,30 @@ func (c *FlypMe) CreateOrder(orderInfo instantswap.CreateOrder) (res instantswap
 		UUID:           tmp.Order.UUID,
 		DepositAddress: tmpAccept.DepositAddress, //from accept order result
 		Expires:        tmpAccept.Expires,        //from accept order result
+		RateType:       rateType,
 	}
 
 	return
 }
 
 // UpdateOrder update the information of an order.
-func (c *FlypMe) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	orderInfo := vars.(UpdateOrder)
+func (c *FlypMe) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	if vars.ExtraID != "" || vars.RefundExtraID != "" {
+		err = instantswap.NotSupportedError(LIBNAME, "extra id update")
+		return
+	}
+	orderInfo := UpdateOrder{Order: UpdateOrderInfo{
+		UUID:          vars.UUID,
+		Destination:   vars.Destination,
+		RefundAddress: vars.RefundAddress,
+	}}
 	payload, err := json.Marshal(orderInfo)
 	if err != nil {
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	r, err := c.client.Do(API_BASE, "POST", "order/update", string(payload), false)
+	r, err := c.client.Do(ctx, API_BASE, "POST", "order/update", string(payload), false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var tmp UpdateOrderResult
@@ -340,7 +391,6 @@ func (c *FlypMe) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResul
 	if len(tmp.Errors) > 0 {
 		err = handleErr(tmp.Errors)
 		if err != nil {
-			err = errors.New(LIBNAME + ":error: " + err.Error())
 			return
 		}
 	}
@@ -354,13 +404,20 @@ func (c *FlypMe) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResul
 		OrderedAmount:  tmp.Order.OrderedAmount,
 		ToCurrency:     tmp.Order.ToCurrency,
 		UUID:           tmp.Order.UUID,
+		RefundAddress:  tmp.Order.RefundAddress,
 	}
 
 	return
 }
 
 // CancelOrder will delete an order based on its id.
-func (c *FlypMe) CancelOrder(orderId string) (res string, err error) {
+func (c *FlypMe) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (c *FlypMe) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (c *FlypMe) CancelOrder(ctx context.Context, orderId string) (res string, err error) {
 	cancelOrder := UUID{
 		UUID: orderId,
 	}
@@ -369,9 +426,8 @@ func (c *FlypMe) CancelOrder(orderId string) (res string, err error) {
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	r, err := c.client.Do(API_BASE, "POST", "order/cancel", string(payload), false)
+	r, err := c.client.Do(ctx, API_BASE, "POST", "order/cancel", string(payload), false)
 	if err != nil {
-		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
 	var result jsonResponse
@@ -382,7 +438,6 @@ func (c *FlypMe) CancelOrder(orderId string) (res string, err error) {
 	if len(result.Errors) > 0 {
 		err = handleErr(result.Errors)
 		if err != nil {
-			err = errors.New(LIBNAME + ":error: " + err.Error())
 			return
 		}
 	}
@@ -393,7 +448,7 @@ func (c *FlypMe) CancelOrder(orderId string) (res string, err error) {
 
 // OrderInfo accepts string of orderID value and return
 // its information
-func (c *FlypMe) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (c *FlypMe) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	getOrderInfo := UUID{
 		UUID: req.OrderId,
 	}
@@ -402,7 +457,7 @@ func (c *FlypMe) OrderInfo(req instantswap.TrackingRequest) (res instantswap.Ord
 		err = errors.New(LIBNAME + ":error: " + err.Error())
 		return
 	}
-	r, err := c.client.Do(API_BASE, "POST", "order/info", string(payload), false)
+	r, err := c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "order/info", string(payload), false)
 	if err != nil {
 		return
 	}
@@ -415,7 +470,6 @@ func (c *FlypMe) OrderInfo(req instantswap.TrackingRequest) (res instantswap.Ord
 	if len(tmp.Errors) > 0 {
 		err = handleErr(tmp.Errors)
 		if err != nil {
-			err = errors.New(LIBNAME + ":error: " + err.Error())
 			return
 		}
 	}
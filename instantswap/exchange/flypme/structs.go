@@ -2,6 +2,7 @@ package flypme
 
 import (
 	"encoding/json"
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 // base json structure
@@ -22,24 +23,24 @@ type ActiveCurrs struct {
 	Currencies []ActiveCurr
 }
 type ActiveCurr struct {
-	ChargedFee       float64 `json:"charged_fee,string"`
-	Code             string  `json:"code"`
-	ConfirmationTime int     `json:"confirmation_time"`
-	CreatedAt        string  `json:"created_at"`
-	CurrencyType     string  `json:"currency_type"`
-	Default          bool    `json:"default"`
-	DisplayPrecision int     `json:"display_precision"`
-	Exchange         bool    `json:"exchange"`
-	Name             string  `json:"name"`
-	Precision        int     `json:"precision"`
-	Send             bool    `json:"send"`
-	UpdatedAt        string  `json:"updated_at"`
-	Website          string  `json:"website"`
+	ChargedFee       instantswap.Amount `json:"charged_fee"`
+	Code             string             `json:"code"`
+	ConfirmationTime int                `json:"confirmation_time"`
+	CreatedAt        string             `json:"created_at"`
+	CurrencyType     string             `json:"currency_type"`
+	Default          bool               `json:"default"`
+	DisplayPrecision int                `json:"display_precision"`
+	Exchange         bool               `json:"exchange"`
+	Name             string             `json:"name"`
+	Precision        int                `json:"precision"`
+	Send             bool               `json:"send"`
+	UpdatedAt        string             `json:"updated_at"`
+	Website          string             `json:"website"`
 }
 
 type QueryLimits struct {
-	Max float64 `json:"max,string"`
-	Min float64 `json:"min,string"`
+	Max instantswap.Amount `json:"max"`
+	Min instantswap.Amount `json:"min"`
 }
 
 // CREATE
@@ -55,14 +56,14 @@ type CreateOrder struct {
 	Order CreateOrderInfo `json:"order"`
 }
 type CreateResultInfo struct {
-	ChargedFee     float64 `json:"charged_fee,string"`
-	Destination    string  `json:"destination"`
-	ExchangeRate   float64 `json:"exchange_rate,string"`
-	FromCurrency   string  `json:"from_currency"`
-	InvoicedAmount float64 `json:"invoiced_amount,string"`
-	OrderedAmount  float64 `json:"ordered_amount,string"`
-	ToCurrency     string  `json:"to_currency"`
-	UUID           string  `json:"uuid"`
+	ChargedFee     instantswap.Amount `json:"charged_fee"`
+	Destination    string             `json:"destination"`
+	ExchangeRate   instantswap.Amount `json:"exchange_rate"`
+	FromCurrency   string             `json:"from_currency"`
+	InvoicedAmount instantswap.Amount `json:"invoiced_amount"`
+	OrderedAmount  instantswap.Amount `json:"ordered_amount"`
+	ToCurrency     string             `json:"to_currency"`
+	UUID           string             `json:"uuid"`
 }
 type CreateResult struct {
 	Errors  json.RawMessage  `json:"errors"`
@@ -72,23 +73,24 @@ type CreateResult struct {
 
 // UPDATE
 type UpdateOrderInfo struct {
-	Destination   string  `json:"destination"`
-	OrderedAmount float64 `json:"ordered_amount,string"`
-	RefundAddress string  `json:"refund_address"`
-	UUID          string  `json:"uuid"`
+	Destination   string      `json:"destination,omitempty"`
+	OrderedAmount json.Number `json:"ordered_amount,string,omitempty"`
+	RefundAddress string      `json:"refund_address,omitempty"`
+	UUID          string      `json:"uuid"`
 }
 type UpdateOrder struct {
 	Order UpdateOrderInfo `json:"order"`
 }
 type UpdateOrderResultInfo struct {
-	ChargedFee     float64 `json:"charged_fee,string"`
-	Destination    string  `json:"destination"`
-	ExchangeRate   float64 `json:"exchange_rate,string"`
-	FromCurrency   string  `json:"from_currency"`
-	InvoicedAmount float64 `json:"invoiced_amount,string"`
-	OrderedAmount  float64 `json:"ordered_amount,string"`
-	ToCurrency     string  `json:"to_currency"`
-	UUID           string  `json:"uuid"`
+	ChargedFee     instantswap.Amount `json:"charged_fee"`
+	Destination    string             `json:"destination"`
+	ExchangeRate   instantswap.Amount `json:"exchange_rate"`
+	FromCurrency   string             `json:"from_currency"`
+	InvoicedAmount instantswap.Amount `json:"invoiced_amount"`
+	OrderedAmount  instantswap.Amount `json:"ordered_amount"`
+	ToCurrency     string             `json:"to_currency"`
+	UUID           string             `json:"uuid"`
+	RefundAddress  string             `json:"refund_address"`
 }
 type UpdateOrderResult struct {
 	Errors  json.RawMessage       `json:"errors"`
@@ -115,14 +117,14 @@ type AcceptOrderResult struct {
 
 // INFO
 type OrderInfoResultInfo struct {
-	ChargedFee     float64 `json:"charged_fee,string"`
-	Destination    string  `json:"destination"`
-	ExchangeRate   float64 `json:"exchange_rate,string"`
-	FromCurrency   string  `json:"from_currency"`
-	InvoicedAmount float64 `json:"invoiced_amount,string"`
-	OrderedAmount  float64 `json:"ordered_amount,string"`
-	ToCurrency     string  `json:"to_currency"`
-	UUID           string  `json:"uuid"`
+	ChargedFee     instantswap.Amount `json:"charged_fee"`
+	Destination    string             `json:"destination"`
+	ExchangeRate   instantswap.Amount `json:"exchange_rate"`
+	FromCurrency   string             `json:"from_currency"`
+	InvoicedAmount instantswap.Amount `json:"invoiced_amount"`
+	OrderedAmount  instantswap.Amount `json:"ordered_amount"`
+	ToCurrency     string             `json:"to_currency"`
+	UUID           string             `json:"uuid"`
 }
 type OrderInfoResult struct {
 	Errors         json.RawMessage     `json:"errors"`
@@ -1,14 +1,13 @@
 package godex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/vibros68/instantswap/instantswap"
-	"github.com/vibros68/instantswap/instantswap/utils"
 )
 
 const (
@@ -30,7 +29,7 @@ type GoDEX struct {
 
 func New(conf instantswap.ExchangeConfig) (*GoDEX, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		if r.Method == http.MethodPost || r.Method == http.MethodPut {
@@ -38,6 +37,8 @@ func New(conf instantswap.ExchangeConfig) (*GoDEX, error) {
 		}
 		return nil
 	})
+	client.SetErrorFunc(parseError)
+	client.SetDefaultRateLimit(instantswap.RateLimit{Rate: 1, Burst: 1})
 	return &GoDEX{client: client, conf: &conf}, nil
 }
 
@@ -45,14 +46,27 @@ func (c *GoDEX) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by godex.
+func (c *GoDEX) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FloatingRate:     true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		ExtraID:          true,
+		Affiliate:        true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCNotRequired,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump
 func (c *GoDEX) SetDebug(enable bool) {
 	c.conf.Debug = enable
 }
 
-func (c *GoDEX) GetCurrencies() (currencies []instantswap.Currency, err error) {
+func (c *GoDEX) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET", "coins", "", false)
+	r, err = c.client.Do(ctx, API_BASE, "GET", "coins", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -61,23 +75,18 @@ func (c *GoDEX) GetCurrencies() (currencies []instantswap.Currency, err error) {
 	if err != nil {
 		return nil, err
 	}
-	currencies = make([]instantswap.Currency, len(fmCurrencies))
-	for i, currency := range fmCurrencies {
+	currencies = make([]instantswap.Currency, 0, len(fmCurrencies))
+	for _, currency := range fmCurrencies {
 		if currency.Disabled == 0 {
-			currencies[i] = instantswap.Currency{
-				Name:     currency.Name,
-				Symbol:   strings.ToLower(currency.Code),
-				IsFiat:   false,
-				IsStable: false,
-			}
+			currencies = append(currencies, instantswap.NewCurrency(currency.Name, currency.Code, ""))
 		}
 	}
 	return
 }
 
-func (c *GoDEX) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
+func (c *GoDEX) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET", "coins", "", false)
+	r, err = c.client.Do(ctx, API_BASE, "GET", "coins", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -92,32 +101,34 @@ func (c *GoDEX) GetCurrenciesToPair(from string) (currencies []instantswap.Curre
 			continue
 		}
 		if currency.Disabled == 0 {
-			currencies = append(currencies, instantswap.Currency{
-				Name:     currency.Name,
-				Symbol:   strings.ToLower(currency.Code),
-				IsFiat:   false,
-				IsStable: false,
-			})
+			currencies = append(currencies, instantswap.NewCurrency(currency.Name, currency.Code, ""))
 		}
 	}
 	return
 }
 
-func (c *GoDEX) queryRate(req InfoRequest) ([]byte, error) {
+func (c *GoDEX) queryRate(ctx context.Context, req InfoRequest) ([]byte, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
-	return c.client.Do(API_BASE, "POST", "info", string(body), false)
+	return c.client.Do(instantswap.WithIdempotent(ctx), API_BASE, "POST", "info", string(body), false)
 }
 
-func (c *GoDEX) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (c *GoDEX) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
 	var req = InfoRequest{
 		From:   strings.ToUpper(vars.From),
 		To:     strings.ToUpper(vars.To),
-		Amount: vars.Amount,
+		Amount: json.Number(vars.Amount.String()),
 	}
-	r, err := c.queryRate(req)
+	r, err := c.queryRate(ctx, req)
 	if err != nil {
 		return res, err
 	}
@@ -126,12 +137,10 @@ func (c *GoDEX) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res i
 	if err != nil {
 		return res, err
 	}
-	var minAmount, _ = info.MinAmount.Float64()
-	var estimatedAmount, _ = info.Amount.Float64()
-	if minAmount > vars.Amount {
-		req.Amount = minAmount
-		time.Sleep(time.Second)
-		r, err := c.queryRate(req)
+	var estimatedAmount = info.Amount
+	if info.MinAmount.Cmp(vars.Amount) > 0 {
+		req.Amount = json.Number(info.MinAmount.String())
+		r, err := c.queryRate(ctx, req)
 		if err != nil {
 			return res, err
 		}
@@ -139,35 +148,43 @@ func (c *GoDEX) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res i
 		if err != nil {
 			return res, err
 		}
-		estimatedAmount = 0
+		estimatedAmount = instantswap.Amount{}
 	}
 	return instantswap.ExchangeRateInfo{
-		Min:             utils.StrToFloat(info.MinAmount.String()),
-		Max:             utils.StrToFloat(info.MaxAmount.String()),
-		ExchangeRate:    utils.StrToFloat(info.Rate.String()),
+		Min:             info.MinAmount,
+		Max:             info.MaxAmount,
+		ExchangeRate:    info.Rate,
 		EstimatedAmount: estimatedAmount,
-		MaxOrder:        0,
+		MaxOrder:        instantswap.Amount{},
 		Signature:       "",
+		RateType:        rateType,
 	}, err
 }
 
-func (c *GoDEX) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
-	return res, fmt.Errorf("not supported")
+func (c *GoDEX) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryRates")
 }
 
-func (c *GoDEX) QueryActiveCurrencies(vars interface{}) (res []instantswap.ActiveCurr, err error) {
-	return
+func (c *GoDEX) QueryActiveCurrencies(ctx context.Context, vars interface{}) (res []instantswap.ActiveCurr, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryActiveCurrencies")
 }
 
-func (c *GoDEX) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return
+func (c *GoDEX) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (c *GoDEX) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *GoDEX) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
 	var txReq = TransactionReq{
 		CoinFrom:          vars.FromCurrency,
 		CoinTo:            vars.ToCurrency,
-		DepositAmount:     vars.InvoicedAmount,
+		DepositAmount:     json.Number(vars.InvoicedAmount.String()),
 		Withdrawal:        vars.Destination,
 		WithdrawalExtraId: "",
 		Return:            vars.RefundAddress,
@@ -181,7 +198,7 @@ func (c *GoDEX) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Creat
 		return res, err
 	}
 	var r []byte
-	r, err = c.client.Do(API_BASE, "POST", "transaction", string(body), false)
+	r, err = c.client.Do(ctx, API_BASE, "POST", "transaction", string(body), false)
 	if err != nil {
 		return res, err
 	}
@@ -191,33 +208,40 @@ func (c *GoDEX) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Creat
 		return res, err
 	}
 	return instantswap.CreateResultInfo{
-		ChargedFee:     utils.StrToFloat(tx.Fee.String()),
+		ChargedFee:     tx.Fee,
 		Destination:    tx.Withdrawal,
-		ExchangeRate:   utils.StrToFloat(tx.Rate.String()),
+		ExchangeRate:   tx.Rate,
 		FromCurrency:   tx.CoinFrom,
-		InvoicedAmount: utils.StrToFloat(tx.DepositAmount.String()),
-		OrderedAmount:  utils.StrToFloat(tx.WithdrawalAmount.String()),
+		InvoicedAmount: tx.DepositAmount,
+		OrderedAmount:  tx.WithdrawalAmount,
 		ToCurrency:     tx.CoinTo,
 		UUID:           tx.TransactionId,
 		DepositAddress: tx.Deposit,
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}, err
 }
 
 // UpdateOrder accepts orderID value and more if needed per lib.
-func (c *GoDEX) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (c *GoDEX) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
-func (c *GoDEX) CancelOrder(orderID string) (res string, err error) {
-	return
+func (c *GoDEX) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (c *GoDEX) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (c *GoDEX) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
 // OrderInfo accepts orderID value and more if needed per lib.
-func (c *GoDEX) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (c *GoDEX) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET", fmt.Sprintf("transaction/%s", req.OrderId), "", false)
+	r, err = c.client.Do(ctx, API_BASE, "GET", fmt.Sprintf("transaction/%s", req.OrderId), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -229,15 +253,15 @@ func (c *GoDEX) OrderInfo(req instantswap.TrackingRequest) (res instantswap.Orde
 	return instantswap.OrderInfoResult{
 		Expires:        0,
 		LastUpdate:     "",
-		ReceiveAmount:  utils.StrToFloat(tx.RealWithdrawalAmount.String()),
+		ReceiveAmount:  tx.RealWithdrawalAmount,
 		TxID:           tx.HashOut,
 		Status:         tx.Status,
 		InternalStatus: GetLocalStatus(tx.Status),
 		Confirmations:  "",
 	}, err
 }
-func (c *GoDEX) EstimateAmount(vars interface{}) (res instantswap.EstimateAmount, err error) {
-	return
+func (c *GoDEX) EstimateAmount(ctx context.Context, vars interface{}) (res instantswap.EstimateAmount, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "EstimateAmount")
 }
 
 // GetLocalStatus translate local status to instantswap.Status.
@@ -2,7 +2,9 @@ package godex
 
 import (
 	"encoding/json"
-	"fmt"
+	"net/http"
+
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 type Error struct {
@@ -11,40 +13,49 @@ type Error struct {
 }
 
 type InfoRequest struct {
-	From   string  `json:"from"`
-	To     string  `json:"to"`
-	Amount float64 `json:"amount"`
+	From   string      `json:"from"`
+	To     string      `json:"to"`
+	Amount json.Number `json:"amount"`
 }
 
 type RevertResponse struct {
-	MinAmount float64 `json:"min_amount"`
-	MaxAmount int     `json:"max_amount"`
-	Amount    float64 `json:"amount"`
-	Fee       int     `json:"fee"`
-	Rate      float64 `json:"rate"`
+	MinAmount instantswap.Amount `json:"min_amount"`
+	MaxAmount int                `json:"max_amount"`
+	Amount    instantswap.Amount `json:"amount"`
+	Fee       int                `json:"fee"`
+	Rate      instantswap.Amount `json:"rate"`
 }
 
 func parseResponseData(data []byte, obj interface{}) error {
 	var godexErr Error
 	err := json.Unmarshal(data, &godexErr)
 	if err != nil {
-		return fmt.Errorf(string(data))
+		return instantswap.NewError(LIBNAME, nil, string(data))
 	}
-	if err == nil && len(godexErr.Error) > 0 {
-		return fmt.Errorf(godexErr.Error)
+	if err = instantswap.ResponseError(LIBNAME, http.StatusOK, data, parseError); err != nil {
+		return err
 	}
 	err = json.Unmarshal(data, obj)
 	return err
 }
 
+// parseError reads the {"success": false, "error"} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var godexErr Error
+	if json.Unmarshal(body, &godexErr) != nil {
+		return nil, ""
+	}
+	return nil, godexErr.Error
+}
+
 type InfoResponse struct {
-	MinAmount    json.Number `json:"min_amount,omitempty"`
-	MaxAmount    json.Number `json:"max_amount,omitempty"`
-	Amount       json.Number `json:"amount,omitempty"`
-	Fee          json.Number `json:"fee,omitempty"`
-	Rate         json.Number `json:"rate,omitempty"`
-	NetworksFrom []Network   `json:"networks_from"`
-	NetworksTo   []Network   `json:"networks_to"`
+	MinAmount    instantswap.Amount `json:"min_amount"`
+	MaxAmount    instantswap.Amount `json:"max_amount"`
+	Amount       instantswap.Amount `json:"amount"`
+	Fee          instantswap.Amount `json:"fee"`
+	Rate         instantswap.Amount `json:"rate"`
+	NetworksFrom []Network          `json:"networks_from"`
+	NetworksTo   []Network          `json:"networks_to"`
 }
 
 type Network struct {
@@ -53,37 +64,37 @@ type Network struct {
 }
 
 type TransactionReq struct {
-	CoinFrom          string  `json:"coin_from"`
-	CoinTo            string  `json:"coin_to"`
-	DepositAmount     float64 `json:"deposit_amount"`
-	Withdrawal        string  `json:"withdrawal"`
-	WithdrawalExtraId string  `json:"withdrawal_extra_id"`
-	Return            string  `json:"return"`
-	ReturnExtraId     string  `json:"return_extra_id"`
-	AffiliateId       string  `json:"affiliate_id"`
-	CoinToNetwork     string  `json:"coin_to_network"`
-	CoinFromNetwork   string  `json:"coin_from_network"`
+	CoinFrom          string      `json:"coin_from"`
+	CoinTo            string      `json:"coin_to"`
+	DepositAmount     json.Number `json:"deposit_amount"`
+	Withdrawal        string      `json:"withdrawal"`
+	WithdrawalExtraId string      `json:"withdrawal_extra_id"`
+	Return            string      `json:"return"`
+	ReturnExtraId     string      `json:"return_extra_id"`
+	AffiliateId       string      `json:"affiliate_id"`
+	CoinToNetwork     string      `json:"coin_to_network"`
+	CoinFromNetwork   string      `json:"coin_from_network"`
 }
 
 type Transaction struct {
-	Status               string      `json:"status"`
-	CoinFrom             string      `json:"coin_from"`
-	CoinTo               string      `json:"coin_to"`
-	DepositAmount        json.Number `json:"deposit_amount"`
-	Withdrawal           string      `json:"withdrawal"`
-	WithdrawalExtraId    string      `json:"withdrawal_extra_id"`
-	Return               string      `json:"return"`
-	ReturnExtraId        string      `json:"return_extra_id"`
-	WithdrawalAmount     json.Number `json:"withdrawal_amount"`
-	Deposit              string      `json:"deposit"`
-	DepositExtraId       string      `json:"deposit_extra_id"`
-	Rate                 json.Number `json:"rate"`
-	Fee                  json.Number `json:"fee"`
-	TransactionId        string      `json:"transaction_id"`
-	HashIn               string      `json:"hash_in"`
-	HashOut              string      `json:"hash_out"`
-	RealDepositAmount    json.Number `json:"real_deposit_amount"`
-	RealWithdrawalAmount json.Number `json:"real_withdrawal_amount"`
+	Status               string             `json:"status"`
+	CoinFrom             string             `json:"coin_from"`
+	CoinTo               string             `json:"coin_to"`
+	DepositAmount        instantswap.Amount `json:"deposit_amount"`
+	Withdrawal           string             `json:"withdrawal"`
+	WithdrawalExtraId    string             `json:"withdrawal_extra_id"`
+	Return               string             `json:"return"`
+	ReturnExtraId        string             `json:"return_extra_id"`
+	WithdrawalAmount     instantswap.Amount `json:"withdrawal_amount"`
+	Deposit              string             `json:"deposit"`
+	DepositExtraId       string             `json:"deposit_extra_id"`
+	Rate                 instantswap.Amount `json:"rate"`
+	Fee                  instantswap.Amount `json:"fee"`
+	TransactionId        string             `json:"transaction_id"`
+	HashIn               string             `json:"hash_in"`
+	HashOut              string             `json:"hash_out"`
+	RealDepositAmount    instantswap.Amount `json:"real_deposit_amount"`
+	RealWithdrawalAmount instantswap.Amount `json:"real_withdrawal_amount"`
 }
 
 type Currency struct {
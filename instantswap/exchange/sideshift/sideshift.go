@@ -1,6 +1,7 @@
 package sideshift
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -15,6 +16,9 @@ const (
 	LIBNAME  = "sideshift"
 )
 
+// codes maps the sideshift codes, its network ids are the canonical ones.
+var codes = instantswap.CodeMap{}
+
 func init() {
 	instantswap.RegisterExchange(LIBNAME, func(config instantswap.ExchangeConfig) (instantswap.IDExchange, error) {
 		return New(config)
@@ -29,14 +33,15 @@ type SideShift struct {
 
 func New(conf instantswap.ExchangeConfig) (*SideShift, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: api key is blank, it is account id on sideshift", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "api key is blank, it is account id on sideshift")
 	}
 	if conf.ApiSecret == "" {
-		return nil, fmt.Errorf("%s:error: api secret is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "api secret is blank")
 	}
-	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
+	var client *instantswap.Client
+	client = instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		if r.Method == http.MethodPost {
-			ipAddress, err := utils.GetPublicIP()
+			ipAddress, err := utils.GetPublicIP(r.Context(), client.HTTPClient())
 			if err != nil {
 				return err
 			}
@@ -45,6 +50,7 @@ func New(conf instantswap.ExchangeConfig) (*SideShift, error) {
 		}
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &SideShift{client: client, conf: &conf}, nil
 }
 
@@ -52,10 +58,26 @@ func (s *SideShift) Name() string {
 	return LIBNAME
 }
 
-func (s *SideShift) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := s.client.Do(API_BASE, "GET", "coins", "", false)
+// Capabilities returns the features supported by sideshift.
+func (s *SideShift) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		ReverseQuote:     true,
+		Networks:         true,
+		CurrenciesToPair: true,
+		Refund:           true,
+		RefundAddress:    true,
+		Update:           true,
+		Affiliate:        true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCUnknown,
+	}
+}
+
+func (s *SideShift) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := s.client.Do(ctx, API_BASE, "GET", "coins", "", false)
 	if err != nil {
-		err = fmt.Errorf("%s:error:%v", LIBNAME, err)
 		return
 	}
 	var csCurrencies []Currency
@@ -66,20 +88,15 @@ func (s *SideShift) GetCurrencies() (currencies []instantswap.Currency, err erro
 	currencies = []instantswap.Currency{}
 	for _, currency := range csCurrencies {
 		for _, network := range currency.Networks {
-			currencies = append(currencies, instantswap.Currency{
-				Name:    currency.Name,
-				Symbol:  currency.Coin,
-				Network: network,
-			})
+			currencies = append(currencies, codes.NewCurrency(currency.Name, currency.Coin, network))
 		}
 	}
 	return
 }
 
-func (s *SideShift) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	r, err := s.client.Do(API_BASE, "GET", "coins", "", false)
+func (s *SideShift) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	r, err := s.client.Do(ctx, API_BASE, "GET", "coins", "", false)
 	if err != nil {
-		err = fmt.Errorf("%s:error:%v", LIBNAME, err)
 		return
 	}
 	var csCurrencies []Currency
@@ -91,37 +108,66 @@ func (s *SideShift) GetCurrenciesToPair(from string) (currencies []instantswap.C
 	for _, currency := range csCurrencies {
 		if currency.Coin != from {
 			for _, network := range currency.Networks {
-				currencies = append(currencies, instantswap.Currency{
-					Name:    currency.Name,
-					Symbol:  currency.Coin,
-					Network: network,
-				})
+				currencies = append(currencies, codes.NewCurrency(currency.Name, currency.Coin, network))
 			}
 		}
 	}
 	return
 }
 
-func (s *SideShift) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
-	return
+func (s *SideShift) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryRates")
 }
 
-func (s *SideShift) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return
+func (s *SideShift) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (s *SideShift) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
-	req := createFixedShift{
+func (s *SideShift) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	rateType, err := s.rateType(vars.Reverse(), vars.RateType)
+	if err != nil {
+		return res, err
+	}
+	// the fixed shifts are created from a quote.
+	if rateType == instantswap.RateTypeFixed && vars.Signature == "" {
+		quote, err := s.GetExchangeRateInfo(ctx, instantswap.ExchangeRateRequest{
+			From:          vars.FromCurrency,
+			FromNetwork:   vars.FromNetwork,
+			To:            vars.ToCurrency,
+			ToNetwork:     vars.ToNetwork,
+			Amount:        vars.InvoicedAmount,
+			ReceiveAmount: vars.OrderedAmount,
+			RateType:      rateType,
+		})
+		if err != nil {
+			return res, err
+		}
+		vars.Signature = quote.Signature
+	}
+	var req interface{} = createFixedShift{
 		SettleAddress: vars.Destination,
 		AffiliateId:   s.conf.ApiKey,
 		QuoteId:       vars.Signature,
 		RefundAddress: vars.RefundAddress,
 	}
+	if rateType == instantswap.RateTypeFloat {
+		from, fromNetwork := codes.ProviderCurrency(vars.FromCurrency, vars.FromNetwork)
+		to, toNetwork := codes.ProviderCurrency(vars.ToCurrency, vars.ToNetwork)
+		req = createVariableShift{
+			SettleAddress:  vars.Destination,
+			AffiliateId:    s.conf.ApiKey,
+			DepositCoin:    strings.ToLower(from),
+			DepositNetwork: fromNetwork,
+			SettleCoin:     strings.ToLower(to),
+			SettleNetwork:  toNetwork,
+			RefundAddress:  vars.RefundAddress,
+		}
+	}
 	body, err := json.Marshal(req)
 	if err != nil {
 		return res, err
 	}
-	r, err := s.client.Do(API_BASE, http.MethodPost, "shifts/fixed", string(body), false)
+	r, err := s.client.Do(ctx, API_BASE, http.MethodPost, "shifts/"+shiftType(rateType), string(body), false)
 	if err != nil {
 		return res, err
 	}
@@ -131,69 +177,204 @@ func (s *SideShift) CreateOrder(vars instantswap.CreateOrder) (res instantswap.C
 		return res, err
 	}
 	return instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    shift.SettleAddress,
-		ExchangeRate:   utils.StrToFloat(shift.Rate),
+		ExchangeRate:   shift.Rate,
 		FromCurrency:   shift.DepositCoin,
-		InvoicedAmount: utils.StrToFloat(shift.DepositAmount),
-		OrderedAmount:  utils.StrToFloat(shift.SettleAmount),
+		InvoicedAmount: shift.DepositAmount,
+		OrderedAmount:  shift.SettleAmount,
 		ToCurrency:     shift.SettleCoin,
 		UUID:           shift.Id,
 		DepositAddress: shift.DepositAddress,
 		Expires:        int(shift.ExpiresAt.Unix()),
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}, nil
 }
 
-func (s *SideShift) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return res, fmt.Errorf("not supported")
+// rateType returns the rate mode of a request, the reverse ones are only
+// supported by the fixed shifts.
+func (s *SideShift) rateType(reverse bool, requested instantswap.RateType) (instantswap.RateType, error) {
+	if reverse {
+		return instantswap.ChooseReverseRateType(LIBNAME, s.Capabilities(), requested)
+	}
+	return instantswap.ChooseRateType(LIBNAME, s.Capabilities(), requested, instantswap.RateTypeFixed)
 }
 
-func (s *SideShift) CancelOrder(orderID string) (res string, err error) {
-	return res, fmt.Errorf("not supported")
+// shiftType returns the type of the shifts of rateType.
+func shiftType(rateType instantswap.RateType) string {
+	if rateType == instantswap.RateTypeFloat {
+		return "variable"
+	}
+	return "fixed"
 }
 
-func (s *SideShift) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	r, err := s.client.Do(API_BASE, http.MethodGet,
-		fmt.Sprintf("shifts/%s", req.OrderId), "", false)
+// UpdateOrder sets the refund address of a shift, it is the only address
+// sideshift allows to change.
+func (s *SideShift) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	if vars.Destination != "" || vars.ExtraID != "" {
+		return res, instantswap.NotSupportedError(LIBNAME, "destination update")
+	}
+	if vars.RefundAddress == "" {
+		return res, instantswap.NewError(LIBNAME, instantswap.ErrInvalidAddress, "refund address is required")
+	}
+	shift, err := s.setRefundAddress(ctx, vars.UUID, vars.RefundAddress, vars.RefundExtraID)
 	if err != nil {
 		return res, err
 	}
-	var shift FixedShift
+	return instantswap.UpdateOrderResultInfo{
+		Destination:    shift.SettleAddress,
+		ExchangeRate:   shift.Rate,
+		FromCurrency:   shift.DepositCoin,
+		InvoicedAmount: shift.DepositAmount,
+		OrderedAmount:  shift.SettleAmount,
+		ToCurrency:     shift.SettleCoin,
+		UUID:           shift.Id,
+		RefundAddress:  shift.RefundAddress,
+	}, nil
+}
+
+// setRefundAddress sets the refund address and memo of the shift id.
+func (s *SideShift) setRefundAddress(ctx context.Context, id, address, memo string) (shift FixedShift, err error) {
+	body, err := json.Marshal(setRefundAddress{
+		Address: address,
+		Memo:    memo,
+	})
+	if err != nil {
+		return shift, err
+	}
+	r, err := s.client.Do(ctx, API_BASE, http.MethodPost,
+		fmt.Sprintf("shifts/%s/set-refund-address", id), string(body), false)
+	if err != nil {
+		return shift, err
+	}
 	err = parseResponseData(r, &shift)
+	return shift, err
+}
+
+// RefundInfo returns the refund of a shift, the shifts in the refund status
+// wait for a refund address.
+func (s *SideShift) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	shift, err := s.shift(ctx, orderID)
+	if err != nil {
+		return res, err
+	}
+	return refundInfo(shift), nil
+}
+
+// SubmitRefund sets the refund address of a shift in the refund status.
+func (s *SideShift) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	res, err = s.RefundInfo(ctx, vars.UUID)
+	if err != nil {
+		return res, err
+	}
+	if !res.Eligible() {
+		return res, instantswap.RefundNotEligibleError(LIBNAME, res)
+	}
+	shift, err := s.setRefundAddress(ctx, vars.UUID, vars.Address, vars.ExtraID)
+	if err != nil {
+		return res, err
+	}
+	return refundInfo(shift), nil
+}
+
+func refundInfo(shift FixedShift) instantswap.RefundInfo {
+	info := instantswap.RefundInfo{
+		UUID:    shift.Id,
+		Address: shift.RefundAddress,
+		ExtraID: shift.RefundMemo,
+		Amount:  shift.DepositAmount,
+		TxID:    shift.RefundHash,
+	}
+	switch strings.ToLower(shift.Status) {
+	case "refund":
+		info.Status = instantswap.RefundStatusEligible
+		if shift.RefundAddress != "" {
+			info.Status = instantswap.RefundStatusPending
+		}
+	case "refunding":
+		info.Status = instantswap.RefundStatusPending
+	case "refunded":
+		info.Status = instantswap.RefundStatusCompleted
+	default:
+		info.Status = instantswap.RefundStatusNotEligible
+	}
+	return info
+}
+
+func (s *SideShift) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
+}
+
+func (s *SideShift) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	shift, err := s.shift(ctx, req.OrderId)
 	if err != nil {
 		return res, err
 	}
 	return instantswap.OrderInfoResult{
 		Expires:        int(shift.ExpiresAt.Unix()),
 		LastUpdate:     "",
-		ReceiveAmount:  0,
+		ReceiveAmount:  instantswap.Amount{},
 		TxID:           shift.SettleHash,
+		RefundTx:       shift.RefundHash,
 		Status:         "",
 		InternalStatus: GetLocalStatus(shift.Status),
 		Confirmations:  "",
 	}, nil
 }
 
-func (s *SideShift) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (s *SideShift) shift(ctx context.Context, id string) (shift FixedShift, err error) {
+	r, err := s.client.Do(ctx, API_BASE, http.MethodGet,
+		fmt.Sprintf("shifts/%s", id), "", false)
+	if err != nil {
+		return shift, err
+	}
+	err = parseResponseData(r, &shift)
+	return shift, err
+}
+
+func (s *SideShift) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	rateType, err := s.rateType(vars.Reverse(), vars.RateType)
+	if err != nil {
+		return res, err
+	}
+	if rateType == instantswap.RateTypeFloat {
+		// the variable shifts have no quote, they are at the rate of the pair.
+		pair, err := s.pair(ctx, vars)
+		if err != nil {
+			return res, err
+		}
+		return instantswap.ExchangeRateInfo{
+			Min:             pair.Min,
+			Max:             pair.Max,
+			ExchangeRate:    pair.Rate,
+			EstimatedAmount: vars.Amount.Mul(pair.Rate),
+			RateType:        instantswap.RateTypeFloat,
+		}, nil
+	}
+	from, fromNetwork := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, toNetwork := codes.ProviderCurrency(vars.To, vars.ToNetwork)
 	var req = ExchangeRateRequest{
-		DepositCoin:    strings.ToLower(vars.From),
-		DepositNetwork: vars.FromNetwork,
-		SettleCoin:     strings.ToLower(vars.To),
-		SettleNetwork:  vars.ToNetwork,
-		DepositAmount:  fmt.Sprintf("%f", vars.Amount),
+		DepositCoin:    strings.ToLower(from),
+		DepositNetwork: fromNetwork,
+		SettleCoin:     strings.ToLower(to),
+		SettleNetwork:  toNetwork,
+		DepositAmount:  vars.Amount.String(),
 		SettleAmount:   "",
 		AffiliateId:    s.conf.ApiKey,
 		CommissionRate: "0",
 	}
+	if vars.Reverse() {
+		req.DepositAmount = ""
+		req.SettleAmount = vars.ReceiveAmount.String()
+	}
 	body, err := json.Marshal(req)
 	if err != nil {
 		return res, err
 	}
-	r, err := s.client.Do(API_BASE, http.MethodPost, "quotes", string(body), false)
+	r, err := s.client.Do(instantswap.WithIdempotent(ctx), API_BASE, http.MethodPost, "quotes", string(body), false)
 	if err != nil {
-		err = fmt.Errorf("%s:error:%v", LIBNAME, err)
 		return
 	}
 	var quote Quote
@@ -201,22 +382,30 @@ func (s *SideShift) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (r
 	if err != nil {
 		return res, err
 	}
-	pair, _ := s.pair(vars)
-	return instantswap.ExchangeRateInfo{
-		Min:             utils.StrToFloat(pair.Min),
-		Max:             utils.StrToFloat(pair.Max),
-		ExchangeRate:    utils.StrToFloat(quote.Rate),
-		EstimatedAmount: utils.StrToFloat(quote.SettleAmount),
-		MaxOrder:        0,
+	pair, _ := s.pair(ctx, vars)
+	res = instantswap.ExchangeRateInfo{
+		Min:             pair.Min,
+		Max:             pair.Max,
+		ExchangeRate:    quote.Rate,
+		EstimatedAmount: quote.SettleAmount,
+		MaxOrder:        instantswap.Amount{},
 		Signature:       quote.Id,
-	}, nil
+		RateType:        instantswap.RateTypeFixed,
+		ValidUntil:      quote.ExpiresAt,
+	}
+	if vars.Reverse() {
+		res.DepositAmount = quote.DepositAmount
+	}
+	return res, nil
 }
 
-func (s *SideShift) pair(vars instantswap.ExchangeRateRequest) (pair PairResponse, err error) {
-	r, err := s.client.Do(API_BASE, http.MethodGet,
+func (s *SideShift) pair(ctx context.Context, vars instantswap.ExchangeRateRequest) (pair PairResponse, err error) {
+	from, fromNetwork := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, toNetwork := codes.ProviderCurrency(vars.To, vars.ToNetwork)
+	r, err := s.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("pair/%s-%s/%s-%s",
-			strings.ToLower(vars.From), vars.FromNetwork,
-			strings.ToLower(vars.To), vars.ToNetwork), "", false)
+			strings.ToLower(from), fromNetwork,
+			strings.ToLower(to), toNetwork), "", false)
 	if err != nil {
 		return pair, err
 	}
@@ -3,12 +3,30 @@ package sideshift
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/vibros68/instantswap/instantswap"
 )
 
 func parseResponseData(r []byte, obj interface{}) error {
 	return json.Unmarshal(r, obj)
 }
 
+// Error is the payload of a failed request.
+type Error struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// parseError reads the {"error": {"message"}} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var e Error
+	if json.Unmarshal(body, &e) != nil {
+		return nil, ""
+	}
+	return nil, e.Error.Message
+}
+
 type Currency struct {
 	Coin           string      `json:"coin"`
 	Networks       []string    `json:"networks"`
@@ -32,27 +50,27 @@ type ExchangeRateRequest struct {
 }
 
 type Quote struct {
-	Id             string    `json:"id"`
-	CreatedAt      time.Time `json:"createdAt"`
-	DepositCoin    string    `json:"depositCoin"`
-	SettleCoin     string    `json:"settleCoin"`
-	DepositNetwork string    `json:"depositNetwork"`
-	SettleNetwork  string    `json:"settleNetwork"`
-	ExpiresAt      time.Time `json:"expiresAt"`
-	DepositAmount  string    `json:"depositAmount"`
-	SettleAmount   string    `json:"settleAmount"`
-	Rate           string    `json:"rate"`
-	AffiliateId    string    `json:"affiliateId"`
+	Id             string             `json:"id"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	DepositCoin    string             `json:"depositCoin"`
+	SettleCoin     string             `json:"settleCoin"`
+	DepositNetwork string             `json:"depositNetwork"`
+	SettleNetwork  string             `json:"settleNetwork"`
+	ExpiresAt      time.Time          `json:"expiresAt"`
+	DepositAmount  instantswap.Amount `json:"depositAmount"`
+	SettleAmount   instantswap.Amount `json:"settleAmount"`
+	Rate           instantswap.Amount `json:"rate"`
+	AffiliateId    string             `json:"affiliateId"`
 }
 
 type PairResponse struct {
-	Min            string `json:"min"`
-	Max            string `json:"max"`
-	Rate           string `json:"rate"`
-	DepositCoin    string `json:"depositCoin"`
-	SettleCoin     string `json:"settleCoin"`
-	DepositNetwork string `json:"depositNetwork"`
-	SettleNetwork  string `json:"settleNetwork"`
+	Min            instantswap.Amount `json:"min"`
+	Max            instantswap.Amount `json:"max"`
+	Rate           instantswap.Amount `json:"rate"`
+	DepositCoin    string             `json:"depositCoin"`
+	SettleCoin     string             `json:"settleCoin"`
+	DepositNetwork string             `json:"depositNetwork"`
+	SettleNetwork  string             `json:"settleNetwork"`
 }
 
 type createFixedShift struct {
@@ -62,28 +80,48 @@ type createFixedShift struct {
 	RefundAddress string `json:"refundAddress"`
 }
 
+type createVariableShift struct {
+	SettleAddress  string `json:"settleAddress"`
+	AffiliateId    string `json:"affiliateId"`
+	DepositCoin    string `json:"depositCoin"`
+	DepositNetwork string `json:"depositNetwork"`
+	SettleCoin     string `json:"settleCoin"`
+	SettleNetwork  string `json:"settleNetwork"`
+	RefundAddress  string `json:"refundAddress"`
+}
+
+// FixedShift is a fixed or variable shift, the variable ones have no quote
+// and amounts until the deposit is received.
+// setRefundAddress is the body of the set-refund-address request.
+type setRefundAddress struct {
+	Address string `json:"address"`
+	Memo    string `json:"memo,omitempty"`
+}
+
 type FixedShift struct {
-	Id             string    `json:"id"`
-	CreatedAt      time.Time `json:"createdAt"`
-	DepositCoin    string    `json:"depositCoin"`
-	SettleCoin     string    `json:"settleCoin"`
-	DepositNetwork string    `json:"depositNetwork"`
-	SettleNetwork  string    `json:"settleNetwork"`
-	DepositAddress string    `json:"depositAddress"`
-	SettleAddress  string    `json:"settleAddress"`
-	DepositMin     string    `json:"depositMin"`
-	DepositMax     string    `json:"depositMax"`
-	RefundAddress  string    `json:"refundAddress"`
-	Type           string    `json:"type"`
-	QuoteId        string    `json:"quoteId"`
-	DepositAmount  string    `json:"depositAmount"`
-	SettleAmount   string    `json:"settleAmount"`
-	ExpiresAt      time.Time `json:"expiresAt"`
-	Status         string    `json:"status"`
-	UpdatedAt      time.Time `json:"updatedAt"`
-	Rate           string    `json:"rate"`
+	Id             string             `json:"id"`
+	CreatedAt      time.Time          `json:"createdAt"`
+	DepositCoin    string             `json:"depositCoin"`
+	SettleCoin     string             `json:"settleCoin"`
+	DepositNetwork string             `json:"depositNetwork"`
+	SettleNetwork  string             `json:"settleNetwork"`
+	DepositAddress string             `json:"depositAddress"`
+	SettleAddress  string             `json:"settleAddress"`
+	DepositMin     string             `json:"depositMin"`
+	DepositMax     string             `json:"depositMax"`
+	RefundAddress  string             `json:"refundAddress"`
+	RefundMemo     string             `json:"refundMemo"`
+	Type           string             `json:"type"`
+	QuoteId        string             `json:"quoteId"`
+	DepositAmount  instantswap.Amount `json:"depositAmount"`
+	SettleAmount   instantswap.Amount `json:"settleAmount"`
+	ExpiresAt      time.Time          `json:"expiresAt"`
+	Status         string             `json:"status"`
+	UpdatedAt      time.Time          `json:"updatedAt"`
+	Rate           instantswap.Amount `json:"rate"`
 	// information of get request
 	DepositHash       string    `json:"depositHash"`
 	SettleHash        string    `json:"settleHash"`
+	RefundHash        string    `json:"refundHash"`
 	DepositReceivedAt time.Time `json:"depositReceivedAt"`
 }
@@ -1,13 +1,13 @@
 package simpleswap
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/vibros68/instantswap/instantswap"
-	"github.com/vibros68/instantswap/instantswap/utils"
 )
 
 const (
@@ -15,6 +15,16 @@ const (
 	LIBNAME  = "simpleswap"
 )
 
+// codes maps the simpleswap tickers of the tokens issued on several networks.
+var codes = instantswap.CodeMap{Currencies: map[string]instantswap.CurrencyKey{
+	"usdterc20": {Symbol: "USDT", Network: instantswap.NetworkEthereum},
+	"usdttrc20": {Symbol: "USDT", Network: instantswap.NetworkTron},
+	"usdtbep20": {Symbol: "USDT", Network: instantswap.NetworkBSC},
+	"usdtsol":   {Symbol: "USDT", Network: instantswap.NetworkSolana},
+	"usdcbep20": {Symbol: "USDC", Network: instantswap.NetworkBSC},
+	"usdcsol":   {Symbol: "USDC", Network: instantswap.NetworkSolana},
+}}
+
 func init() {
 	instantswap.RegisterExchange(LIBNAME, func(config instantswap.ExchangeConfig) (instantswap.IDExchange, error) {
 		return New(config)
@@ -29,9 +39,10 @@ type SimpleSwap struct {
 
 func New(conf instantswap.ExchangeConfig) (*SimpleSwap, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf)
+	client.SetErrorFunc(parseError)
 	return &SimpleSwap{client: client, conf: &conf}, nil
 }
 
@@ -39,13 +50,26 @@ func (c *SimpleSwap) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by simpleswap.
+func (c *SimpleSwap) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		Limits:           true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump
 func (c *SimpleSwap) SetDebug(enable bool) {
 	c.conf.Debug = enable
 }
 
-func (c *SimpleSwap) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, http.MethodGet,
+func (c *SimpleSwap) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := c.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("get_all_currencies?api_key=%s", c.conf.ApiKey),
 		"", false)
 	if err != nil {
@@ -58,16 +82,14 @@ func (c *SimpleSwap) GetCurrencies() (currencies []instantswap.Currency, err err
 	}
 	currencies = make([]instantswap.Currency, len(ssCurrencies))
 	for i, curr := range ssCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:   curr.Name,
-			Symbol: curr.Symbol,
-		}
+		currencies[i] = codes.NewCurrency(curr.Name, curr.Symbol, curr.Network)
 	}
 	return currencies, nil
 }
 
-func (c *SimpleSwap) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	r, err := c.client.Do(API_BASE, http.MethodGet,
+func (c *SimpleSwap) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	from, _ = codes.ProviderCurrency(from, "")
+	r, err := c.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("get_pairs?api_key=%s&fixed=true&symbol=%s", c.conf.ApiKey, strings.ToLower(from)),
 		"", false)
 	if err != nil {
@@ -80,49 +102,64 @@ func (c *SimpleSwap) GetCurrenciesToPair(from string) (currencies []instantswap.
 	}
 	currencies = make([]instantswap.Currency, len(ssCurrencies))
 	for i, curr := range ssCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:   curr,
-			Symbol: curr,
-		}
+		currencies[i] = codes.NewCurrency("", curr, "")
 	}
 	return
 }
 
-func (c *SimpleSwap) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (c *SimpleSwap) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET",
-		fmt.Sprintf("get_estimated?api_key=%s&currency_from=%s&currency_to=%s&fixed=true&amount=%.8f",
-			c.conf.ApiKey, strings.ToLower(vars.From), strings.ToLower(vars.To), vars.Amount),
+	from, _ := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.To, vars.ToNetwork)
+	r, err = c.client.Do(ctx, API_BASE, "GET",
+		fmt.Sprintf("get_estimated?api_key=%s&currency_from=%s&currency_to=%s&fixed=%t&amount=%s",
+			c.conf.ApiKey, strings.ToLower(from), strings.ToLower(to), rateType == instantswap.RateTypeFixed, vars.Amount),
 		"", false)
 	if err != nil {
 		return
 	}
 	var response = string(r)
 	if response == "null" {
-		return res, fmt.Errorf("invalid request")
+		return res, instantswap.NewError(LIBNAME, instantswap.ErrPairNotSupported, "invalid request")
 	}
-	var estimatedAmountStr string
-	err = json.Unmarshal(r, &estimatedAmountStr)
+	var estimatedAmount instantswap.Amount
+	err = json.Unmarshal(r, &estimatedAmount)
 	if err != nil {
 		return res, err
 	}
-	estimatedAmount := utils.StrToFloat(estimatedAmountStr)
 	return instantswap.ExchangeRateInfo{
-		Min:             0,
-		Max:             0,
-		ExchangeRate:    estimatedAmount / vars.Amount,
+		Min:             instantswap.Amount{},
+		Max:             instantswap.Amount{},
+		ExchangeRate:    estimatedAmount.Div(vars.Amount, instantswap.RatePrecision),
 		EstimatedAmount: estimatedAmount,
-		MaxOrder:        0,
+		MaxOrder:        instantswap.Amount{},
 		Signature:       "",
+		RateType:        rateType,
 	}, err
 }
 
-func (c *SimpleSwap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *SimpleSwap) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
+	from, _ := codes.ProviderCurrency(vars.FromCurrency, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.ToCurrency, vars.ToNetwork)
 	var form = CreateExchange{
-		CurrencyFrom:      strings.ToLower(vars.FromCurrency),
-		CurrencyTo:        strings.ToLower(vars.ToCurrency),
-		Fixed:             false,
-		Amount:            vars.InvoicedAmount,
+		CurrencyFrom:      strings.ToLower(from),
+		CurrencyTo:        strings.ToLower(to),
+		Fixed:             rateType == instantswap.RateTypeFixed,
+		Amount:            json.Number(vars.InvoicedAmount.String()),
 		AddressTo:         vars.Destination,
 		ExtraIdTo:         "",
 		UserRefundAddress: vars.RefundAddress,
@@ -135,7 +172,7 @@ func (c *SimpleSwap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 	}
 	// do request
 	var r []byte
-	r, err = c.client.Do(API_BASE, "POST", fmt.Sprintf("create_exchange?api_key=%s", c.conf.ApiKey),
+	r, err = c.client.Do(ctx, API_BASE, "POST", fmt.Sprintf("create_exchange?api_key=%s", c.conf.ApiKey),
 		string(payload), false)
 	if err != nil {
 		return
@@ -145,12 +182,12 @@ func (c *SimpleSwap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 	if err != nil {
 		return
 	}
-	var invoicedAmount = utils.StrToFloat(order.AmountFrom)
-	var orderedAmount = utils.StrToFloat(order.AmountTo)
+	var invoicedAmount = order.AmountFrom
+	var orderedAmount = order.AmountTo
 	res = instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.AddressTo,
-		ExchangeRate:   invoicedAmount / orderedAmount,
+		ExchangeRate:   invoicedAmount.Div(orderedAmount, instantswap.RatePrecision),
 		FromCurrency:   order.CurrencyFrom,
 		InvoicedAmount: invoicedAmount,
 		OrderedAmount:  orderedAmount,
@@ -160,23 +197,32 @@ func (c *SimpleSwap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}
 	return
 }
 
 // UpdateOrder accepts orderID value and more if needed per lib
-func (c *SimpleSwap) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (c *SimpleSwap) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
 
-func (c *SimpleSwap) CancelOrder(orderID string) (res string, err error) {
-	return
+func (c *SimpleSwap) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+
+func (c *SimpleSwap) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+
+func (c *SimpleSwap) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
 // OrderInfo accepts orderID value and more if needed per lib.
-func (c *SimpleSwap) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (c *SimpleSwap) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET",
+	r, err = c.client.Do(ctx, API_BASE, "GET",
 		fmt.Sprintf("get_exchange?id=%s&api_key=%s", req.OrderId, c.conf.ApiKey),
 		"", false)
 	if err != nil {
@@ -190,7 +236,7 @@ func (c *SimpleSwap) OrderInfo(req instantswap.TrackingRequest) (res instantswap
 	return instantswap.OrderInfoResult{
 		Expires:        0,
 		LastUpdate:     order.UpdatedAt,
-		ReceiveAmount:  utils.StrToFloat(order.AmountTo),
+		ReceiveAmount:  order.AmountTo,
 		TxID:           order.TxTo,
 		Status:         order.Status,
 		InternalStatus: GetLocalStatus(order.Status),
@@ -199,10 +245,9 @@ func (c *SimpleSwap) OrderInfo(req instantswap.TrackingRequest) (res instantswap
 }
 
 func parseResponseData(data []byte, obj interface{}) error {
-	var simpleSwapErr Error
-	err := json.Unmarshal(data, &simpleSwapErr)
-	if err == nil && simpleSwapErr.Code > 0 && len(simpleSwapErr.Message) > 0 {
-		return fmt.Errorf(simpleSwapErr.Message)
+	err := instantswap.ResponseError(LIBNAME, http.StatusOK, data, parseError)
+	if err != nil {
+		return err
 	}
 	err = json.Unmarshal(data, obj)
 	if err != nil {
@@ -211,6 +256,19 @@ func parseResponseData(data []byte, obj interface{}) error {
 	return nil
 }
 
+// parseError reads the {"code", "message"} or {"code", "error", "description"}
+// payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var simpleSwapErr Error
+	if json.Unmarshal(body, &simpleSwapErr) != nil || simpleSwapErr.Code == 0 {
+		return nil, ""
+	}
+	if simpleSwapErr.Description != "" {
+		return nil, simpleSwapErr.Description
+	}
+	return nil, simpleSwapErr.Message
+}
+
 // GetLocalStatus translate local status to instantswap.Status.
 func GetLocalStatus(status string) instantswap.Status {
 	status = strings.ToLower(status)
@@ -1,41 +1,48 @@
 package simpleswap
 
+import (
+	"encoding/json"
+
+	"github.com/vibros68/instantswap/instantswap"
+)
+
 type CreateExchange struct {
-	CurrencyFrom      string  `json:"currency_from"`
-	CurrencyTo        string  `json:"currency_to"`
-	Fixed             bool    `json:"fixed"`
-	Amount            float64 `json:"amount"`
-	AddressTo         string  `json:"address_to"`
-	ExtraIdTo         string  `json:"extraIdTo"`
-	UserRefundAddress string  `json:"userRefundAddress"`
-	UserRefundExtraId string  `json:"userRefundExtraId"`
-	Referral          string  `json:"referral"`
+	CurrencyFrom      string      `json:"currency_from"`
+	CurrencyTo        string      `json:"currency_to"`
+	Fixed             bool        `json:"fixed"`
+	Amount            json.Number `json:"amount"`
+	AddressTo         string      `json:"address_to"`
+	ExtraIdTo         string      `json:"extraIdTo"`
+	UserRefundAddress string      `json:"userRefundAddress"`
+	UserRefundExtraId string      `json:"userRefundExtraId"`
+	Referral          string      `json:"referral"`
 }
 
 type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code        int    `json:"code"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
 }
 
 type Order struct {
-	Id                string `json:"id"`
-	Type              string `json:"type"`
-	Timestamp         string `json:"timestamp"`
-	UpdatedAt         string `json:"updated_at"`
-	CurrencyFrom      string `json:"currency_from"`
-	CurrencyTo        string `json:"currency_to"`
-	AmountFrom        string `json:"amount_from"`
-	ExpectedAmount    string `json:"expected_amount"`
-	AmountTo          string `json:"amount_to"`
-	AddressFrom       string `json:"address_from"`
-	AddressTo         string `json:"address_to"`
-	ExtraIdFrom       string `json:"extra_id_from"`
-	ExtraIdTo         string `json:"extra_id_to"`
-	UserRefundAddress string `json:"user_refund_address"`
-	UserRefundExtraId string `json:"user_refund_extra_id"`
-	TxFrom            string `json:"tx_from"`
-	TxTo              string `json:"tx_to"`
-	Status            string `json:"status"`
+	Id                string             `json:"id"`
+	Type              string             `json:"type"`
+	Timestamp         string             `json:"timestamp"`
+	UpdatedAt         string             `json:"updated_at"`
+	CurrencyFrom      string             `json:"currency_from"`
+	CurrencyTo        string             `json:"currency_to"`
+	AmountFrom        instantswap.Amount `json:"amount_from"`
+	ExpectedAmount    instantswap.Amount `json:"expected_amount"`
+	AmountTo          instantswap.Amount `json:"amount_to"`
+	AddressFrom       string             `json:"address_from"`
+	AddressTo         string             `json:"address_to"`
+	ExtraIdFrom       string             `json:"extra_id_from"`
+	ExtraIdTo         string             `json:"extra_id_to"`
+	UserRefundAddress string             `json:"user_refund_address"`
+	UserRefundExtraId string             `json:"user_refund_extra_id"`
+	TxFrom            string             `json:"tx_from"`
+	TxTo              string             `json:"tx_to"`
+	Status            string             `json:"status"`
 	Currencies        struct {
 		CurrencyFromTicker string `json:"currency_from_ticker"`
 		CurrencyToTicker   string `json:"currency_to_ticker"`
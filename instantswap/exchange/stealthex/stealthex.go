@@ -1,6 +1,7 @@
 package stealthex
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/vibros68/instantswap/instantswap"
@@ -13,6 +14,17 @@ const (
 	LIBNAME  = "stealthex"
 )
 
+// codes maps the stealthex tickers of the tokens issued on several networks.
+var codes = instantswap.CodeMap{Currencies: map[string]instantswap.CurrencyKey{
+	"usdt":      {Symbol: "USDT", Network: instantswap.NetworkEthereum},
+	"usdttrc20": {Symbol: "USDT", Network: instantswap.NetworkTron},
+	"usdtbsc":   {Symbol: "USDT", Network: instantswap.NetworkBSC},
+	"usdtsol":   {Symbol: "USDT", Network: instantswap.NetworkSolana},
+	"usdc":      {Symbol: "USDC", Network: instantswap.NetworkEthereum},
+	"usdcbsc":   {Symbol: "USDC", Network: instantswap.NetworkBSC},
+	"usdcsol":   {Symbol: "USDC", Network: instantswap.NetworkSolana},
+}}
+
 type stealthex struct {
 	client *instantswap.Client
 	conf   *instantswap.ExchangeConfig
@@ -28,6 +40,19 @@ func (s *stealthex) Name() string {
 	return LIBNAME
 }
 
+// Capabilities returns the features supported by stealthex.
+func (s *stealthex) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		ExtraID:          true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCOnRisk,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (s *stealthex) SetDebug(enable bool) {
 	s.conf.Debug = enable
@@ -36,16 +61,17 @@ func (s *stealthex) SetDebug(enable bool) {
 // New return a stealthex client.
 func New(conf instantswap.ExchangeConfig) (*stealthex, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &stealthex{client: client, conf: &conf}, nil
 }
 
-func (s *stealthex) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := s.client.Do(API_BASE, http.MethodGet,
+func (s *stealthex) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := s.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("currency?api_key=%s&fixed=boolean", s.conf.ApiKey), "", false)
 	if err != nil {
 		return nil, err
@@ -57,19 +83,14 @@ func (s *stealthex) GetCurrencies() (currencies []instantswap.Currency, err erro
 	}
 	currencies = make([]instantswap.Currency, len(sCurrs))
 	for i, curr := range sCurrs {
-		currencies[i] = instantswap.Currency{
-			Name:     curr.Name,
-			Symbol:   curr.Symbol,
-			IsFiat:   false,
-			IsStable: false,
-			Network:  curr.Network,
-		}
+		currencies[i] = codes.NewCurrency(curr.Name, curr.Symbol, curr.Network)
 	}
 	return currencies, nil
 }
 
-func (s *stealthex) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	r, err := s.client.Do(API_BASE, http.MethodGet,
+func (s *stealthex) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	from, _ = codes.ProviderCurrency(from, "")
+	r, err := s.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("pairs/%s?api_key=%s", strings.ToLower(from), s.conf.ApiKey), "", false)
 	if err != nil {
 		return nil, err
@@ -80,21 +101,17 @@ func (s *stealthex) GetCurrenciesToPair(from string) (currencies []instantswap.C
 		return nil, err
 	}
 	for _, toCurr := range pairs {
-		currencies = append(currencies, instantswap.Currency{
-			Name:     "",
-			Symbol:   toCurr,
-			IsFiat:   false,
-			IsStable: false,
-			Network:  "",
-		})
+		currencies = append(currencies, codes.NewCurrency("", toCurr, ""))
 	}
 	return currencies, nil
 }
 
-func (s *stealthex) estimateAmount(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	r, err := s.client.Do(API_BASE, http.MethodGet,
-		fmt.Sprintf("estimate/%s/%s?api_key=%s&fixed=true&amount=%.8f",
-			strings.ToLower(vars.From), strings.ToLower(vars.To), s.conf.ApiKey, vars.Amount), "", false)
+func (s *stealthex) estimateAmount(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	from, _ := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.To, vars.ToNetwork)
+	r, err := s.client.Do(ctx, API_BASE, http.MethodGet,
+		fmt.Sprintf("estimate/%s/%s?api_key=%s&fixed=%t&amount=%s",
+			strings.ToLower(from), strings.ToLower(to), s.conf.ApiKey, vars.RateType == instantswap.RateTypeFixed, vars.Amount), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -104,15 +121,18 @@ func (s *stealthex) estimateAmount(vars instantswap.ExchangeRateRequest) (res in
 		return res, err
 	}
 	res.EstimatedAmount = estimate.EstimatedAmount
-	res.ExchangeRate = vars.Amount / estimate.EstimatedAmount
+	res.ExchangeRate = estimate.EstimatedAmount.Div(vars.Amount, instantswap.RatePrecision)
 	res.Signature = estimate.RateId
+	res.RateType = vars.RateType
 	return res, nil
 }
 
-func (s *stealthex) getRange(vars instantswap.ExchangeRateRequest) (*Range, error) {
-	body, err := s.client.Do(API_BASE, http.MethodGet,
-		fmt.Sprintf("range/%s/%s?api_key=%s&fixed=true",
-			strings.ToLower(vars.From), strings.ToLower(vars.To), s.conf.ApiKey), "", false)
+func (s *stealthex) getRange(ctx context.Context, vars instantswap.ExchangeRateRequest) (*Range, error) {
+	from, _ := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.To, vars.ToNetwork)
+	body, err := s.client.Do(ctx, API_BASE, http.MethodGet,
+		fmt.Sprintf("range/%s/%s?api_key=%s&fixed=%t",
+			strings.ToLower(from), strings.ToLower(to), s.conf.ApiKey, vars.RateType == instantswap.RateTypeFixed), "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -121,37 +141,53 @@ func (s *stealthex) getRange(vars instantswap.ExchangeRateRequest) (*Range, erro
 	return &r, err
 }
 
-func (s *stealthex) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
-	res, err = s.estimateAmount(vars)
+func (s *stealthex) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	vars.RateType, err = instantswap.ChooseRateType(LIBNAME, s.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
 	if err != nil {
 		return res, err
 	}
-	r, err := s.getRange(vars)
+	res, err = s.estimateAmount(ctx, vars)
 	if err != nil {
+		return res, err
+	}
+	r, err := s.getRange(ctx, vars)
+	if err == nil {
 		res.Min = r.MinAmount
 		res.Max = r.MaxAmount
 	}
 	return res, nil
 }
 
-func (s *stealthex) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return res, fmt.Errorf("not supported")
+func (s *stealthex) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (s *stealthex) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (s *stealthex) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, s.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
+	from, _ := codes.ProviderCurrency(vars.FromCurrency, vars.FromNetwork)
+	to, _ := codes.ProviderCurrency(vars.ToCurrency, vars.ToNetwork)
 	var req = OrderRequest{
-		CurrencyFrom:  vars.FromCurrency,
-		CurrencyTo:    vars.ToCurrency,
+		CurrencyFrom:  strings.ToLower(from),
+		CurrencyTo:    strings.ToLower(to),
 		AddressTo:     vars.Destination,
-		AmountFrom:    vars.InvoicedAmount,
+		AmountFrom:    json.Number(vars.InvoicedAmount.String()),
 		RateId:        vars.Signature,
 		RefundAddress: vars.RefundAddress,
 		RefundExtraId: vars.RefundExtraID,
 		Provider:      vars.Provider,
-		Fixed:         true,
+		Fixed:         rateType == instantswap.RateTypeFixed,
 	}
 	body, _ := json.Marshal(req)
-	r, err := s.client.Do(API_BASE, http.MethodPost, fmt.Sprintf("exchange?api_key=%s", s.conf.ApiKey), string(body), false)
+	r, err := s.client.Do(ctx, API_BASE, http.MethodPost, fmt.Sprintf("exchange?api_key=%s", s.conf.ApiKey), string(body), false)
 	if err != nil {
 		return res, err
 	}
@@ -161,9 +197,9 @@ func (s *stealthex) CreateOrder(vars instantswap.CreateOrder) (res instantswap.C
 		return res, err
 	}
 	res = instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.AddressTo,
-		ExchangeRate:   order.AmountFrom / order.AmountTo,
+		ExchangeRate:   order.AmountTo.Div(order.AmountFrom, instantswap.RatePrecision),
 		FromCurrency:   order.CurrencyFrom,
 		InvoicedAmount: order.AmountFrom,
 		OrderedAmount:  order.AmountTo,
@@ -173,19 +209,26 @@ func (s *stealthex) CreateOrder(vars instantswap.CreateOrder) (res instantswap.C
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}
 	return res, nil
 }
 
-func (s *stealthex) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (s *stealthex) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
-func (s *stealthex) CancelOrder(orderID string) (res string, err error) {
-	return
+func (s *stealthex) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (s *stealthex) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (s *stealthex) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
-func (s *stealthex) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	r, err := s.client.Do(API_BASE, http.MethodGet, fmt.Sprintf("exchange/%s?api_key=%s", req.OrderId, s.conf.ApiKey), "", false)
+func (s *stealthex) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	r, err := s.client.Do(ctx, API_BASE, http.MethodGet, fmt.Sprintf("exchange/%s?api_key=%s", req.OrderId, s.conf.ApiKey), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -206,14 +249,29 @@ func (s *stealthex) OrderInfo(req instantswap.TrackingRequest) (res instantswap.
 	return
 }
 
-func (s *stealthex) EstimateAmount(vars interface{}) (res instantswap.EstimateAmount, err error) {
-	return
+func (s *stealthex) EstimateAmount(ctx context.Context, vars interface{}) (res instantswap.EstimateAmount, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "EstimateAmount")
 }
 
 func parseResponseData(data []byte, obj interface{}) error {
 	return json.Unmarshal(data, obj)
 }
 
+// parseError reads the {"err": {"kind", "details"}} payload of a failed
+// response, the kind is guessed from both the kind and the details.
+func parseError(statusCode int, body []byte) (error, string) {
+	var e Error
+	if json.Unmarshal(body, &e) != nil || e.Err.Kind == "" {
+		return nil, ""
+	}
+	message := e.Err.Details
+	if message == "" {
+		message = e.Err.Kind
+	}
+	kind := strings.ReplaceAll(strings.ToLower(e.Err.Kind), "_", " ")
+	return instantswap.ErrorKind(kind + ": " + e.Err.Details), message
+}
+
 // waiting, confirming, exchanging, sending, finished, failed, refunded, verifying
 func parseStatus(status string) instantswap.Status {
 	switch status {
@@ -1,6 +1,10 @@
 package stealthex
 
-import "time"
+import (
+	"encoding/json"
+	"github.com/vibros68/instantswap/instantswap"
+	"time"
+)
 
 type Currency struct {
 	Symbol            string      `json:"symbol"`
@@ -18,28 +22,28 @@ type Currency struct {
 }
 
 type Estimate struct {
-	EstimatedAmount float64 `json:"estimated_amount,string"`
-	RateId          string  `json:"rate_id"`
+	EstimatedAmount instantswap.Amount `json:"estimated_amount"`
+	RateId          string             `json:"rate_id"`
 }
 
 type Range struct {
-	MinAmount float64 `json:"min_amount,string"`
-	MaxAmount float64 `json:"max_amount,string"`
+	MinAmount instantswap.Amount `json:"min_amount"`
+	MaxAmount instantswap.Amount `json:"max_amount"`
 }
 
 type OrderRequest struct {
-	CurrencyFrom  string  `json:"currency_from"`
-	CurrencyTo    string  `json:"currency_to"`
-	AddressTo     string  `json:"address_to"`
-	ExtraIdTo     string  `json:"extra_id_to"`
-	AmountFrom    float64 `json:"amount_from,omitempty,string"`
-	AmountTo      float64 `json:"amount_to,omitempty,string"`
-	RateId        string  `json:"rate_id"`
-	Referral      string  `json:"referral"`
-	Fixed         bool    `json:"fixed"`
-	Provider      string  `json:"provider"`
-	RefundAddress string  `json:"refund_address"`
-	RefundExtraId string  `json:"refund_extra_id"`
+	CurrencyFrom  string      `json:"currency_from"`
+	CurrencyTo    string      `json:"currency_to"`
+	AddressTo     string      `json:"address_to"`
+	ExtraIdTo     string      `json:"extra_id_to"`
+	AmountFrom    json.Number `json:"amount_from,omitempty,string"`
+	AmountTo      json.Number `json:"amount_to,omitempty,string"`
+	RateId        string      `json:"rate_id"`
+	Referral      string      `json:"referral"`
+	Fixed         bool        `json:"fixed"`
+	Provider      string      `json:"provider"`
+	RefundAddress string      `json:"refund_address"`
+	RefundExtraId string      `json:"refund_extra_id"`
 }
 
 type Order struct {
@@ -49,9 +53,9 @@ type Order struct {
 	UpdatedAt      time.Time           `json:"updated_at"`
 	CurrencyFrom   string              `json:"currency_from"`
 	CurrencyTo     string              `json:"currency_to"`
-	AmountFrom     float64             `json:"amount_from,string"`
+	AmountFrom     instantswap.Amount  `json:"amount_from"`
 	ExpectedAmount string              `json:"expected_amount"`
-	AmountTo       float64             `json:"amount_to,string"`
+	AmountTo       instantswap.Amount  `json:"amount_to"`
 	PartnerFee     interface{}         `json:"partner_fee"`
 	AddressFrom    string              `json:"address_from"`
 	AddressTo      string              `json:"address_to"`
@@ -64,3 +68,11 @@ type Order struct {
 	RefundAddress  string              `json:"refund_address"`
 	RefundExtraId  string              `json:"refund_extra_id"`
 }
+
+// Error is the payload of a failed request.
+type Error struct {
+	Err struct {
+		Kind    string `json:"kind"`
+		Details string `json:"details"`
+	} `json:"err"`
+}
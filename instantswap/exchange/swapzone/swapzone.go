@@ -1,6 +1,7 @@
 package swapzone
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/vibros68/instantswap/instantswap"
-	"github.com/vibros68/instantswap/instantswap/utils"
 )
 
 const (
@@ -25,13 +25,14 @@ func init() {
 // New return a SwapZone client.
 func New(conf instantswap.ExchangeConfig) (*SwapZone, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		r.Header.Set("x-api-key", conf.ApiKey)
 		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &SwapZone{client: client, conf: &conf}, nil
 }
 
@@ -46,14 +47,33 @@ func (c *SwapZone) Name() string {
 	return LIBNAME
 }
 
+// apiRateTypes are the rateType values of the api by rate mode, "all"
+// requests the rates of both modes.
+var apiRateTypes = map[instantswap.RateType]string{
+	instantswap.RateTypeFixed: "fix",
+	instantswap.RateTypeFloat: "float",
+}
+
+// Capabilities returns the features supported by swapzone.
+func (c *SwapZone) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCUnknown,
+	}
+}
+
 // SetDebug set enable/disable http request/response dump.
 func (c *SwapZone) SetDebug(enable bool) {
 	c.conf.Debug = enable
 }
 
-func (c *SwapZone) GetCurrencies() (currencies []instantswap.Currency, err error) {
+func (c *SwapZone) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET", "exchange/currencies", "", false)
+	r, err = c.client.Do(ctx, API_BASE, "GET", "exchange/currencies", "", false)
 	if err != nil {
 		return
 	}
@@ -64,16 +84,13 @@ func (c *SwapZone) GetCurrencies() (currencies []instantswap.Currency, err error
 	}
 	currencies = make([]instantswap.Currency, len(szCurrencies))
 	for i, curr := range szCurrencies {
-		currencies[i] = instantswap.Currency{
-			Name:   curr.Name,
-			Symbol: curr.Ticker,
-		}
+		currencies[i] = instantswap.NewCurrency(curr.Name, curr.Ticker, curr.Network)
 	}
 	return
 }
-func (c *SwapZone) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
+func (c *SwapZone) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET", "exchange/currencies", "", false)
+	r, err = c.client.Do(ctx, API_BASE, "GET", "exchange/currencies", "", false)
 	if err != nil {
 		return
 	}
@@ -87,19 +104,29 @@ func (c *SwapZone) GetCurrenciesToPair(from string) (currencies []instantswap.Cu
 		if strings.ToLower(curr.Ticker) == strings.ToLower(from) {
 			continue
 		}
-		currencies = append(currencies, instantswap.Currency{
-			Name:   curr.Name,
-			Symbol: curr.Ticker,
-		})
+		currencies = append(currencies, instantswap.NewCurrency(curr.Name, curr.Ticker, curr.Network))
 	}
 	return
 }
 
-func (c *SwapZone) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (c *SwapZone) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	// the best rate of any mode is requested when no mode is set.
+	var rateType instantswap.RateType
+	apiRateType := "all"
+	if vars.RateType != "" {
+		rateType, err = instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+		if err != nil {
+			return res, err
+		}
+		apiRateType = apiRateTypes[rateType]
+	}
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET",
-		fmt.Sprintf("exchange/get-rate?from=%s&to=%s&amount=%.8f&rateType=all&availableInUSA=false&chooseRate=best&noRefundAddress=false",
-			strings.ToLower(vars.From), strings.ToLower(vars.To), vars.Amount),
+	r, err = c.client.Do(ctx, API_BASE, "GET",
+		fmt.Sprintf("exchange/get-rate?from=%s&to=%s&amount=%s&rateType=%s&availableInUSA=false&chooseRate=best&noRefundAddress=false",
+			strings.ToLower(vars.From), strings.ToLower(vars.To), vars.Amount, apiRateType),
 		"", false)
 	if err != nil {
 		return
@@ -112,28 +139,46 @@ func (c *SwapZone) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (re
 	res.Min = exchangeRate.MinAmount
 	res.Max = exchangeRate.MaxAmount
 	res.EstimatedAmount = exchangeRate.AmountTo
-	res.ExchangeRate = exchangeRate.AmountTo / exchangeRate.AmountFrom
+	res.ExchangeRate = exchangeRate.AmountTo.Div(exchangeRate.AmountFrom, instantswap.RatePrecision)
 	res.Signature = exchangeRate.QuotaId
+	res.ValidUntil = exchangeRate.ValidUntil
+	res.RateType = rateType
+	for mode, value := range apiRateTypes {
+		if exchangeRate.RateType == value {
+			res.RateType = mode
+		}
+	}
 	return
 }
 
-func (c *SwapZone) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
-	return res, fmt.Errorf("not supported")
+func (c *SwapZone) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryRates")
 }
 
-func (c *SwapZone) QueryActiveCurrencies(vars interface{}) (res []instantswap.ActiveCurr, err error) {
-	return
+func (c *SwapZone) QueryActiveCurrencies(ctx context.Context, vars interface{}) (res []instantswap.ActiveCurr, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryActiveCurrencies")
 }
 
-func (c *SwapZone) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return
+func (c *SwapZone) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (c *SwapZone) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (c *SwapZone) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, c.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
+	// the fixed rate is the one of the quota, the orders without quota float.
+	if rateType == instantswap.RateTypeFixed && vars.Signature == "" {
+		return res, instantswap.NewError(LIBNAME, nil, "fixed rate order requires the quota id of a fixed rate as signature")
+	}
 	var form = make(url.Values)
 	form.Set("from", strings.ToLower(vars.FromCurrency))
 	form.Set("to", strings.ToLower(vars.ToCurrency))
-	form.Set("amountDeposit", fmt.Sprintf("%.8f", vars.InvoicedAmount))
+	form.Set("amountDeposit", vars.InvoicedAmount.String())
 	form.Set("addressReceive", vars.Destination)
 	form.Set("extraIdReceive", "") // Memo tag (optional)
 	form.Set("refundAddress", vars.RefundAddress)
@@ -143,7 +188,7 @@ func (c *SwapZone) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Cr
 	}
 
 	var r []byte
-	r, err = c.client.Do(API_BASE, "POST", "exchange/create",
+	r, err = c.client.Do(ctx, API_BASE, "POST", "exchange/create",
 		form.Encode(), false)
 	if err != nil {
 		return
@@ -154,12 +199,12 @@ func (c *SwapZone) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Cr
 		return
 	}
 	var order = tx.Transaction
-	var invoicedAmount = utils.StrToFloat(order.AmountDeposit)
-	var orderedAmount = utils.StrToFloat(order.AmountEstimated)
+	var invoicedAmount = order.AmountDeposit
+	var orderedAmount = order.AmountEstimated
 	res = instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.AddressReceive,
-		ExchangeRate:   invoicedAmount / orderedAmount,
+		ExchangeRate:   invoicedAmount.Div(orderedAmount, instantswap.RatePrecision),
 		FromCurrency:   order.From,
 		InvoicedAmount: invoicedAmount,
 		OrderedAmount:  orderedAmount,
@@ -169,22 +214,29 @@ func (c *SwapZone) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Cr
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}
 	return
 }
 
 // UpdateOrder accepts orderID value and more if needed per lib.
-func (c *SwapZone) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (c *SwapZone) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
-func (c *SwapZone) CancelOrder(orderID string) (res string, err error) {
-	return
+func (c *SwapZone) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (c *SwapZone) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (c *SwapZone) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
 // OrderInfo accepts orderID value and more if needed per lib.
-func (c *SwapZone) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (c *SwapZone) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	var r []byte
-	r, err = c.client.Do(API_BASE, "GET",
+	r, err = c.client.Do(ctx, API_BASE, "GET",
 		fmt.Sprintf("exchange/tx?id=%s", req.OrderId),
 		"", false)
 	if err != nil {
@@ -199,27 +251,26 @@ func (c *SwapZone) OrderInfo(req instantswap.TrackingRequest) (res instantswap.O
 	res = instantswap.OrderInfoResult{
 		Expires:        0,
 		LastUpdate:     "",
-		ReceiveAmount:  0,
+		ReceiveAmount:  instantswap.Amount{},
 		TxID:           "",
 		Status:         order.Status,
 		InternalStatus: GetLocalStatus(order.Status),
 		Confirmations:  "",
 	}
 	if order.Status == "" {
-		res.ReceiveAmount = utils.StrToFloat(order.AmountEstimated)
+		res.ReceiveAmount = order.AmountEstimated
 	}
 	return
 }
 
-func (c *SwapZone) EstimateAmount(vars interface{}) (res instantswap.EstimateAmount, err error) {
-	return
+func (c *SwapZone) EstimateAmount(ctx context.Context, vars interface{}) (res instantswap.EstimateAmount, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "EstimateAmount")
 }
 
 func parseResponseData(data []byte, obj interface{}) error {
-	var swapzoneErr SwapzoneError
-	err := json.Unmarshal(data, &swapzoneErr)
-	if err == nil && swapzoneErr.Error {
-		return fmt.Errorf(swapzoneErr.Message)
+	err := instantswap.ResponseError(LIBNAME, http.StatusOK, data, parseError)
+	if err != nil {
+		return err
 	}
 	err = json.Unmarshal(data, obj)
 	if err != nil {
@@ -228,6 +279,15 @@ func parseResponseData(data []byte, obj interface{}) error {
 	return nil
 }
 
+// parseError reads the {"error": true, "message"} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var swapzoneErr SwapzoneError
+	if json.Unmarshal(body, &swapzoneErr) != nil || !swapzoneErr.Error {
+		return nil, ""
+	}
+	return nil, swapzoneErr.Message
+}
+
 // GetLocalStatus translate local status to instantswap.Status.
 func GetLocalStatus(status string) instantswap.Status {
 	status = strings.ToLower(status)
@@ -8,13 +8,13 @@ import (
 )
 
 type Coin struct {
-	Name    string  `json:"name"`
-	Ticker  string  `json:"ticker"`
-	Network string  `json:"network"`
-	Memo    bool    `json:"memo"`
-	Image   string  `json:"image"`
-	Minimum float64 `json:"minimum"`
-	Maximum float64 `json:"maximum"`
+	Name    string             `json:"name"`
+	Ticker  string             `json:"ticker"`
+	Network string             `json:"network"`
+	Memo    bool               `json:"memo"`
+	Image   string             `json:"image"`
+	Minimum instantswap.Amount `json:"minimum"`
+	Maximum instantswap.Amount `json:"maximum"`
 }
 
 type Error struct {
@@ -22,19 +22,19 @@ type Error struct {
 }
 
 type Rate struct {
-	TradeId     string  `json:"trade_id"`
-	Date        string  `json:"date"`
-	TickerFrom  string  `json:"ticker_from"`
-	TickerTo    string  `json:"ticker_to"`
-	CoinFrom    string  `json:"coin_from"`
-	CoinTo      string  `json:"coin_to"`
-	NetworkFrom string  `json:"network_from"`
-	NetworkTo   string  `json:"network_to"`
-	AmountFrom  float64 `json:"amount_from"`
-	AmountTo    float64 `json:"amount_to"`
-	Provider    string  `json:"provider"`
-	Fixed       bool    `json:"fixed"`
-	Status      string  `json:"status"`
+	TradeId     string             `json:"trade_id"`
+	Date        string             `json:"date"`
+	TickerFrom  string             `json:"ticker_from"`
+	TickerTo    string             `json:"ticker_to"`
+	CoinFrom    string             `json:"coin_from"`
+	CoinTo      string             `json:"coin_to"`
+	NetworkFrom string             `json:"network_from"`
+	NetworkTo   string             `json:"network_to"`
+	AmountFrom  instantswap.Amount `json:"amount_from"`
+	AmountTo    instantswap.Amount `json:"amount_to"`
+	Provider    string             `json:"provider"`
+	Fixed       bool               `json:"fixed"`
+	Status      string             `json:"status"`
 	Quotes      struct {
 		Quotes []Quote `json:"quotes"`
 	} `json:"quotes"`
@@ -43,51 +43,75 @@ type Rate struct {
 
 func (r *Rate) maxProvider() string {
 	for _, q := range r.Quotes.Quotes {
-		if q.Waste == 0 {
+		if q.Waste.IsZero() {
 			return q.Provider
 		}
 	}
 	return r.Provider
 }
 
-func (r *Rate) rate() float64 {
-	return r.AmountTo / r.AmountFrom
+func (r *Rate) rateType() instantswap.RateType {
+	if r.Fixed {
+		return instantswap.RateTypeFixed
+	}
+	return instantswap.RateTypeFloat
+}
+
+// quote returns the best quote of rateType, the quotes are sorted by amount.
+func (r *Rate) quote(rateType instantswap.RateType) (Quote, bool) {
+	for _, q := range r.Quotes.Quotes {
+		if q.rateType() == rateType {
+			return q, true
+		}
+	}
+	return Quote{}, false
+}
+
+func (q *Quote) rateType() instantswap.RateType {
+	if strings.EqualFold(q.Fixed, "true") {
+		return instantswap.RateTypeFixed
+	}
+	return instantswap.RateTypeFloat
+}
+
+func (r *Rate) rate() instantswap.Amount {
+	return r.AmountTo.Div(r.AmountFrom, instantswap.RatePrecision)
 }
 
 type Quote struct {
-	Provider  string  `json:"provider"`
-	KycRating string  `json:"kycrating"`
-	LogPolicy string  `json:"logpolicy"`
-	Insurance int     `json:"insurance"`
-	Fixed     string  `json:"fixed"`
-	AmountTo  float64 `json:"amount_to,string"`
-	Waste     float64 `json:"waste,string"`
-	Eta       float64 `json:"eta"`
+	Provider  string             `json:"provider"`
+	KycRating string             `json:"kycrating"`
+	LogPolicy string             `json:"logpolicy"`
+	Insurance int                `json:"insurance"`
+	Fixed     string             `json:"fixed"`
+	AmountTo  instantswap.Amount `json:"amount_to"`
+	Waste     instantswap.Amount `json:"waste"`
+	Eta       float64            `json:"eta"`
 }
 
 type Trade struct {
-	TradeId             string    `json:"trade_id"`
-	Date                time.Time `json:"date"`
-	TickerFrom          string    `json:"ticker_from"`
-	TickerTo            string    `json:"ticker_to"`
-	CoinFrom            string    `json:"coin_from"`
-	CoinTo              string    `json:"coin_to"`
-	NetworkFrom         string    `json:"network_from"`
-	NetworkTo           string    `json:"network_to"`
-	AmountFrom          float64   `json:"amount_from"`
-	AmountTo            float64   `json:"amount_to"`
-	Provider            string    `json:"provider"`
-	Fixed               bool      `json:"fixed"`
-	Payment             bool      `json:"payment"`
-	Status              string    `json:"status"`
-	AddressProvider     string    `json:"address_provider"`
-	AddressProviderMemo string    `json:"address_provider_memo"`
-	AddressUser         string    `json:"address_user"`
-	AddressUserMemo     string    `json:"address_user_memo"`
-	RefundAddress       string    `json:"refund_address"`
-	RefundAddressMemo   string    `json:"refund_address_memo"`
-	Password            string    `json:"password"`
-	IdProvider          string    `json:"id_provider"`
+	TradeId             string             `json:"trade_id"`
+	Date                time.Time          `json:"date"`
+	TickerFrom          string             `json:"ticker_from"`
+	TickerTo            string             `json:"ticker_to"`
+	CoinFrom            string             `json:"coin_from"`
+	CoinTo              string             `json:"coin_to"`
+	NetworkFrom         string             `json:"network_from"`
+	NetworkTo           string             `json:"network_to"`
+	AmountFrom          instantswap.Amount `json:"amount_from"`
+	AmountTo            instantswap.Amount `json:"amount_to"`
+	Provider            string             `json:"provider"`
+	Fixed               bool               `json:"fixed"`
+	Payment             bool               `json:"payment"`
+	Status              string             `json:"status"`
+	AddressProvider     string             `json:"address_provider"`
+	AddressProviderMemo string             `json:"address_provider_memo"`
+	AddressUser         string             `json:"address_user"`
+	AddressUserMemo     string             `json:"address_user_memo"`
+	RefundAddress       string             `json:"refund_address"`
+	RefundAddressMemo   string             `json:"refund_address_memo"`
+	Password            string             `json:"password"`
+	IdProvider          string             `json:"id_provider"`
 	Quotes              struct {
 		Support struct {
 			TxUrl      string `json:"tx_url"`
@@ -103,10 +127,10 @@ type Trade struct {
 }
 
 type TradeDetail struct {
-	Webhook            string      `json:"webhook"`
-	Hashout            interface{} `json:"hashout"`
-	MarketrateCreation float64     `json:"marketrate_creation"`
-	AmountBtc          float64     `json:"amount_btc"`
+	Webhook            string             `json:"webhook"`
+	Hashout            interface{}        `json:"hashout"`
+	MarketrateCreation instantswap.Amount `json:"marketrate_creation"`
+	AmountBtc          instantswap.Amount `json:"amount_btc"`
 	Support            struct {
 		TxUrl      string `json:"tx_url"`
 		SupportUrl string `json:"support_url"`
@@ -123,8 +147,15 @@ func (t *TradeDetail) tx() string {
 	return t.Hashout.(string)
 }
 
-func (t *Trade) rate() float64 {
-	return t.AmountTo / t.AmountFrom
+func (t *Trade) rateType() instantswap.RateType {
+	if t.Fixed {
+		return instantswap.RateTypeFixed
+	}
+	return instantswap.RateTypeFloat
+}
+
+func (t *Trade) rate() instantswap.Amount {
+	return t.AmountTo.Div(t.AmountFrom, instantswap.RatePrecision)
 }
 
 //- new: you have rates, but did not create the swap yet;
@@ -1,6 +1,7 @@
 package trocador
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/vibros68/instantswap/instantswap"
@@ -14,6 +15,24 @@ const (
 	LIBNAME  = "trocador"
 )
 
+// codes maps the trocador network codes, the coins on their own chain are on
+// Mainnet.
+var codes = instantswap.CodeMap{
+	Native: "Mainnet",
+	Networks: map[string]string{
+		"ERC20":    instantswap.NetworkEthereum,
+		"TRC20":    instantswap.NetworkTron,
+		"BEP20":    instantswap.NetworkBSC,
+		"Solana":   instantswap.NetworkSolana,
+		"Polygon":  instantswap.NetworkPolygon,
+		"Arbitrum": instantswap.NetworkArbitrum,
+		"Optimism": instantswap.NetworkOptimism,
+		"Base":     instantswap.NetworkBase,
+		"AVAXC":    instantswap.NetworkAvalanche,
+		"TON":      instantswap.NetworkTON,
+	},
+}
+
 type trocador struct {
 	client *instantswap.Client
 	conf   *instantswap.ExchangeConfig
@@ -33,11 +52,12 @@ func (t *trocador) SetDebug(enable bool) {
 // New return a trocador client.
 func New(conf instantswap.ExchangeConfig) (*trocador, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &trocador{client: client, conf: &conf}, nil
 }
 
@@ -45,10 +65,23 @@ func (t *trocador) Name() string {
 	return LIBNAME
 }
 
-func (t *trocador) currencies() ([]instantswap.Currency, error) {
+// Capabilities returns the features supported by trocador.
+func (t *trocador) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FixedRate:        true,
+		FloatingRate:     true,
+		Networks:         true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCUnknown,
+	}
+}
+
+func (t *trocador) currencies(ctx context.Context) ([]instantswap.Currency, error) {
 	var form = url.Values{}
 	form.Set("api_key", t.conf.ApiKey)
-	r, err := t.client.Do(API_BASE, "GET", "coins?"+form.Encode(), "", false)
+	r, err := t.client.Do(ctx, API_BASE, "GET", "coins?"+form.Encode(), "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -59,21 +92,29 @@ func (t *trocador) currencies() ([]instantswap.Currency, error) {
 	}
 	var currencies []instantswap.Currency
 	for _, tcdCurr := range coins {
-		curr := instantswap.Currency{
-			Name:    tcdCurr.Name,
-			Symbol:  tcdCurr.Ticker,
-			Network: tcdCurr.Network,
-		}
-		currencies = append(currencies, curr)
+		currencies = append(currencies, codes.NewCurrency(tcdCurr.Name, tcdCurr.Ticker, tcdCurr.Network))
 	}
 	return currencies, nil
 }
 
-func (t *trocador) coin(ticker string) (*Coin, error) {
+// coin returns the metadata of the ticker on the trocador network, the first
+// listed network when it is empty. It is memoized by the cache of ctx.
+func (t *trocador) coin(ctx context.Context, ticker, network string) (*Coin, error) {
+	ticker = strings.ToLower(ticker)
+	coin, err := instantswap.Cached(ctx, "coin/"+ticker+"/"+network, func(ctx context.Context) (interface{}, error) {
+		return t.fetchCoin(ctx, ticker, network)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coin.(*Coin), nil
+}
+
+func (t *trocador) fetchCoin(ctx context.Context, ticker, network string) (*Coin, error) {
 	var form = url.Values{}
 	form.Set("api_key", t.conf.ApiKey)
-	form.Set("ticker", strings.ToLower(ticker))
-	r, err := t.client.Do(API_BASE, "GET", "coin?"+form.Encode(), "", false)
+	form.Set("ticker", ticker)
+	r, err := t.client.Do(ctx, API_BASE, "GET", "coin?"+form.Encode(), "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -82,23 +123,26 @@ func (t *trocador) coin(ticker string) (*Coin, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(coins) == 0 {
-		return nil, fmt.Errorf("coin not found")
+	for i := range coins {
+		if network == "" || strings.EqualFold(coins[i].Network, network) {
+			return &coins[i], nil
+		}
 	}
-	return &coins[0], nil
+	return nil, instantswap.NewError(LIBNAME, instantswap.ErrPairNotSupported,
+		fmt.Sprintf("coin %s not found on network %s", ticker, network))
 }
 
-func (t *trocador) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	return t.currencies()
+func (t *trocador) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	return t.currencies(ctx)
 }
 
-func (t *trocador) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
-	currencies, err = t.currencies()
+func (t *trocador) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
+	all, err := t.currencies(ctx)
 	if err != nil {
 		return nil, err
 	}
-	for _, curr := range currencies {
-		if curr.Symbol == from {
+	for _, curr := range all {
+		if strings.EqualFold(curr.Symbol, from) {
 			continue
 		}
 		currencies = append(currencies, curr)
@@ -106,16 +150,25 @@ func (t *trocador) GetCurrenciesToPair(from string) (currencies []instantswap.Cu
 	return
 }
 
-func (t *trocador) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (t *trocador) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, t.Capabilities(), vars.RateType, "")
+	if err != nil {
+		return res, err
+	}
 	var r []byte
 	var form = url.Values{}
+	from, fromNetwork := codes.ProviderCurrency(vars.From, vars.FromNetwork)
+	to, toNetwork := codes.ProviderCurrency(vars.To, vars.ToNetwork)
 	form.Set("api_key", t.conf.ApiKey)
-	form.Set("ticker_from", strings.ToLower(vars.From))
-	form.Set("ticker_to", strings.ToLower(vars.To))
-	form.Set("network_from", vars.FromNetwork)
-	form.Set("network_to", vars.ToNetwork)
-	form.Set("amount_from", fmt.Sprintf("%.8f", vars.Amount))
-	r, err = t.client.Do(API_BASE, "GET", "new_rate?"+form.Encode(), "", false)
+	form.Set("ticker_from", strings.ToLower(from))
+	form.Set("ticker_to", strings.ToLower(to))
+	form.Set("network_from", fromNetwork)
+	form.Set("network_to", toNetwork)
+	form.Set("amount_from", vars.Amount.String())
+	r, err = t.client.Do(ctx, API_BASE, "GET", "new_rate?"+form.Encode(), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -124,51 +177,80 @@ func (t *trocador) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (re
 	if err != nil {
 		return res, err
 	}
-	coin, err := t.coin(vars.From)
+	coin, err := t.coin(ctx, from, fromNetwork)
 	if err != nil {
 		return res, err
 	}
-	return instantswap.ExchangeRateInfo{
+	res = instantswap.ExchangeRateInfo{
 		Min:             coin.Minimum,
 		Max:             coin.Maximum,
 		ExchangeRate:    rate.rate(),
 		EstimatedAmount: rate.AmountTo,
-		MaxOrder:        0,
+		MaxOrder:        instantswap.Amount{},
 		Signature:       rate.TradeId,
 		Provider:        rate.maxProvider(),
-	}, nil
+		RateType:        rate.rateType(),
+	}
+	// the best rate may be of the other type, use the best quote of the
+	// requested one.
+	if rateType != "" && rateType != res.RateType {
+		quote, ok := rate.quote(rateType)
+		if !ok {
+			return res, instantswap.NewError(LIBNAME, instantswap.ErrPairNotSupported,
+				fmt.Sprintf("no %s rate for %s-%s", rateType, from, to))
+		}
+		res.EstimatedAmount = quote.AmountTo
+		res.ExchangeRate = quote.AmountTo.Div(rate.AmountFrom, instantswap.RatePrecision)
+		res.Provider = quote.Provider
+		res.RateType = rateType
+	}
+	return res, nil
 }
 
-func (t *trocador) QueryRates(vars interface{}) (res []instantswap.QueryRate, err error) {
-	return res, fmt.Errorf("not supported")
+func (t *trocador) QueryRates(ctx context.Context, vars interface{}) (res []instantswap.QueryRate, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryRates")
 }
 
-func (t *trocador) QueryActiveCurrencies(vars interface{}) (res []instantswap.ActiveCurr, err error) {
-	return res, fmt.Errorf("not supported")
+func (t *trocador) QueryActiveCurrencies(ctx context.Context, vars interface{}) (res []instantswap.ActiveCurr, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryActiveCurrencies")
 }
 
-func (t *trocador) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return res, fmt.Errorf("not supported")
+func (t *trocador) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (t *trocador) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (t *trocador) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, t.Capabilities(), vars.RateType, instantswap.RateTypeFixed)
+	if err != nil {
+		return res, err
+	}
 	var r []byte
 	var form = url.Values{}
 	if len(vars.Signature) > 0 {
 		form.Set("id", vars.Signature)
 	}
+	from, fromNetwork := codes.ProviderCurrency(vars.FromCurrency, vars.FromNetwork)
+	to, toNetwork := codes.ProviderCurrency(vars.ToCurrency, vars.ToNetwork)
 	form.Set("api_key", t.conf.ApiKey)
-	form.Set("ticker_from", strings.ToLower(vars.FromCurrency))
-	form.Set("ticker_to", strings.ToLower(vars.ToCurrency))
-	form.Set("network_from", vars.FromNetwork)
-	form.Set("network_to", vars.ToNetwork)
-	form.Set("amount_from", fmt.Sprintf("%.8f", vars.InvoicedAmount))
+	form.Set("ticker_from", strings.ToLower(from))
+	form.Set("ticker_to", strings.ToLower(to))
+	form.Set("network_from", fromNetwork)
+	form.Set("network_to", toNetwork)
+	form.Set("amount_from", vars.InvoicedAmount.String())
 	form.Set("address", vars.Destination)
-	form.Set("fixed", "True")
+	if rateType == instantswap.RateTypeFixed {
+		form.Set("fixed", "True")
+	} else {
+		form.Set("fixed", "False")
+	}
 	form.Set("refund", vars.RefundAddress)
 	form.Set("provider", vars.Provider)
 	form.Set("refund_memo", "0")
-	r, err = t.client.Do(API_BASE, "GET", "new_trade?"+form.Encode(), "", false)
+	// the trade is created by a GET request, it must not be retried.
+	r, err = t.client.Do(instantswap.WithNotIdempotent(ctx), API_BASE, "GET", "new_trade?"+form.Encode(), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -179,7 +261,7 @@ func (t *trocador) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Cr
 	}
 
 	return instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    trade.AddressUser,
 		ExchangeRate:   trade.rate(),
 		FromCurrency:   strings.ToUpper(trade.TickerFrom),
@@ -191,24 +273,31 @@ func (t *trocador) CreateOrder(vars instantswap.CreateOrder) (res instantswap.Cr
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       trade.rateType(),
 	}, nil
 }
 
 // UpdateOrder accepts orderID value and more if needed per lib.
-func (t *trocador) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (t *trocador) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
 }
-func (t *trocador) CancelOrder(orderID string) (res string, err error) {
-	return
+func (t *trocador) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
+}
+func (t *trocador) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (t *trocador) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
 // OrderInfo accepts orderID value and more if needed per lib.
-func (t *trocador) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+func (t *trocador) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
 	var r []byte
 	var form = url.Values{}
 	form.Set("id", req.OrderId)
 	form.Set("api_key", t.conf.ApiKey)
-	r, err = t.client.Do(API_BASE, http.MethodGet,
+	r, err = t.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("trade?%s", form.Encode()),
 		"", false)
 	if err != nil {
@@ -220,14 +309,14 @@ func (t *trocador) OrderInfo(req instantswap.TrackingRequest) (res instantswap.O
 		return
 	}
 	if len(trades) == 0 {
-		return res, fmt.Errorf("order not found")
+		return res, instantswap.NewError(LIBNAME, instantswap.ErrOrderNotFound, "order not found")
 	}
 	trade := trades[0]
 
 	res = instantswap.OrderInfoResult{
 		Expires:        0,
 		LastUpdate:     "",
-		ReceiveAmount:  0,
+		ReceiveAmount:  instantswap.Amount{},
 		TxID:           trade.Details.tx(),
 		Status:         trade.Status,
 		InternalStatus: localStatus(trade.Status),
@@ -236,16 +325,22 @@ func (t *trocador) OrderInfo(req instantswap.TrackingRequest) (res instantswap.O
 	return
 }
 
-func (t *trocador) EstimateAmount(vars interface{}) (res instantswap.EstimateAmount, err error) {
-	return
+func (t *trocador) EstimateAmount(ctx context.Context, vars interface{}) (res instantswap.EstimateAmount, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "EstimateAmount")
 }
 
 func parseResponseData(data []byte, obj interface{}) error {
-	var err Error
-	if json.Unmarshal(data, &err) == nil {
-		if len(err.Error) > 0 {
-			return fmt.Errorf(err.Error)
-		}
+	if err := instantswap.ResponseError(LIBNAME, http.StatusOK, data, parseError); err != nil {
+		return err
 	}
 	return json.Unmarshal(data, obj)
 }
+
+// parseError reads the {"error"} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var e Error
+	if json.Unmarshal(body, &e) != nil {
+		return nil, ""
+	}
+	return nil, e.Error
+}
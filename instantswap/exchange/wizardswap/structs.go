@@ -1,5 +1,10 @@
 package wizardswap
 
+import (
+	"encoding/json"
+	"github.com/vibros68/instantswap/instantswap"
+)
+
 type Currency struct {
 	Field1                   int         `json:"0"`
 	Field2                   string      `json:"1"`
@@ -45,8 +50,13 @@ type Currency struct {
 	Testnet                  int         `json:"testnet"`
 }
 
+// Error is the payload of a failed request.
+type Error struct {
+	Error string `json:"error"`
+}
+
 type Estimate struct {
-	EstimatedAmount float64 `json:"estimated_amount,string"`
+	EstimatedAmount instantswap.Amount `json:"estimated_amount"`
 }
 
 /*currency_from	String	Base currency ticker in lowercase
@@ -59,14 +69,14 @@ refund_extra_id	String	Refund Extra ID (optional)
 api_key	String	User API key to earn referral fees.*/
 
 type OrderRequest struct {
-	CurrencyFrom  string  `json:"currency_from"`
-	CurrencyTo    string  `json:"currency_to"`
-	AddressTo     string  `json:"address_to"`
-	AmountFrom    float64 `json:"amount_from,string"`
-	RefundAddress string  `json:"refund_address"`
-	ExtraIdTo     string  `json:"extra_id_to"`
-	RefundExtraId string  `json:"refund_extra_id"`
-	ApiKey        string  `json:"api_key"`
+	CurrencyFrom  string      `json:"currency_from"`
+	CurrencyTo    string      `json:"currency_to"`
+	AddressTo     string      `json:"address_to"`
+	AmountFrom    json.Number `json:"amount_from,string"`
+	RefundAddress string      `json:"refund_address"`
+	ExtraIdTo     string      `json:"extra_id_to"`
+	RefundExtraId string      `json:"refund_extra_id"`
+	ApiKey        string      `json:"api_key"`
 }
 
 type Exchange struct {
@@ -76,9 +86,9 @@ type Exchange struct {
 	UpdatedAt      string              `json:"updated_at"`
 	CurrencyFrom   string              `json:"currency_from"`
 	CurrencyTo     string              `json:"currency_to"`
-	AmountFrom     float64             `json:"amount_from,string"`
-	ExpectedAmount float64             `json:"expected_amount,string"`
-	AmountTo       float64             `json:"amount_to,string"`
+	AmountFrom     instantswap.Amount  `json:"amount_from"`
+	ExpectedAmount instantswap.Amount  `json:"expected_amount"`
+	AmountTo       instantswap.Amount  `json:"amount_to"`
 	AddressFrom    string              `json:"address_from"`
 	AddressTo      string              `json:"address_to"`
 	ExtraIdFrom    string              `json:"extra_id_from"`
@@ -97,9 +107,9 @@ type ExchangeInfo struct {
 	UpdatedAt      string             `json:"updated_at"`
 	CurrencyFrom   string             `json:"currency_from"`
 	CurrencyTo     string             `json:"currency_to"`
-	AmountFrom     float64            `json:"amount_from,string"`
-	AmountTo       float64            `json:"amount_to,string"`
-	ExpectedAmount float64            `json:"expected_amount,string"`
+	AmountFrom     instantswap.Amount `json:"amount_from"`
+	AmountTo       instantswap.Amount `json:"amount_to"`
+	ExpectedAmount instantswap.Amount `json:"expected_amount"`
 	AddressFrom    string             `json:"address_from"`
 	AddressTo      string             `json:"address_to"`
 	ExtraIdFrom    string             `json:"extra_id_from"`
@@ -1,6 +1,7 @@
 package wizardswap
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/vibros68/instantswap/instantswap"
@@ -32,11 +33,12 @@ func (w *wizardswap) SetDebug(enable bool) {
 // New return a wizardswap client.
 func New(conf instantswap.ExchangeConfig) (*wizardswap, error) {
 	if conf.ApiKey == "" {
-		return nil, fmt.Errorf("%s:error: APIKEY is blank", LIBNAME)
+		return nil, instantswap.NewError(LIBNAME, instantswap.ErrAuth, "APIKEY is blank")
 	}
 	client := instantswap.NewClient(LIBNAME, &conf, func(r *http.Request, body string) error {
 		return nil
 	})
+	client.SetErrorFunc(parseError)
 	return &wizardswap{client: client, conf: &conf}, nil
 }
 
@@ -44,8 +46,19 @@ func (w *wizardswap) Name() string {
 	return LIBNAME
 }
 
-func (w *wizardswap) GetCurrencies() (currencies []instantswap.Currency, err error) {
-	r, err := w.client.Do(API_BASE, http.MethodGet, "currency", "", false)
+// Capabilities returns the features supported by wizardswap.
+func (w *wizardswap) Capabilities() instantswap.Capabilities {
+	return instantswap.Capabilities{
+		FloatingRate:     true,
+		CurrenciesToPair: true,
+		RefundAddress:    true,
+		RequiresApiKey:   true,
+		KYC:              instantswap.KYCNotRequired,
+	}
+}
+
+func (w *wizardswap) GetCurrencies(ctx context.Context) (currencies []instantswap.Currency, err error) {
+	r, err := w.client.Do(ctx, API_BASE, http.MethodGet, "currency", "", false)
 	if err != nil {
 		return nil, err
 	}
@@ -56,20 +69,14 @@ func (w *wizardswap) GetCurrencies() (currencies []instantswap.Currency, err err
 	}
 	currencies = make([]instantswap.Currency, len(sCurrs))
 	for i, curr := range sCurrs {
-		currencies[i] = instantswap.Currency{
-			Name:     curr.Name,
-			Symbol:   curr.Symbol,
-			IsFiat:   false,
-			IsStable: false,
-			Network:  "",
-		}
+		currencies[i] = instantswap.NewCurrency(curr.Name, curr.Symbol, "")
 	}
 	return currencies, nil
 }
 
-func (w *wizardswap) GetCurrenciesToPair(from string) (currencies []instantswap.Currency, err error) {
+func (w *wizardswap) GetCurrenciesToPair(ctx context.Context, from string) (currencies []instantswap.Currency, err error) {
 	from = strings.ToLower(from)
-	r, err := w.client.Do(API_BASE, http.MethodGet,
+	r, err := w.client.Do(ctx, API_BASE, http.MethodGet,
 		fmt.Sprintf("pairs/%s", from), "", false)
 	if err != nil {
 		return nil, err
@@ -83,26 +90,27 @@ func (w *wizardswap) GetCurrenciesToPair(from string) (currencies []instantswap.
 		if toCurr == from {
 			continue
 		}
-		currencies = append(currencies, instantswap.Currency{
-			Name:     "",
-			Symbol:   toCurr,
-			IsFiat:   false,
-			IsStable: false,
-			Network:  "",
-		})
+		currencies = append(currencies, instantswap.NewCurrency("", toCurr, ""))
 	}
 	return currencies, nil
 }
 
-func (w *wizardswap) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+func (w *wizardswap) GetExchangeRateInfo(ctx context.Context, vars instantswap.ExchangeRateRequest) (res instantswap.ExchangeRateInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, w.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
 	f := map[string]string{
 		"currency_from": strings.ToLower(vars.From),
 		"currency_to":   strings.ToLower(vars.To),
-		"amount_from":   fmt.Sprintf("%.8f", vars.Amount),
+		"amount_from":   vars.Amount.String(),
 		"api_key":       w.conf.ApiKey,
 	}
 	data, _ := json.Marshal(f)
-	r, err := w.client.Do(API_BASE, http.MethodPost, "estimate", string(data), false)
+	r, err := w.client.Do(instantswap.WithIdempotent(ctx), API_BASE, http.MethodPost, "estimate", string(data), false)
 	if err != nil {
 		return res, err
 	}
@@ -112,25 +120,33 @@ func (w *wizardswap) GetExchangeRateInfo(vars instantswap.ExchangeRateRequest) (
 		return res, err
 	}
 	res.EstimatedAmount = estimate.EstimatedAmount
-	res.ExchangeRate = estimate.EstimatedAmount / vars.Amount
+	res.ExchangeRate = estimate.EstimatedAmount.Div(vars.Amount, instantswap.RatePrecision)
+	res.RateType = rateType
 	return res, nil
 }
 
-func (w *wizardswap) QueryLimits(fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
-	return res, fmt.Errorf("not supported")
+func (w *wizardswap) QueryLimits(ctx context.Context, fromCurr, toCurr string) (res instantswap.QueryLimits, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "QueryLimits")
 }
 
-func (w *wizardswap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+func (w *wizardswap) CreateOrder(ctx context.Context, vars instantswap.CreateOrder) (res instantswap.CreateResultInfo, err error) {
+	if vars.Reverse() {
+		return res, instantswap.NotSupportedError(LIBNAME, "reverse quote")
+	}
+	rateType, err := instantswap.ChooseRateType(LIBNAME, w.Capabilities(), vars.RateType, instantswap.RateTypeFloat)
+	if err != nil {
+		return res, err
+	}
 	f := map[string]string{
 		"currency_from":  strings.ToLower(vars.FromCurrency),
 		"currency_to":    strings.ToLower(vars.ToCurrency),
-		"amount_from":    fmt.Sprintf("%.8f", vars.InvoicedAmount),
+		"amount_from":    vars.InvoicedAmount.String(),
 		"api_key":        w.conf.ApiKey,
 		"address_to":     vars.Destination,
 		"refund_address": vars.RefundAddress,
 	}
 	data, _ := json.Marshal(f)
-	r, err := w.client.Do(API_BASE, http.MethodPost, "exchange", string(data), false)
+	r, err := w.client.Do(ctx, API_BASE, http.MethodPost, "exchange", string(data), false)
 	if err != nil {
 		return res, err
 	}
@@ -140,9 +156,9 @@ func (w *wizardswap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 		return res, err
 	}
 	res = instantswap.CreateResultInfo{
-		ChargedFee:     0,
+		ChargedFee:     instantswap.Amount{},
 		Destination:    order.AddressTo,
-		ExchangeRate:   order.AmountFrom / order.AmountTo,
+		ExchangeRate:   order.AmountFrom.Div(order.AmountTo, instantswap.RatePrecision),
 		FromCurrency:   order.CurrencyFrom,
 		InvoicedAmount: order.AmountFrom,
 		OrderedAmount:  order.AmountTo,
@@ -152,19 +168,26 @@ func (w *wizardswap) CreateOrder(vars instantswap.CreateOrder) (res instantswap.
 		Expires:        0,
 		ExtraID:        "",
 		PayoutExtraID:  "",
+		RateType:       rateType,
 	}
 	return res, nil
 }
 
-func (w *wizardswap) UpdateOrder(vars interface{}) (res instantswap.UpdateOrderResultInfo, err error) {
-	return
+func (w *wizardswap) UpdateOrder(ctx context.Context, vars instantswap.UpdateOrderInfo) (res instantswap.UpdateOrderResultInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "UpdateOrder")
+}
+func (w *wizardswap) RefundInfo(ctx context.Context, orderID string) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "RefundInfo")
 }
-func (w *wizardswap) CancelOrder(orderID string) (res string, err error) {
-	return
+func (w *wizardswap) SubmitRefund(ctx context.Context, vars instantswap.RefundRequest) (res instantswap.RefundInfo, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "SubmitRefund")
+}
+func (w *wizardswap) CancelOrder(ctx context.Context, orderID string) (res string, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "CancelOrder")
 }
 
-func (w *wizardswap) OrderInfo(req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
-	r, err := w.client.Do(API_BASE, http.MethodGet, fmt.Sprintf("exchange/%s", req.OrderId), "", false)
+func (w *wizardswap) OrderInfo(ctx context.Context, req instantswap.TrackingRequest) (res instantswap.OrderInfoResult, err error) {
+	r, err := w.client.Do(ctx, API_BASE, http.MethodGet, fmt.Sprintf("exchange/%s", req.OrderId), "", false)
 	if err != nil {
 		return res, err
 	}
@@ -185,14 +208,23 @@ func (w *wizardswap) OrderInfo(req instantswap.TrackingRequest) (res instantswap
 	return res, nil
 }
 
-func (w *wizardswap) EstimateAmount(vars interface{}) (res instantswap.EstimateAmount, err error) {
-	return
+func (w *wizardswap) EstimateAmount(ctx context.Context, vars interface{}) (res instantswap.EstimateAmount, err error) {
+	return res, instantswap.NotSupportedError(LIBNAME, "EstimateAmount")
 }
 
 func parseResponseData(data []byte, obj interface{}) error {
 	return json.Unmarshal(data, obj)
 }
 
+// parseError reads the {"error"} payload of a failed response.
+func parseError(statusCode int, body []byte) (error, string) {
+	var e Error
+	if json.Unmarshal(body, &e) != nil {
+		return nil, ""
+	}
+	return nil, e.Error
+}
+
 // waiting, confirming, exchanging, sending, finished, failed, refunded, verifying
 func parseStatus(status string) instantswap.Status {
 	switch status {
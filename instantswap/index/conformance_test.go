@@ -0,0 +1,480 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vibros68/instantswap/instantswap"
+)
+
+// fixture is the recorded api of an exchange and the cases run against it.
+// Fixtures are stored in testdata/<exchange name>.json.
+type fixture struct {
+	Config instantswap.ExchangeConfig `json:"config"`
+	Routes []route                    `json:"routes"`
+	Cases  []testCase                 `json:"cases"`
+}
+
+// route is a recorded response. A request matches the route when the method
+// and the path are equal, all the query values are present and the body
+// contains all the BodyContains strings. The first matched route is served.
+type route struct {
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	Query        map[string]string `json:"query"`
+	BodyContains []string          `json:"bodyContains"`
+	Status       int               `json:"status"`
+	Body         json.RawMessage   `json:"body"`
+	Text         string            `json:"text"`
+}
+
+func (r *route) match(req *http.Request, body string) bool {
+	if r.Method != req.Method || r.Path != req.URL.Path {
+		return false
+	}
+	query := req.URL.Query()
+	for k, v := range r.Query {
+		if query.Get(k) != v {
+			return false
+		}
+	}
+	for _, s := range r.BodyContains {
+		if !strings.Contains(body, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// testCase calls an IDExchange method with Request. When Error is set the
+// call must fail with an *instantswap.ExchangeError containing it, and of
+// Kind when it is set, otherwise the result must match Expect. Expect holds
+// the expected struct fields of the result, for a list result it holds "len"
+// and "contains".
+type testCase struct {
+	Name    string                 `json:"name"`
+	Call    string                 `json:"call"`
+	Request json.RawMessage        `json:"request"`
+	Expect  map[string]interface{} `json:"expect"`
+	Error   string                 `json:"error"`
+	Kind    string                 `json:"kind"`
+}
+
+// errorKinds are the error kinds a fixture case can expect.
+var errorKinds = map[string]error{
+	"PairNotSupported":    instantswap.ErrPairNotSupported,
+	"AmountBelowMin":      instantswap.ErrAmountBelowMin,
+	"AmountAboveMax":      instantswap.ErrAmountAboveMax,
+	"InvalidAddress":      instantswap.ErrInvalidAddress,
+	"OrderNotFound":       instantswap.ErrOrderNotFound,
+	"Auth":                instantswap.ErrAuth,
+	"RateExpired":         instantswap.ErrRateExpired,
+	"ExchangeUnavailable": instantswap.ErrExchangeUnavailable,
+	"NotSupported":        instantswap.ErrNotSupported,
+	"RefundNotEligible":   instantswap.ErrRefundNotEligible,
+	"TooManyRequests":     instantswap.TooManyRequestsError,
+}
+
+// requiredCalls are the methods every fixture must cover with a succeeded case.
+var requiredCalls = []string{"GetCurrencies", "GetExchangeRateInfo", "CreateOrder", "OrderInfo"}
+
+func TestConformance(t *testing.T) {
+	for _, name := range instantswap.Exchanges() {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			f, err := loadFixture(name)
+			if err != nil {
+				t.Fatalf("load fixture: %v", err)
+			}
+			checkCoverage(t, f)
+			server := newFixtureServer(t, f.Routes)
+			defer server.Close()
+			conf := f.Config
+			// the ApiBase of a fixture is a path prefix on the server.
+			conf.ApiBase = server.URL + "/" + f.Config.ApiBase
+			conf.HttpClient = newRewriteClient(server)
+			conf.Retry.MinBackoff = time.Millisecond
+			conf.RateLimit.Rate = -1
+			exchange, err := instantswap.NewExchange(name, conf)
+			if err != nil {
+				t.Fatalf("new exchange: %v", err)
+			}
+			if exchange.Name() != name {
+				t.Errorf("Name() = %s, expected: %s", exchange.Name(), name)
+			}
+			for _, c := range f.Cases {
+				c := c
+				t.Run(c.Name, func(t *testing.T) {
+					runCase(t, exchange, c)
+				})
+			}
+			t.Run("capabilities", func(t *testing.T) {
+				checkCapabilities(t, exchange)
+			})
+		})
+	}
+}
+
+func loadFixture(name string) (*fixture, error) {
+	data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var f fixture
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func checkCoverage(t *testing.T, f *fixture) {
+	covered := make(map[string]bool)
+	var errorCase bool
+	for _, c := range f.Cases {
+		if c.Error != "" {
+			errorCase = true
+		} else {
+			covered[c.Call] = true
+		}
+	}
+	for _, call := range requiredCalls {
+		if !covered[call] {
+			t.Errorf("fixture has no case for %s", call)
+		}
+	}
+	if !errorCase {
+		t.Errorf("fixture has no error case")
+	}
+}
+
+func newFixtureServer(t *testing.T, routes []route) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		for _, rt := range routes {
+			if !rt.match(r, string(body)) {
+				continue
+			}
+			status := rt.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			w.WriteHeader(status)
+			if rt.Text != "" {
+				_, _ = w.Write([]byte(rt.Text))
+			} else {
+				_, _ = w.Write(rt.Body)
+			}
+			return
+		}
+		t.Logf("no route for %s %s %s", r.Method, r.URL, body)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+// rewriteTransport sends every request to the fixture server, it catches the
+// requests sent to absolute urls outside of the exchange api.
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (rt *rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = rt.target.Scheme
+	r.URL.Host = rt.target.Host
+	r.Host = rt.target.Host
+	return rt.next.RoundTrip(r)
+}
+
+// checkCapabilities checks that the methods which are not supported by the
+// exchange return an ErrNotSupported error.
+func checkCapabilities(t *testing.T, exchange instantswap.IDExchange) {
+	capable, ok := exchange.(instantswap.Capable)
+	if !ok {
+		t.Fatalf("%T does not declare its capabilities", exchange)
+	}
+	caps := capable.Capabilities()
+	if !caps.FixedRate && !caps.FloatingRate {
+		t.Error("neither fixed nor floating rate is supported")
+	}
+	ctx := context.Background()
+	unsupported := map[string]func() error{}
+	if !caps.Limits {
+		unsupported["QueryLimits"] = func() error {
+			_, err := exchange.QueryLimits(ctx, "btc", "ltc")
+			return err
+		}
+	}
+	if !caps.CurrenciesToPair {
+		unsupported["GetCurrenciesToPair"] = func() error {
+			_, err := exchange.GetCurrenciesToPair(ctx, "btc")
+			return err
+		}
+	}
+	if !caps.Cancel {
+		unsupported["CancelOrder"] = func() error {
+			_, err := exchange.CancelOrder(ctx, "order-id")
+			return err
+		}
+	}
+	if !caps.Update {
+		unsupported["UpdateOrder"] = func() error {
+			_, err := exchange.UpdateOrder(ctx, instantswap.UpdateOrderInfo{UUID: "order-id", RefundAddress: "refund-address"})
+			return err
+		}
+	}
+	if !caps.Refund {
+		unsupported["RefundInfo"] = func() error {
+			_, err := exchange.RefundInfo(ctx, "order-id")
+			return err
+		}
+		unsupported["SubmitRefund"] = func() error {
+			_, err := exchange.SubmitRefund(ctx, instantswap.RefundRequest{UUID: "order-id", Address: "refund-address"})
+			return err
+		}
+	}
+	for rateType, supported := range map[instantswap.RateType]bool{
+		instantswap.RateTypeFixed: caps.FixedRate,
+		instantswap.RateTypeFloat: caps.FloatingRate,
+	} {
+		if supported {
+			continue
+		}
+		rateType := rateType
+		unsupported["GetExchangeRateInfo "+string(rateType)] = func() error {
+			_, err := exchange.GetExchangeRateInfo(ctx, instantswap.ExchangeRateRequest{
+				From: "BTC", To: "LTC", Amount: instantswap.MustParseAmount("1"), RateType: rateType,
+			})
+			return err
+		}
+		unsupported["CreateOrder "+string(rateType)] = func() error {
+			_, err := exchange.CreateOrder(ctx, instantswap.CreateOrder{
+				FromCurrency: "BTC", ToCurrency: "LTC", InvoicedAmount: instantswap.MustParseAmount("1"), RateType: rateType,
+			})
+			return err
+		}
+	}
+	if !caps.ReverseQuote {
+		unsupported["GetExchangeRateInfo reverse"] = func() error {
+			_, err := exchange.GetExchangeRateInfo(ctx, instantswap.ExchangeRateRequest{
+				From: "BTC", To: "LTC", ReceiveAmount: instantswap.MustParseAmount("1"),
+			})
+			return err
+		}
+		unsupported["CreateOrder reverse"] = func() error {
+			_, err := exchange.CreateOrder(ctx, instantswap.CreateOrder{
+				FromCurrency: "BTC", ToCurrency: "LTC", OrderedAmount: instantswap.MustParseAmount("1"),
+			})
+			return err
+		}
+	}
+	for method, fn := range unsupported {
+		if err := fn(); !errors.Is(err, instantswap.ErrNotSupported) {
+			t.Errorf("%s error = %v, expected kind: %v", method, err, instantswap.ErrNotSupported)
+		}
+	}
+	if !caps.RequiresApiKey {
+		if _, err := instantswap.NewExchange(exchange.Name(), instantswap.ExchangeConfig{}); err != nil {
+			t.Errorf("new exchange without api key: %v", err)
+		}
+	}
+}
+
+func newRewriteClient(server *httptest.Server) *http.Client {
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: &rewriteTransport{target: target, next: server.Client().Transport}}
+}
+
+func runCase(t *testing.T, exchange instantswap.IDExchange, c testCase) {
+	res, err := call(context.Background(), exchange, c)
+	if c.Error != "" {
+		if err == nil {
+			t.Fatalf("expected error containing %q, got nil", c.Error)
+		}
+		if !strings.Contains(strings.ToLower(err.Error()), strings.ToLower(c.Error)) {
+			t.Fatalf("expected error containing %q, got: %v", c.Error, err)
+		}
+		var exchangeErr *instantswap.ExchangeError
+		if !errors.As(err, &exchangeErr) {
+			t.Fatalf("expected an ExchangeError, got %T: %v", err, err)
+		}
+		if exchangeErr.Exchange != exchange.Name() {
+			t.Errorf("error exchange = %s, expected: %s", exchangeErr.Exchange, exchange.Name())
+		}
+		if c.Kind != "" {
+			kind, ok := errorKinds[c.Kind]
+			if !ok {
+				t.Fatalf("unknown error kind %q", c.Kind)
+			}
+			if !errors.Is(err, kind) {
+				t.Fatalf("expected error of kind %s, got: %v (kind: %v)", c.Kind, err, exchangeErr.Kind)
+			}
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = matchResult(reflect.ValueOf(res), c.Expect); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func call(ctx context.Context, exchange instantswap.IDExchange, c testCase) (interface{}, error) {
+	decode := func(v interface{}) error {
+		if len(c.Request) == 0 {
+			return nil
+		}
+		return json.Unmarshal(c.Request, v)
+	}
+	switch c.Call {
+	case "GetCurrencies":
+		return exchange.GetCurrencies(ctx)
+	case "GetCurrenciesToPair":
+		var from string
+		if err := decode(&from); err != nil {
+			return nil, err
+		}
+		return exchange.GetCurrenciesToPair(ctx, from)
+	case "QueryLimits":
+		var pair [2]string
+		if err := decode(&pair); err != nil {
+			return nil, err
+		}
+		return exchange.QueryLimits(ctx, pair[0], pair[1])
+	case "GetExchangeRateInfo":
+		var req instantswap.ExchangeRateRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return exchange.GetExchangeRateInfo(ctx, req)
+	case "CreateOrder":
+		var req instantswap.CreateOrder
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return exchange.CreateOrder(ctx, req)
+	case "OrderInfo":
+		var req instantswap.TrackingRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return exchange.OrderInfo(ctx, req)
+	case "UpdateOrder":
+		var req instantswap.UpdateOrderInfo
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return exchange.UpdateOrder(ctx, req)
+	case "RefundInfo":
+		var orderID string
+		if err := decode(&orderID); err != nil {
+			return nil, err
+		}
+		return exchange.RefundInfo(ctx, orderID)
+	case "SubmitRefund":
+		var req instantswap.RefundRequest
+		if err := decode(&req); err != nil {
+			return nil, err
+		}
+		return exchange.SubmitRefund(ctx, req)
+	}
+	return nil, fmt.Errorf("unknown call %q", c.Call)
+}
+
+func matchResult(v reflect.Value, expect map[string]interface{}) error {
+	if v.Kind() == reflect.Slice {
+		return matchList(v, expect)
+	}
+	return matchFields(v, expect)
+}
+
+func matchList(v reflect.Value, expect map[string]interface{}) error {
+	for key, want := range expect {
+		switch key {
+		case "len":
+			if float64(v.Len()) != want.(float64) {
+				return fmt.Errorf("len = %d, expected: %v", v.Len(), want)
+			}
+		case "contains":
+			for _, item := range want.([]interface{}) {
+				fields := item.(map[string]interface{})
+				var found bool
+				for i := 0; i < v.Len() && !found; i++ {
+					found = matchFields(v.Index(i), fields) == nil
+				}
+				if !found {
+					return fmt.Errorf("list does not contain %v", fields)
+				}
+			}
+		case "excludes":
+			for _, item := range want.([]interface{}) {
+				fields := item.(map[string]interface{})
+				for i := 0; i < v.Len(); i++ {
+					if matchFields(v.Index(i), fields) == nil {
+						return fmt.Errorf("list contains %v", fields)
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("unknown list expectation %q", key)
+		}
+	}
+	return nil
+}
+
+func matchFields(v reflect.Value, expect map[string]interface{}) error {
+	for name, want := range expect {
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("unknown field %s", name)
+		}
+		switch want := want.(type) {
+		case float64:
+			got, ok := toFloat(field)
+			if !ok {
+				return fmt.Errorf("%s is not a number", name)
+			}
+			if math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+				return fmt.Errorf("%s = %v, expected: %v", name, got, want)
+			}
+		case string:
+			if got := fmt.Sprint(field.Interface()); got != want {
+				return fmt.Errorf("%s = %q, expected: %q", name, got, want)
+			}
+		case bool:
+			if field.Kind() != reflect.Bool || field.Bool() != want {
+				return fmt.Errorf("%s = %v, expected: %v", name, field.Interface(), want)
+			}
+		default:
+			return fmt.Errorf("unsupported expectation for %s: %v", name, want)
+		}
+	}
+	return nil
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	if amount, ok := v.Interface().(instantswap.Amount); ok {
+		return amount.Float64(), true
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	}
+	return 0, false
+}
@@ -1,29 +1,38 @@
 package instantswap
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 )
 
 const TX_HASH_INTERNAL_TRANSFER = "Internal transfer"
 
+// IDExchange is implemented by every instant exchange. All methods take a
+// context which bounds the HTTP requests made to the exchange api.
 type IDExchange interface {
 	Name() string
 	// GetCurrencies returns current active currencies in the exchange
-	GetCurrencies() (currencies []Currency, err error)
+	GetCurrencies(ctx context.Context) (currencies []Currency, err error)
 	// GetCurrenciesToPair return a list of available currencies for a specific currency
-	GetCurrenciesToPair(from string) (currencies []Currency, err error)
-	QueryLimits(fromCurr, toCurr string) (res QueryLimits, err error)
-	CreateOrder(vars CreateOrder) (res CreateResultInfo, err error)
+	GetCurrenciesToPair(ctx context.Context, from string) (currencies []Currency, err error)
+	QueryLimits(ctx context.Context, fromCurr, toCurr string) (res QueryLimits, err error)
+	CreateOrder(ctx context.Context, vars CreateOrder) (res CreateResultInfo, err error)
 	//UpdateOrder accepts orderID value and more if needed per lib
-	UpdateOrder(vars interface{}) (res UpdateOrderResultInfo, err error)
-	CancelOrder(orderID string) (res string, err error)
+	UpdateOrder(ctx context.Context, vars UpdateOrderInfo) (res UpdateOrderResultInfo, err error)
+	CancelOrder(ctx context.Context, orderID string) (res string, err error)
 
 	//OrderInfo accepts orderID value and more if needed per lib
-	OrderInfo(req TrackingRequest) (res OrderInfoResult, err error)
+	OrderInfo(ctx context.Context, req TrackingRequest) (res OrderInfoResult, err error)
+	// RefundInfo returns whether the order can be refunded and the state of
+	// its refund.
+	RefundInfo(ctx context.Context, orderID string) (res RefundInfo, err error)
+	// SubmitRefund gives the refund address of an eligible order.
+	SubmitRefund(ctx context.Context, vars RefundRequest) (res RefundInfo, err error)
 
-	GetExchangeRateInfo(vars ExchangeRateRequest) (res ExchangeRateInfo, err error)
+	GetExchangeRateInfo(ctx context.Context, vars ExchangeRateRequest) (res ExchangeRateInfo, err error)
 }
 
 type TrackingRequest struct {
@@ -36,7 +45,18 @@ type ExchangeRateRequest struct {
 	FromNetwork string
 	To          string
 	ToNetwork   string
-	Amount      float64
+	Amount      Amount
+	// ReceiveAmount is the amount to receive of a reverse quote, which
+	// returns the amount to send as DepositAmount. Amount is zero then.
+	ReceiveAmount Amount
+	// RateType is the requested rate mode, the exchange default when it is
+	// empty.
+	RateType RateType
+}
+
+// Reverse tells whether the request is a reverse quote.
+func (r ExchangeRateRequest) Reverse() bool {
+	return r.Amount.IsZero() && r.ReceiveAmount.Sign() > 0
 }
 
 var driv = driver{
@@ -71,6 +91,17 @@ func (d *driver) newExchange(name string, config ExchangeConfig) (IDExchange, er
 	return newExplorer(config)
 }
 
+func (d *driver) exchanges() []string {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	names := make([]string, 0, len(d.stack))
+	for name := range d.stack {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func RegisterExchange(symbol string, newExchange NewExchangeFunc) {
 	driv.registerExchange(symbol, newExchange)
 }
@@ -78,3 +109,8 @@ func RegisterExchange(symbol string, newExchange NewExchangeFunc) {
 func NewExchange(symbol string, config ExchangeConfig) (IDExchange, error) {
 	return driv.newExchange(symbol, config)
 }
+
+// Exchanges returns the sorted names of the registered exchanges.
+func Exchanges() []string {
+	return driv.exchanges()
+}
@@ -1,5 +1,10 @@
 package instantswap
 
+import (
+	"net/http"
+	"time"
+)
+
 type ExchangeConfig struct {
 	Debug     bool
 	ApiKey    string
@@ -7,6 +12,22 @@ type ExchangeConfig struct {
 	// AffiliateId is used to earn refer coin from transaction
 	AffiliateId string
 	UserId      string
+	// ApiBase overrides the default api endpoint of the exchange. It is
+	// useful to point the exchange to a staging or a local test server. The
+	// other endpoints of an exchange, like the v2 api of changenow, are kept.
+	ApiBase string
+	// HttpClient is the client used to send requests to the exchange api.
+	// When it is nil a new client using Transport is created.
+	HttpClient *http.Client
+	// Transport is the round tripper of the default http client, it is
+	// ignored when HttpClient is set.
+	Transport http.RoundTripper
+	// Retry is the retry policy of the failed requests, the zero fields are
+	// set from DefaultRetryPolicy.
+	Retry RetryPolicy
+	// RateLimit limits the requests sent to the exchange with the api key,
+	// the exchange default is used when it is not set.
+	RateLimit RateLimit
 }
 
 //DECENTRALIZED EXCHANGES
@@ -21,37 +42,40 @@ type ActiveCurrs struct {
 	Currencies []ActiveCurr
 }
 type ActiveCurr struct {
-	ChargedFee       float64 `json:"charged_fee,string,omitempty"`
-	Code             string  `json:"code,omitempty"`
-	ConfirmationTime int     `json:"confirmation_time,omitempty"`
-	CreatedAt        string  `json:"created_at,omitempty"`
-	CurrencyType     string  `json:"currency_type,omitempty"`
-	Default          bool    `json:"default,omitempty"`
-	DisplayPrecision int     `json:"display_precision,omitempty"`
-	Exchange         bool    `json:"exchange,omitempty"`
-	Name             string  `json:"name,omitempty"`
-	Precision        int     `json:"precision,omitempty"`
-	Send             bool    `json:"send,omitempty"`
-	UpdatedAt        string  `json:"updated_at,omitempty"`
-	Website          string  `json:"website,omitempty"`
+	ChargedFee       Amount `json:"charged_fee"`
+	Code             string `json:"code,omitempty"`
+	ConfirmationTime int    `json:"confirmation_time,omitempty"`
+	CreatedAt        string `json:"created_at,omitempty"`
+	CurrencyType     string `json:"currency_type,omitempty"`
+	Default          bool   `json:"default,omitempty"`
+	DisplayPrecision int    `json:"display_precision,omitempty"`
+	Exchange         bool   `json:"exchange,omitempty"`
+	Name             string `json:"name,omitempty"`
+	Precision        int    `json:"precision,omitempty"`
+	Send             bool   `json:"send,omitempty"`
+	UpdatedAt        string `json:"updated_at,omitempty"`
+	Website          string `json:"website,omitempty"`
 }
 
 type QueryLimits struct {
-	Max float64 `json:"max,string"`
-	Min float64 `json:"min,string"`
+	Max Amount `json:"max"`
+	Min Amount `json:"min"`
 }
 
 // CREATE
 type CreateOrder struct {
-	RefundAddress  string  `json:"refund_address"`
-	Destination    string  `json:"destination"`
-	FromCurrency   string  `json:"from_currency"`
-	OrderedAmount  float64 `json:"ordered_amount,string"`  //amount in "to" currency. you want to be received
-	InvoicedAmount float64 `json:"invoiced_amount,string"` //amount in "from" currency. you will send it
-	ToCurrency     string  `json:"to_currency"`
-	FromNetwork    string  `json:"from_network"`
-	ToNetwork      string  `json:"to_network"`
-	Provider       string  `json:"Provider"` // used for some intermediate exchange
+	RefundAddress  string `json:"refund_address"`
+	Destination    string `json:"destination"`
+	FromCurrency   string `json:"from_currency"`
+	OrderedAmount  Amount `json:"ordered_amount"`  //amount in "to" currency. you want to be received
+	InvoicedAmount Amount `json:"invoiced_amount"` //amount in "from" currency. you will send it
+	ToCurrency     string `json:"to_currency"`
+	FromNetwork    string `json:"from_network"`
+	ToNetwork      string `json:"to_network"`
+	Provider       string `json:"Provider"` // used for some intermediate exchange
+	// RateType is the requested rate mode, the exchange default when it is
+	// empty.
+	RateType RateType `json:"rate_type,omitempty"`
 
 	//changenow.io
 	ExtraID string `json:"extraId,omitempty"` //changenow.io requirement
@@ -64,20 +88,29 @@ type CreateOrder struct {
 	//changelly
 	RefundExtraID string `json:"refundExtraId,omitempty"`
 }
+
+// Reverse tells whether the order is a reverse order, for OrderedAmount to
+// be received, the amount to send is set by the exchange.
+func (c CreateOrder) Reverse() bool {
+	return c.InvoicedAmount.IsZero() && c.OrderedAmount.Sign() > 0
+}
+
 type CreateResultInfo struct {
-	ChargedFee     float64 `json:"charged_fee,string,omitempty"`
-	Destination    string  `json:"destination,omitempty"`
-	ExchangeRate   float64 `json:"exchange_rate,string,omitempty"`
-	FromCurrency   string  `json:"from_currency,omitempty"`
-	InvoicedAmount float64 `json:"invoiced_amount,string,omitempty"`
-	OrderedAmount  float64 `json:"ordered_amount,string,omitempty"`
-	ToCurrency     string  `json:"to_currency,omitempty"`
-	UUID           string  `json:"uuid,omitempty"`
+	ChargedFee     Amount `json:"charged_fee"`
+	Destination    string `json:"destination,omitempty"`
+	ExchangeRate   Amount `json:"exchange_rate"`
+	FromCurrency   string `json:"from_currency,omitempty"`
+	InvoicedAmount Amount `json:"invoiced_amount"`
+	OrderedAmount  Amount `json:"ordered_amount"`
+	ToCurrency     string `json:"to_currency,omitempty"`
+	UUID           string `json:"uuid,omitempty"`
 
 	DepositAddress string
 	Expires        int    `json:"expires,omitempty"`
 	ExtraID        string `json:"extraId,omitempty"` //changenow.io requirement //changelly payinExtraId value
 	PayoutExtraID  string `json:"payoutExtraId,omitempty"`
+	// RateType is the rate mode of the order.
+	RateType RateType `json:"rate_type,omitempty"`
 }
 type CreateResult struct {
 	Expires int              `json:"expires"`
@@ -85,24 +118,29 @@ type CreateResult struct {
 }
 
 // UPDATE
+// UpdateOrderInfo changes the addresses of the order UUID, the empty fields
+// are kept. The exchanges return an error of kind ErrNotSupported for the
+// fields they can not change.
 type UpdateOrderInfo struct {
-	Destination   string  `json:"destination"`
-	OrderedAmount float64 `json:"ordered_amount,string"`
-	RefundAddress string  `json:"refund_address"`
-	UUID          string  `json:"uuid"`
+	UUID          string `json:"uuid"`
+	Destination   string `json:"destination,omitempty"`
+	ExtraID       string `json:"extra_id,omitempty"`
+	RefundAddress string `json:"refund_address,omitempty"`
+	RefundExtraID string `json:"refund_extra_id,omitempty"`
 }
 type UpdateOrder struct {
 	Order UpdateOrderInfo `json:"order"`
 }
 type UpdateOrderResultInfo struct {
-	ChargedFee     float64 `json:"charged_fee,string"`
-	Destination    string  `json:"destination"`
-	ExchangeRate   float64 `json:"exchange_rate,string"`
-	FromCurrency   string  `json:"from_currency"`
-	InvoicedAmount float64 `json:"invoiced_amount,string"`
-	OrderedAmount  float64 `json:"ordered_amount,string"`
-	ToCurrency     string  `json:"to_currency"`
-	UUID           string  `json:"uuid"`
+	ChargedFee     Amount `json:"charged_fee"`
+	Destination    string `json:"destination"`
+	ExchangeRate   Amount `json:"exchange_rate"`
+	FromCurrency   string `json:"from_currency"`
+	InvoicedAmount Amount `json:"invoiced_amount"`
+	OrderedAmount  Amount `json:"ordered_amount"`
+	ToCurrency     string `json:"to_currency"`
+	UUID           string `json:"uuid"`
+	RefundAddress  string `json:"refund_address,omitempty"`
 }
 type UpdateOrderResult struct {
 	Expires int                   `json:"expires"`
@@ -131,8 +169,8 @@ type AcceptOrderResult struct {
 type OrderInfoResult struct {
 	Expires        int
 	LastUpdate     string // should be datetime object
-	OrderedAmount  float64
-	ReceiveAmount  float64
+	OrderedAmount  Amount
+	ReceiveAmount  Amount
 	TxID           string
 	DepositTx      string
 	RefundTx       string
@@ -146,10 +184,10 @@ type UUID struct {
 }
 
 type EstimateAmount struct {
-	EstimatedAmount          float64     `json:"estimatedAmount"` //destinationCurrency
-	DepositAmount            float64     `json:"depositAmount,omitempty"`
-	NetworkFee               float64     `json:"networkFee,omitempty"`
-	ServiceCommission        float64     `json:"serviceCommission,omitempty"`
+	EstimatedAmount          Amount      `json:"estimatedAmount"` //destinationCurrency
+	DepositAmount            Amount      `json:"depositAmount"`
+	NetworkFee               Amount      `json:"networkFee"`
+	ServiceCommission        Amount      `json:"serviceCommission"`
 	TransactionSpeedForecast string      `json:"transactionSpeedForecast,omitempty"`
 	WarningMessage           interface{} `json:"warningMessage,omitempty"`
 	FromCurrency             string      `json:"fromCurrency,omitempty"`
@@ -159,15 +197,24 @@ type EstimateAmount struct {
 
 type ExchangeRateInfo struct {
 	// Min is the smallest amount will be accepted by the exchange
-	Min float64
+	Min Amount
 	// Max is the maximum amount will be accepted by the exchange
 	// return Max = 0 means: there are not limited amount
-	Max             float64
-	ExchangeRate    float64
-	EstimatedAmount float64
-	MaxOrder        float64
+	Max             Amount
+	ExchangeRate    Amount
+	EstimatedAmount Amount
+	MaxOrder        Amount
 	Signature       string
 	Provider        string // used for some intermediate exchange
+	// RateType is the mode of the rate, empty when the exchange does not
+	// tell it.
+	RateType RateType
+	// ValidUntil is the expiry of the rate identified by Signature, zero
+	// when the exchange does not tell it.
+	ValidUntil time.Time
+	// DepositAmount is the amount to send of a reverse quote, EstimatedAmount
+	// is then the requested ReceiveAmount.
+	DepositAmount Amount
 }
 
 type Status int
@@ -187,6 +234,15 @@ const (
 	OrderStatusFailed
 )
 
+// IsTerminal tells whether the order will not change anymore.
+func (s Status) IsTerminal() bool {
+	switch s {
+	case OrderStatusCompleted, OrderStatusRefunded, OrderStatusCanceled, OrderStatusExpired, OrderStatusFailed:
+		return true
+	}
+	return false
+}
+
 func (s Status) String() string {
 	switch s {
 	case OrderStatusCompleted:
@@ -216,10 +272,14 @@ func (s Status) String() string {
 	}
 }
 
+// Currency is a currency listed by an exchange. Symbol is the canonical upper
+// case ticker and Network the canonical network ID, see CurrencyID.
 type Currency struct {
 	Name     string
 	Symbol   string
 	IsFiat   bool
 	IsStable bool
 	Network  string
+	// Precision is the number of decimals of the currency amounts.
+	Precision int
 }
@@ -0,0 +1,313 @@
+package instantswap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PairMatrix is a snapshot of the pairs supported by several exchanges, it is
+// built from their currency and pair lists without requesting any rate. The
+// currencies are identified by their CurrencyID, like BTC or USDT/tron.
+type PairMatrix struct {
+	Time      time.Time                 `json:"time"`
+	Exchanges map[string]*ExchangePairs `json:"exchanges"`
+}
+
+// ExchangePairs are the currencies and pairs of an exchange.
+type ExchangePairs struct {
+	// Currencies are the sorted IDs of the currencies of the exchange.
+	Currencies []string `json:"currencies"`
+	// Pairs are the sorted IDs of the destinations of every source currency.
+	// It is nil for the exchanges which do not list their pairs, any two of
+	// their currencies are assumed to be a pair.
+	Pairs map[string][]string `json:"pairs,omitempty"`
+	// PairErrors are the errors of the failed pair lists by source currency
+	// ID, the pairs of these currencies may be incomplete.
+	PairErrors map[string]string `json:"pair_errors,omitempty"`
+	// Error is the error of a failed currency list, the currencies and pairs
+	// of the exchange may be incomplete.
+	Error string `json:"error,omitempty"`
+}
+
+// BuildPairMatrix lists the currencies and pairs of the exchanges
+// concurrently. The exchanges implementing GetCurrenciesToPair are asked for
+// the pairs of every currency, use CachedExchange to reuse the lists.
+func BuildPairMatrix(ctx context.Context, exchanges ...IDExchange) *PairMatrix {
+	m := &PairMatrix{
+		Time:      time.Now().UTC(),
+		Exchanges: make(map[string]*ExchangePairs, len(exchanges)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, exchange := range exchanges {
+		wg.Add(1)
+		go func(exchange IDExchange) {
+			defer wg.Done()
+			pairs := exchangePairs(ctx, exchange)
+			mu.Lock()
+			m.Exchanges[exchange.Name()] = pairs
+			mu.Unlock()
+		}(exchange)
+	}
+	wg.Wait()
+	return m
+}
+
+func exchangePairs(ctx context.Context, exchange IDExchange) *ExchangePairs {
+	res := &ExchangePairs{}
+	currencies, err := exchange.GetCurrencies(ctx)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	// ids are the currency ids by symbol, the pairs are listed by symbol.
+	ids := make(map[string][]string)
+	for _, currency := range currencies {
+		symbol := CanonicalSymbol(currency.Symbol)
+		ids[symbol] = append(ids[symbol], CurrencyID(currency.Symbol, currency.Network))
+	}
+	for _, currencyIDs := range ids {
+		res.Currencies = append(res.Currencies, currencyIDs...)
+	}
+	res.Currencies = sortedUnique(res.Currencies)
+	if !ExchangeCapabilities(exchange).CurrenciesToPair {
+		return res
+	}
+	symbols := make([]string, 0, len(ids))
+	for symbol := range ids {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	res.Pairs = make(map[string][]string)
+	for _, symbol := range symbols {
+		targets, err := exchange.GetCurrenciesToPair(ctx, symbol)
+		if err != nil {
+			if ctx.Err() != nil {
+				res.Error = err.Error()
+				return res
+			}
+			if res.PairErrors == nil {
+				res.PairErrors = make(map[string]string)
+			}
+			for _, from := range ids[symbol] {
+				res.PairErrors[from] = err.Error()
+			}
+			continue
+		}
+		for _, from := range ids[symbol] {
+			var to []string
+			for _, target := range targets {
+				if id := CurrencyID(target.Symbol, target.Network); id != from {
+					to = append(to, id)
+				}
+			}
+			if len(to) > 0 {
+				res.Pairs[from] = sortedUnique(to)
+			}
+		}
+	}
+	return res
+}
+
+func sortedUnique(list []string) []string {
+	sort.Strings(list)
+	res := list[:0]
+	for i, s := range list {
+		if i == 0 || s != list[i-1] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// PairMatrix lists the currencies and pairs of the aggregated exchanges.
+func (a *Aggregator) PairMatrix(ctx context.Context) *PairMatrix {
+	exchanges := make([]IDExchange, 0, len(a.names))
+	for _, name := range a.names {
+		exchanges = append(exchanges, a.exchanges[name])
+	}
+	return BuildPairMatrix(ctx, exchanges...)
+}
+
+// ReadPairMatrix decodes a matrix written by WriteJSON.
+func ReadPairMatrix(r io.Reader) (*PairMatrix, error) {
+	var m PairMatrix
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Exchanges == nil {
+		m.Exchanges = make(map[string]*ExchangePairs)
+	}
+	return &m, nil
+}
+
+// WriteJSON writes the matrix as indented JSON.
+func (m *PairMatrix) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Supports tells whether the exchange can swap from on fromNetwork to to on
+// toNetwork. An empty network matches any network of the currency.
+func (m *PairMatrix) Supports(exchange, from, fromNetwork, to, toNetwork string) bool {
+	pairs, ok := m.Exchanges[exchange]
+	if !ok {
+		return false
+	}
+	for _, source := range pairs.Currencies {
+		if !matchCurrency(source, from, fromNetwork) {
+			continue
+		}
+		targets := pairs.Currencies
+		if pairs.Pairs != nil {
+			targets = pairs.Pairs[source]
+		}
+		for _, target := range targets {
+			if target != source && matchCurrency(target, to, toNetwork) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExchangesFor returns the sorted names of the exchanges which can swap from
+// on fromNetwork to to on toNetwork. An empty network matches any network of
+// the currency.
+func (m *PairMatrix) ExchangesFor(from, fromNetwork, to, toNetwork string) []string {
+	var names []string
+	for name := range m.Exchanges {
+		if m.Supports(name, from, fromNetwork, to, toNetwork) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchCurrency tells whether the currency id is symbol on network, any
+// network when network is empty.
+func matchCurrency(id, symbol, network string) bool {
+	if network == "" {
+		return strings.SplitN(id, "/", 2)[0] == CanonicalSymbol(symbol)
+	}
+	return id == CurrencyID(symbol, network)
+}
+
+// PairChange is a pair added or removed between two matrices.
+type PairChange struct {
+	Exchange string `json:"exchange"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// CurrencyChange is a currency added or removed between two matrices.
+type CurrencyChange struct {
+	Exchange string `json:"exchange"`
+	Currency string `json:"currency"`
+}
+
+// PairMatrixDiff are the changes between two matrices. The pairs of the
+// exchanges which do not list their pairs change with their currencies, only
+// the currencies are reported for them.
+type PairMatrixDiff struct {
+	AddedPairs        []PairChange     `json:"added_pairs,omitempty"`
+	RemovedPairs      []PairChange     `json:"removed_pairs,omitempty"`
+	AddedCurrencies   []CurrencyChange `json:"added_currencies,omitempty"`
+	RemovedCurrencies []CurrencyChange `json:"removed_currencies,omitempty"`
+	// Skipped are the exchanges which are missing or failed in one of the
+	// matrices, they are not compared. The pairs of a currency which failed
+	// in one of the matrices are not compared either.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Empty tells whether the matrices have the same pairs.
+func (d PairMatrixDiff) Empty() bool {
+	return len(d.AddedPairs) == 0 && len(d.RemovedPairs) == 0 &&
+		len(d.AddedCurrencies) == 0 && len(d.RemovedCurrencies) == 0
+}
+
+// DiffPairMatrix returns the pairs and currencies added and removed from old
+// to latest, sorted by exchange.
+func DiffPairMatrix(old, latest *PairMatrix) PairMatrixDiff {
+	var d PairMatrixDiff
+	names := make(map[string]bool)
+	for name := range old.Exchanges {
+		names[name] = true
+	}
+	for name := range latest.Exchanges {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		before, after := old.Exchanges[name], latest.Exchanges[name]
+		if before == nil || after == nil || before.Error != "" || after.Error != "" {
+			d.Skipped = append(d.Skipped, name)
+			continue
+		}
+		added, removed := diffLists(before.Currencies, after.Currencies)
+		for _, currency := range added {
+			d.AddedCurrencies = append(d.AddedCurrencies, CurrencyChange{Exchange: name, Currency: currency})
+		}
+		for _, currency := range removed {
+			d.RemovedCurrencies = append(d.RemovedCurrencies, CurrencyChange{Exchange: name, Currency: currency})
+		}
+		if before.Pairs == nil || after.Pairs == nil {
+			continue
+		}
+		for _, from := range unionKeys(before.Pairs, after.Pairs) {
+			if before.PairErrors[from] != "" || after.PairErrors[from] != "" {
+				continue
+			}
+			added, removed := diffLists(before.Pairs[from], after.Pairs[from])
+			for _, to := range added {
+				d.AddedPairs = append(d.AddedPairs, PairChange{Exchange: name, From: from, To: to})
+			}
+			for _, to := range removed {
+				d.RemovedPairs = append(d.RemovedPairs, PairChange{Exchange: name, From: from, To: to})
+			}
+		}
+	}
+	return d
+}
+
+// diffLists returns the elements of the sorted list b missing from a, and
+// the elements of a missing from b.
+func diffLists(a, b []string) (added, removed []string) {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			removed = append(removed, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			added = append(added, b[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return added, removed
+}
+
+func unionKeys(a, b map[string][]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		keys = append(keys, key)
+	}
+	return sortedUnique(keys)
+}
@@ -0,0 +1,156 @@
+package instantswap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pairExchange lists the pairs of pairs, by source symbol.
+type pairExchange struct {
+	fakeExchange
+	currencies []Currency
+	pairs      map[string][]Currency
+	pairErrs   map[string]error
+}
+
+func (p *pairExchange) Capabilities() Capabilities {
+	return Capabilities{CurrenciesToPair: true}
+}
+
+func (p *pairExchange) GetCurrencies(ctx context.Context) ([]Currency, error) {
+	return p.currencies, p.err
+}
+
+func (p *pairExchange) GetCurrenciesToPair(ctx context.Context, from string) ([]Currency, error) {
+	if err := p.pairErrs[from]; err != nil {
+		return nil, err
+	}
+	return p.pairs[from], p.err
+}
+
+func TestPairMatrix(t *testing.T) {
+	btc := Currency{Symbol: "BTC", Network: NetworkBitcoin}
+	usdtTron := Currency{Symbol: "USDT", Network: NetworkTron}
+	usdtEth := Currency{Symbol: "USDT", Network: NetworkEthereum}
+	listing := &pairExchange{
+		fakeExchange: fakeExchange{name: "listing"},
+		currencies:   []Currency{btc, usdtTron, usdtEth},
+		pairs: map[string][]Currency{
+			"BTC":  {usdtTron, usdtEth},
+			"USDT": {btc, usdtTron},
+		},
+	}
+	meshed := &fakeExchange{name: "meshed"}
+	failing := &fakeExchange{name: "failing", err: errors.New("down")}
+	partial := &pairExchange{
+		fakeExchange: fakeExchange{name: "partial"},
+		currencies:   []Currency{btc, usdtTron},
+		pairs:        map[string][]Currency{"BTC": {usdtTron}},
+		pairErrs:     map[string]error{"USDT": errors.New("down")},
+	}
+	m := BuildPairMatrix(context.Background(), listing, meshed, failing, partial)
+
+	expected := &ExchangePairs{
+		Currencies: []string{"BTC", "USDT", "USDT/tron"},
+		Pairs: map[string][]string{
+			"BTC":       {"USDT", "USDT/tron"},
+			"USDT":      {"BTC", "USDT/tron"},
+			"USDT/tron": {"BTC"},
+		},
+	}
+	if !reflect.DeepEqual(m.Exchanges["listing"], expected) {
+		t.Fatalf("listing = %+v, expected: %+v", m.Exchanges["listing"], expected)
+	}
+	if m.Exchanges["meshed"].Pairs != nil || len(m.Exchanges["meshed"].Currencies) != 2 {
+		t.Errorf("meshed = %+v", m.Exchanges["meshed"])
+	}
+	if m.Exchanges["failing"].Error == "" {
+		t.Error("failing exchange has no error")
+	}
+	expected = &ExchangePairs{
+		Currencies: []string{"BTC", "USDT/tron"},
+		Pairs:      map[string][]string{"BTC": {"USDT/tron"}},
+		PairErrors: map[string]string{"USDT/tron": "down"},
+	}
+	if !reflect.DeepEqual(m.Exchanges["partial"], expected) {
+		t.Errorf("partial = %+v, expected: %+v", m.Exchanges["partial"], expected)
+	}
+
+	tests := []struct {
+		from, fromNetwork, to, toNetwork string
+		exchanges                        []string
+	}{
+		{"btc", "", "usdt", "trc20", []string{"listing", "partial"}},
+		{"USDT", "tron", "BTC", "bitcoin", []string{"listing"}},
+		{"BTC", "", "meshed", "", []string{"meshed"}},
+		{"BTC", "", "BTC", "", nil},
+		{"DCR", "", "BTC", "", nil},
+	}
+	for _, test := range tests {
+		got := m.ExchangesFor(test.from, test.fromNetwork, test.to, test.toNetwork)
+		if !reflect.DeepEqual(got, test.exchanges) {
+			t.Errorf("ExchangesFor(%s/%s, %s/%s) = %v, expected: %v", test.from, test.fromNetwork,
+				test.to, test.toNetwork, got, test.exchanges)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := m.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadPairMatrix(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Exchanges, m.Exchanges) {
+		t.Errorf("decoded = %+v, expected: %+v", decoded.Exchanges, m.Exchanges)
+	}
+	if d := DiffPairMatrix(m, decoded); !d.Empty() {
+		t.Errorf("diff of the same matrix = %+v", d)
+	}
+}
+
+func TestDiffPairMatrix(t *testing.T) {
+	old := &PairMatrix{Exchanges: map[string]*ExchangePairs{
+		"listing": {
+			Currencies: []string{"BTC", "DCR", "USDT"},
+			Pairs:      map[string][]string{"BTC": {"DCR", "USDT"}, "DCR": {"BTC"}},
+		},
+		"meshed":  {Currencies: []string{"BTC", "XMR"}},
+		"failing": {Currencies: []string{"BTC", "DCR"}},
+		"partial": {
+			Currencies: []string{"BTC", "DCR"},
+			Pairs:      map[string][]string{"BTC": {"DCR"}, "DCR": {"BTC"}},
+		},
+	}}
+	latest := &PairMatrix{Exchanges: map[string]*ExchangePairs{
+		"listing": {
+			Currencies: []string{"BTC", "DCR", "USDT"},
+			Pairs:      map[string][]string{"BTC": {"USDT"}, "USDT": {"BTC"}},
+		},
+		"meshed":  {Currencies: []string{"BTC", "LTC"}},
+		"failing": {Error: "down"},
+		"partial": {
+			Currencies: []string{"BTC", "DCR"},
+			Pairs:      map[string][]string{"BTC": {"DCR"}},
+			PairErrors: map[string]string{"DCR": "down"},
+		},
+	}}
+	d := DiffPairMatrix(old, latest)
+	expected := PairMatrixDiff{
+		AddedPairs: []PairChange{{Exchange: "listing", From: "USDT", To: "BTC"}},
+		RemovedPairs: []PairChange{
+			{Exchange: "listing", From: "BTC", To: "DCR"},
+			{Exchange: "listing", From: "DCR", To: "BTC"},
+		},
+		AddedCurrencies:   []CurrencyChange{{Exchange: "meshed", Currency: "LTC"}},
+		RemovedCurrencies: []CurrencyChange{{Exchange: "meshed", Currency: "XMR"}},
+		Skipped:           []string{"failing"},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("diff = %+v, expected: %+v", d, expected)
+	}
+}
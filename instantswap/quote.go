@@ -0,0 +1,182 @@
+package instantswap
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RateType is the mode of a rate.
+type RateType string
+
+const (
+	// RateTypeFloat rates follow the market until the deposit is received.
+	RateTypeFloat RateType = "float"
+	// RateTypeFixed rates are guaranteed until the quote expires.
+	RateTypeFixed RateType = "fixed"
+)
+
+// ChooseRateType returns the rate mode applied by an exchange with caps for
+// the requested one, fallback when it is empty. It returns an error of kind
+// ErrNotSupported for a mode the exchange does not support.
+func ChooseRateType(exchange string, caps Capabilities, requested, fallback RateType) (RateType, error) {
+	switch requested {
+	case "":
+		return fallback, nil
+	case RateTypeFixed:
+		if caps.FixedRate {
+			return requested, nil
+		}
+	case RateTypeFloat:
+		if caps.FloatingRate {
+			return requested, nil
+		}
+	default:
+		return "", NewError(exchange, nil, fmt.Sprintf("unknown rate type %q", requested))
+	}
+	return "", NewError(exchange, ErrNotSupported, fmt.Sprintf("%s rate is not supported", requested))
+}
+
+// ChooseReverseRateType is ChooseRateType for the reverse quotes and orders,
+// the exchanges only guarantee the amount received at fixed rate.
+func ChooseReverseRateType(exchange string, caps Capabilities, requested RateType) (RateType, error) {
+	if !caps.ReverseQuote {
+		return "", NotSupportedError(exchange, "reverse quote")
+	}
+	rateType, err := ChooseRateType(exchange, caps, requested, RateTypeFixed)
+	if err == nil && rateType != RateTypeFixed {
+		return "", NewError(exchange, ErrNotSupported, fmt.Sprintf("reverse quote at %s rate is not supported", rateType))
+	}
+	return rateType, err
+}
+
+// Quote is a rate of an exchange for a request. The fixed quotes are
+// identified by ID, which is passed to CreateOrder as Signature.
+type Quote struct {
+	ID       string
+	Exchange string
+	Request  ExchangeRateRequest
+	RateType RateType
+	Rate     Amount
+	// EstimatedAmount is the amount received for Request.Amount.
+	EstimatedAmount Amount
+	// DepositAmount is the amount to send of a reverse quote.
+	DepositAmount Amount
+	Min           Amount
+	Max           Amount
+	// ValidUntil is zero when the exchange does not tell the expiry.
+	ValidUntil time.Time
+	Provider   string
+}
+
+// NewQuote returns the quote of the rate info returned by exchange for vars.
+func NewQuote(exchange string, vars ExchangeRateRequest, info ExchangeRateInfo) Quote {
+	estimated := info.EstimatedAmount
+	if estimated.IsZero() && vars.Reverse() {
+		estimated = vars.ReceiveAmount
+	} else if estimated.IsZero() {
+		estimated = vars.Amount.Mul(info.ExchangeRate)
+	}
+	return Quote{
+		ID:              info.Signature,
+		Exchange:        exchange,
+		Request:         vars,
+		RateType:        info.RateType,
+		Rate:            info.ExchangeRate,
+		EstimatedAmount: estimated,
+		DepositAmount:   info.DepositAmount,
+		Min:             info.Min,
+		Max:             info.Max,
+		ValidUntil:      info.ValidUntil,
+		Provider:        info.Provider,
+	}
+}
+
+// GetQuote requests the rate of vars to exchange and returns its quote.
+func GetQuote(ctx context.Context, exchange IDExchange, vars ExchangeRateRequest) (Quote, error) {
+	info, err := exchange.GetExchangeRateInfo(ctx, vars)
+	if err != nil {
+		return Quote{}, err
+	}
+	return NewQuote(exchange.Name(), vars, info), nil
+}
+
+// Expired tells whether the quote expired at now.
+func (q Quote) Expired(now time.Time) bool {
+	return !q.ValidUntil.IsZero() && !now.Before(q.ValidUntil)
+}
+
+// guaranteed tells whether the exchange honors the rate of the quote when the
+// order is created with its ID.
+func (q Quote) guaranteed() bool {
+	return q.RateType == RateTypeFixed && q.ID != ""
+}
+
+// CreateOrderFromQuote creates the order of quote on exchange. The currencies,
+// amounts and signature of vars are set from the quote, the other fields, like
+// the addresses, are kept. The order of a reverse quote is a reverse order.
+//
+// It fails with an error of kind ErrRateExpired when the quote expired. The
+// rate of a quote which is not guaranteed is requested again, and the order is
+// not created when the new estimate is lower than the quoted one by more than
+// slippage, a fraction like 0.01 for 1%. The order created with a lower
+// estimate is returned with an error of kind ErrSlippageExceeded, it is
+// cancelled when the exchange supports it.
+func CreateOrderFromQuote(ctx context.Context, exchange IDExchange, quote Quote, vars CreateOrder, slippage Amount) (res CreateResultInfo, err error) {
+	if quote.Expired(time.Now()) {
+		return res, NewError(quote.Exchange, ErrRateExpired,
+			fmt.Sprintf("quote %s expired at %s", quote.ID, quote.ValidUntil.Format(time.RFC3339)))
+	}
+	if !quote.guaranteed() {
+		info, err := exchange.GetExchangeRateInfo(ctx, quote.Request)
+		if err != nil {
+			return res, err
+		}
+		fresh := NewQuote(quote.Exchange, quote.Request, info)
+		if err := quote.checkSlippage(fresh.EstimatedAmount, slippage); err != nil {
+			return res, err
+		}
+		if fresh.ID != "" {
+			quote.ID = fresh.ID
+		}
+	}
+	vars.FromCurrency = quote.Request.From
+	vars.FromNetwork = quote.Request.FromNetwork
+	vars.ToCurrency = quote.Request.To
+	vars.ToNetwork = quote.Request.ToNetwork
+	vars.InvoicedAmount = quote.Request.Amount
+	vars.OrderedAmount = quote.EstimatedAmount
+	vars.Signature = quote.ID
+	vars.RateType = quote.RateType
+	if vars.Provider == "" {
+		vars.Provider = quote.Provider
+	}
+	res, err = exchange.CreateOrder(ctx, vars)
+	if err != nil {
+		return res, err
+	}
+	if res.OrderedAmount.Sign() > 0 && res.InvoicedAmount.Equal(quote.Request.Amount) {
+		if err := quote.checkSlippage(res.OrderedAmount, slippage); err != nil {
+			if ExchangeCapabilities(exchange).Cancel {
+				exchange.CancelOrder(ctx, res.UUID)
+			}
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// checkSlippage returns an error of kind ErrSlippageExceeded when estimated is
+// lower than the estimate of the quote by more than slippage.
+func (q Quote) checkSlippage(estimated, slippage Amount) error {
+	if q.EstimatedAmount.Sign() <= 0 {
+		return nil
+	}
+	floor := q.EstimatedAmount.Sub(q.EstimatedAmount.Mul(slippage))
+	if estimated.Cmp(floor) >= 0 {
+		return nil
+	}
+	return NewError(q.Exchange, ErrSlippageExceeded,
+		fmt.Sprintf("estimated amount %s is below %s, the quoted %s minus %s slippage",
+			estimated, floor, q.EstimatedAmount, slippage))
+}
@@ -0,0 +1,113 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// quoteExchange creates the orders with ordered amount.
+type quoteExchange struct {
+	fakeExchange
+	ordered   Amount
+	cancelled []string
+}
+
+func (q *quoteExchange) Capabilities() Capabilities {
+	return Capabilities{Cancel: true}
+}
+
+func (q *quoteExchange) CreateOrder(ctx context.Context, vars CreateOrder) (CreateResultInfo, error) {
+	q.orders = append(q.orders, vars)
+	return CreateResultInfo{UUID: "order", InvoicedAmount: vars.InvoicedAmount, OrderedAmount: q.ordered}, nil
+}
+
+func (q *quoteExchange) CancelOrder(ctx context.Context, orderID string) (string, error) {
+	q.cancelled = append(q.cancelled, orderID)
+	return orderID, nil
+}
+
+func TestCreateOrderFromQuote(t *testing.T) {
+	ex := &quoteExchange{fakeExchange: fakeExchange{name: "ex", rate: ExchangeRateInfo{
+		ExchangeRate: NewAmount(100, 0),
+		Signature:    "quote-1",
+		RateType:     RateTypeFixed,
+		ValidUntil:   time.Now().Add(time.Minute),
+	}}}
+	vars := ExchangeRateRequest{From: "BTC", To: "DCR", Amount: NewAmount(2, 0)}
+	quote, err := GetQuote(context.Background(), ex, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.ID != "quote-1" || !quote.EstimatedAmount.Equal(NewAmount(200, 0)) || quote.Expired(time.Now()) {
+		t.Fatalf("quote = %+v", quote)
+	}
+	slippage := MustParseAmount("0.01")
+
+	res, err := CreateOrderFromQuote(context.Background(), ex, quote, CreateOrder{Destination: "dcr-address"}, slippage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := ex.orders[0]
+	if order.Signature != "quote-1" || order.FromCurrency != "BTC" || !order.InvoicedAmount.Equal(vars.Amount) ||
+		order.Destination != "dcr-address" || res.UUID != "order" {
+		t.Errorf("order = %+v", order)
+	}
+
+	expired := quote
+	expired.ValidUntil = time.Now().Add(-time.Second)
+	if _, err := CreateOrderFromQuote(context.Background(), ex, expired, CreateOrder{}, slippage); !errors.Is(err, ErrRateExpired) {
+		t.Errorf("expired quote error = %v, expected: %v", err, ErrRateExpired)
+	}
+
+	// a floating quote is requested again
+	floating := quote
+	floating.RateType = RateTypeFloat
+	floating.EstimatedAmount = NewAmount(202, 0)
+	if _, err := CreateOrderFromQuote(context.Background(), ex, floating, CreateOrder{}, slippage); err != nil {
+		t.Errorf("estimate within the slippage error = %v", err)
+	}
+	floating.EstimatedAmount = NewAmount(210, 0)
+	if _, err := CreateOrderFromQuote(context.Background(), ex, floating, CreateOrder{}, slippage); !errors.Is(err, ErrSlippageExceeded) {
+		t.Errorf("estimate beyond the slippage error = %v, expected: %v", err, ErrSlippageExceeded)
+	}
+	if len(ex.orders) != 2 {
+		t.Errorf("%d orders created, expected: 2", len(ex.orders))
+	}
+
+	// the order re-quoted by the exchange is cancelled
+	ex.ordered = NewAmount(190, 0)
+	if _, err := CreateOrderFromQuote(context.Background(), ex, quote, CreateOrder{}, slippage); !errors.Is(err, ErrSlippageExceeded) {
+		t.Errorf("ordered amount beyond the slippage error = %v, expected: %v", err, ErrSlippageExceeded)
+	}
+	if len(ex.cancelled) != 1 {
+		t.Errorf("cancelled = %v, expected the order", ex.cancelled)
+	}
+}
+
+func TestChooseRateType(t *testing.T) {
+	fixedOnly := Capabilities{FixedRate: true}
+	both := Capabilities{FixedRate: true, FloatingRate: true}
+	tests := []struct {
+		caps      Capabilities
+		requested RateType
+		expected  RateType
+		kind      error
+	}{
+		{fixedOnly, "", RateTypeFixed, nil},
+		{fixedOnly, RateTypeFixed, RateTypeFixed, nil},
+		{fixedOnly, RateTypeFloat, "", ErrNotSupported},
+		{both, RateTypeFloat, RateTypeFloat, nil},
+		{both, "market", "", nil},
+	}
+	for _, test := range tests {
+		got, err := ChooseRateType("ex", test.caps, test.requested, RateTypeFixed)
+		if got != test.expected {
+			t.Errorf("ChooseRateType(%q) = %q, expected: %q", test.requested, got, test.expected)
+		}
+		if (err != nil) != (test.expected == "") || (test.kind != nil && !errors.Is(err, test.kind)) {
+			t.Errorf("ChooseRateType(%q) error = %v, expected kind: %v", test.requested, err, test.kind)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package instantswap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is the rate limit of the exchanges which do not set their
+// own default.
+var DefaultRateLimit = RateLimit{Rate: 5, Burst: 10}
+
+// RateLimit configures the token bucket limiting the requests sent to an
+// exchange. The requests wait for a token instead of failing.
+type RateLimit struct {
+	// Rate is the number of requests per second, a negative rate disables
+	// the limiter.
+	Rate float64
+	// Burst is the number of requests which can be sent at once, it is at
+	// least 1.
+	Burst int
+}
+
+// limiters are the rate limiters shared by the clients of an exchange using
+// the same api key.
+var limiters = struct {
+	mu    sync.Mutex
+	stack map[string]*limiter
+}{stack: make(map[string]*limiter)}
+
+// sharedLimiter returns the limiter of the exchange and api key, it is
+// created with limit by the first client. It returns nil for a disabled
+// limit.
+func sharedLimiter(exchange, apiKey string, limit RateLimit) *limiter {
+	if limit.Rate <= 0 {
+		return nil
+	}
+	limiters.mu.Lock()
+	defer limiters.mu.Unlock()
+	key := exchange + "/" + apiKey
+	l, ok := limiters.stack[key]
+	if !ok {
+		l = newLimiter(limit)
+		limiters.stack[key] = l
+	}
+	return l
+}
+
+// limiter is a token bucket, a request takes a token and waits when there is
+// none. The tokens go below zero to queue the waiting requests.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &limiter{
+		rate:   limit.Rate,
+		burst:  float64(limit.Burst),
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns the delay before it is available.
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait waits for a token unless ctx is done first, the token is given back
+// then.
+func (l *limiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay == 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(RateLimit{Rate: 100, Burst: 2})
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the burst is sent at once, the 2 next requests wait 10ms each.
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("4 requests sent in %v", elapsed)
+	}
+
+	l = newLimiter(RateLimit{Rate: 1})
+	if err := l.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait error = %v, expected: %v", err, context.DeadlineExceeded)
+	}
+	if l.tokens < -0.1 {
+		t.Errorf("the token of the canceled request was not given back: %v", l.tokens)
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	conf := ExchangeConfig{ApiKey: "rate-limit-key"}
+	a := NewClient("limited", &conf)
+	a.SetDefaultRateLimit(RateLimit{Rate: 20, Burst: 1})
+	b := NewClient("limited", &conf)
+	b.SetDefaultRateLimit(RateLimit{Rate: 20, Burst: 1})
+	other := NewClient("limited", &ExchangeConfig{ApiKey: "other-key", RateLimit: RateLimit{Rate: -1}})
+	if a.rateLimiter() != b.rateLimiter() {
+		t.Fatal("the clients using the same api key do not share their limiter")
+	}
+	if other.rateLimiter() != nil {
+		t.Fatal("the limiter was not disabled")
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	for _, client := range []*Client{a, b, a} {
+		if _, err := client.Do(ctx, server.URL+"/", http.MethodGet, "", "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests sent in %v, expected at least 100ms", elapsed)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := a.Do(ctx, server.URL+"/", http.MethodGet, "", "", false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do error = %v, expected: %v", err, context.DeadlineExceeded)
+	}
+}
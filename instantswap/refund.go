@@ -0,0 +1,62 @@
+package instantswap
+
+// RefundStatus is the state of the refund of an order.
+type RefundStatus int
+
+const (
+	RefundStatusUnknown RefundStatus = iota
+	// RefundStatusNotEligible orders can not be refunded, their deposit is
+	// not received or they were exchanged.
+	RefundStatusNotEligible
+	// RefundStatusEligible orders failed, expired or are on hold, they wait
+	// for the refund address given by SubmitRefund.
+	RefundStatusEligible
+	// RefundStatusPending refunds have an address and are being sent.
+	RefundStatusPending
+	RefundStatusCompleted
+)
+
+func (s RefundStatus) String() string {
+	switch s {
+	case RefundStatusNotEligible:
+		return "Not eligible"
+	case RefundStatusEligible:
+		return "Eligible"
+	case RefundStatusPending:
+		return "Pending"
+	case RefundStatusCompleted:
+		return "Completed"
+	default:
+		return "Unknown"
+	}
+}
+
+// RefundRequest asks the refund of the deposit of the order UUID to Address.
+// ExtraID is the memo or destination tag of Address.
+type RefundRequest struct {
+	UUID    string `json:"uuid"`
+	Address string `json:"address"`
+	ExtraID string `json:"extra_id,omitempty"`
+}
+
+// RefundInfo is the refund of an order, TxID is the refund transaction once
+// it is sent.
+type RefundInfo struct {
+	UUID    string
+	Status  RefundStatus
+	Address string
+	ExtraID string
+	Amount  Amount
+	TxID    string
+}
+
+// Eligible tells whether a refund address can be submitted for the order.
+func (r RefundInfo) Eligible() bool {
+	return r.Status == RefundStatusEligible
+}
+
+// RefundNotEligibleError returns the error of a refund asked for an order
+// which is not eligible.
+func RefundNotEligibleError(exchange string, info RefundInfo) error {
+	return NewError(exchange, ErrRefundNotEligible, "refund of order "+info.UUID+" is "+info.Status.String())
+}
@@ -0,0 +1,395 @@
+package instantswap
+
+import (
+	"strings"
+)
+
+// Canonical network IDs. The networks of Currency, ExchangeRateRequest and
+// CreateOrder are canonical IDs, every exchange maps them to its own codes.
+const (
+	NetworkBitcoin         = "bitcoin"
+	NetworkEthereum        = "ethereum"
+	NetworkTron            = "tron"
+	NetworkBSC             = "bsc"
+	NetworkSolana          = "solana"
+	NetworkPolygon         = "polygon"
+	NetworkArbitrum        = "arbitrum"
+	NetworkOptimism        = "optimism"
+	NetworkBase            = "base"
+	NetworkAvalanche       = "avax"
+	NetworkTON             = "ton"
+	NetworkLitecoin        = "litecoin"
+	NetworkDecred          = "decred"
+	NetworkMonero          = "monero"
+	NetworkDogecoin        = "dogecoin"
+	NetworkBitcoinCash     = "bitcoincash"
+	NetworkDash            = "dash"
+	NetworkZcash           = "zcash"
+	NetworkEthereumClassic = "ethereumclassic"
+	NetworkRipple          = "ripple"
+	NetworkStellar         = "stellar"
+	NetworkCardano         = "cardano"
+	NetworkPolkadot        = "polkadot"
+	NetworkCosmos          = "cosmos"
+	NetworkAlgorand        = "algorand"
+)
+
+// NetworkInfo describes a canonical network.
+type NetworkInfo struct {
+	ID   string
+	Name string
+	// Native is the symbol of the currency paying the network fees.
+	Native string
+	// Aliases are the other names of the network used by the exchanges,
+	// like ERC20 or TRC20.
+	Aliases []string
+}
+
+// CurrencyInfo describes a canonical currency.
+type CurrencyInfo struct {
+	Symbol   string
+	Name     string
+	IsFiat   bool
+	IsStable bool
+	// Networks are the networks the currency is issued on, the first one is
+	// its default network, assumed when an exchange does not tell it.
+	Networks []string
+	// Precision is the number of decimals of the currency amounts.
+	Precision int
+	// NetworkPrecisions are the precisions on the networks where it is not
+	// Precision.
+	NetworkPrecisions map[string]int
+}
+
+// DefaultPrecision is the number of decimals of the currencies missing from
+// the registry.
+const DefaultPrecision = 8
+
+var networks = []NetworkInfo{
+	{ID: NetworkBitcoin, Name: "Bitcoin", Native: "BTC", Aliases: []string{"btc"}},
+	{ID: NetworkEthereum, Name: "Ethereum", Native: "ETH", Aliases: []string{"eth", "erc20"}},
+	{ID: NetworkTron, Name: "Tron", Native: "TRX", Aliases: []string{"trx", "trc20"}},
+	{ID: NetworkBSC, Name: "BNB Smart Chain", Native: "BNB", Aliases: []string{"bep20", "bnbsmartchain", "binancesmartchain"}},
+	{ID: NetworkSolana, Name: "Solana", Native: "SOL", Aliases: []string{"sol", "spl"}},
+	{ID: NetworkPolygon, Name: "Polygon", Native: "MATIC", Aliases: []string{"matic", "pol"}},
+	{ID: NetworkArbitrum, Name: "Arbitrum One", Native: "ETH", Aliases: []string{"arb", "arbitrumone"}},
+	{ID: NetworkOptimism, Name: "Optimism", Native: "ETH", Aliases: []string{"op"}},
+	{ID: NetworkBase, Name: "Base", Native: "ETH"},
+	{ID: NetworkAvalanche, Name: "Avalanche C-Chain", Native: "AVAX", Aliases: []string{"avaxc", "avalanche", "cchain", "avaxcchain"}},
+	{ID: NetworkTON, Name: "TON", Native: "TON", Aliases: []string{"toncoin"}},
+	{ID: NetworkLitecoin, Name: "Litecoin", Native: "LTC", Aliases: []string{"ltc"}},
+	{ID: NetworkDecred, Name: "Decred", Native: "DCR", Aliases: []string{"dcr"}},
+	{ID: NetworkMonero, Name: "Monero", Native: "XMR", Aliases: []string{"xmr"}},
+	{ID: NetworkDogecoin, Name: "Dogecoin", Native: "DOGE", Aliases: []string{"doge"}},
+	{ID: NetworkBitcoinCash, Name: "Bitcoin Cash", Native: "BCH", Aliases: []string{"bch"}},
+	{ID: NetworkDash, Name: "Dash", Native: "DASH"},
+	{ID: NetworkZcash, Name: "Zcash", Native: "ZEC", Aliases: []string{"zec"}},
+	{ID: NetworkEthereumClassic, Name: "Ethereum Classic", Native: "ETC", Aliases: []string{"etc"}},
+	{ID: NetworkRipple, Name: "XRP Ledger", Native: "XRP", Aliases: []string{"xrp", "xrpl"}},
+	{ID: NetworkStellar, Name: "Stellar", Native: "XLM", Aliases: []string{"xlm"}},
+	{ID: NetworkCardano, Name: "Cardano", Native: "ADA", Aliases: []string{"ada"}},
+	{ID: NetworkPolkadot, Name: "Polkadot", Native: "DOT", Aliases: []string{"dot"}},
+	{ID: NetworkCosmos, Name: "Cosmos", Native: "ATOM", Aliases: []string{"atom"}},
+	{ID: NetworkAlgorand, Name: "Algorand", Native: "ALGO", Aliases: []string{"algo"}},
+}
+
+var currencies = []CurrencyInfo{
+	{Symbol: "BTC", Name: "Bitcoin", Networks: []string{NetworkBitcoin}, Precision: 8},
+	{Symbol: "ETH", Name: "Ethereum", Networks: []string{NetworkEthereum, NetworkArbitrum, NetworkOptimism, NetworkBase}, Precision: 18},
+	{Symbol: "LTC", Name: "Litecoin", Networks: []string{NetworkLitecoin}, Precision: 8},
+	{Symbol: "DCR", Name: "Decred", Networks: []string{NetworkDecred}, Precision: 8},
+	{Symbol: "XMR", Name: "Monero", Networks: []string{NetworkMonero}, Precision: 12},
+	{Symbol: "DOGE", Name: "Dogecoin", Networks: []string{NetworkDogecoin}, Precision: 8},
+	{Symbol: "BCH", Name: "Bitcoin Cash", Networks: []string{NetworkBitcoinCash}, Precision: 8},
+	{Symbol: "DASH", Name: "Dash", Networks: []string{NetworkDash}, Precision: 8},
+	{Symbol: "ZEC", Name: "Zcash", Networks: []string{NetworkZcash}, Precision: 8},
+	{Symbol: "ETC", Name: "Ethereum Classic", Networks: []string{NetworkEthereumClassic}, Precision: 18},
+	{Symbol: "TRX", Name: "Tron", Networks: []string{NetworkTron}, Precision: 6},
+	{Symbol: "BNB", Name: "BNB", Networks: []string{NetworkBSC}, Precision: 18},
+	{Symbol: "SOL", Name: "Solana", Networks: []string{NetworkSolana}, Precision: 9},
+	{Symbol: "MATIC", Name: "Polygon", Networks: []string{NetworkPolygon}, Precision: 18},
+	{Symbol: "AVAX", Name: "Avalanche", Networks: []string{NetworkAvalanche}, Precision: 18},
+	{Symbol: "TON", Name: "Toncoin", Networks: []string{NetworkTON}, Precision: 9},
+	{Symbol: "XRP", Name: "XRP", Networks: []string{NetworkRipple}, Precision: 6},
+	{Symbol: "XLM", Name: "Stellar", Networks: []string{NetworkStellar}, Precision: 7},
+	{Symbol: "ADA", Name: "Cardano", Networks: []string{NetworkCardano}, Precision: 6},
+	{Symbol: "DOT", Name: "Polkadot", Networks: []string{NetworkPolkadot}, Precision: 10},
+	{Symbol: "ATOM", Name: "Cosmos", Networks: []string{NetworkCosmos}, Precision: 6},
+	{Symbol: "ALGO", Name: "Algorand", Networks: []string{NetworkAlgorand}, Precision: 6},
+	{Symbol: "USDT", Name: "Tether", IsStable: true, Precision: 6,
+		Networks: []string{NetworkEthereum, NetworkTron, NetworkBSC, NetworkSolana, NetworkPolygon,
+			NetworkArbitrum, NetworkOptimism, NetworkAvalanche, NetworkTON},
+		NetworkPrecisions: map[string]int{NetworkBSC: 18}},
+	{Symbol: "USDC", Name: "USD Coin", IsStable: true, Precision: 6,
+		Networks: []string{NetworkEthereum, NetworkTron, NetworkBSC, NetworkSolana, NetworkPolygon,
+			NetworkArbitrum, NetworkOptimism, NetworkBase, NetworkAvalanche, NetworkStellar, NetworkAlgorand},
+		NetworkPrecisions: map[string]int{NetworkBSC: 18}},
+	{Symbol: "DAI", Name: "Dai", IsStable: true, Networks: []string{NetworkEthereum, NetworkBSC, NetworkPolygon}, Precision: 18},
+	{Symbol: "USD", Name: "US Dollar", IsFiat: true, Precision: 2},
+	{Symbol: "EUR", Name: "Euro", IsFiat: true, Precision: 2},
+	{Symbol: "GBP", Name: "British Pound", IsFiat: true, Precision: 2},
+}
+
+var (
+	networkIndex  = map[string]*NetworkInfo{}
+	currencyIndex = map[string]*CurrencyInfo{}
+	// nativeIndex maps the symbols to the networks they are the native
+	// currency of, ETH to ethereum rather than arbitrum.
+	nativeIndex = map[string]string{}
+)
+
+func init() {
+	for i := range networks {
+		network := &networks[i]
+		networkIndex[networkKey(network.ID)] = network
+		networkIndex[networkKey(network.Name)] = network
+		for _, alias := range network.Aliases {
+			networkIndex[networkKey(alias)] = network
+		}
+		if _, ok := nativeIndex[network.Native]; !ok {
+			nativeIndex[network.Native] = network.ID
+		}
+	}
+	for i := range currencies {
+		currencyIndex[currencies[i].Symbol] = &currencies[i]
+	}
+}
+
+// networkKey normalizes a network name, "BNB Smart Chain", "bnb-smart-chain"
+// and "BNB_SMART_CHAIN" have the same key.
+func networkKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_', '.':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// Networks returns the canonical networks.
+func Networks() []NetworkInfo {
+	return append([]NetworkInfo(nil), networks...)
+}
+
+// LookupNetwork finds a network by ID, name or alias, case insensitively.
+func LookupNetwork(name string) (NetworkInfo, bool) {
+	if network, ok := networkIndex[networkKey(name)]; ok {
+		return *network, true
+	}
+	return NetworkInfo{}, false
+}
+
+// CanonicalNetwork returns the ID of the network, the lower case name when it
+// is not in the registry and "" for "".
+func CanonicalNetwork(name string) string {
+	if network, ok := LookupNetwork(name); ok {
+		return network.ID
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// CanonicalSymbol returns the canonical symbol of a currency, its upper case
+// ticker.
+func CanonicalSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// LookupCurrency finds a currency by symbol, case insensitively.
+func LookupCurrency(symbol string) (CurrencyInfo, bool) {
+	if currency, ok := currencyIndex[CanonicalSymbol(symbol)]; ok {
+		return *currency, true
+	}
+	return CurrencyInfo{}, false
+}
+
+// DefaultNetwork returns the default network of the currency, "" when it is
+// not in the registry.
+func DefaultNetwork(symbol string) string {
+	if currency, ok := currencyIndex[CanonicalSymbol(symbol)]; ok && len(currency.Networks) > 0 {
+		return currency.Networks[0]
+	}
+	return ""
+}
+
+// CurrencyID returns the canonical identifier of a currency on a network,
+// like USDT/tron. The network is omitted when it is empty or the default
+// network of the currency, so BTC and BTC/bitcoin are both BTC.
+func CurrencyID(symbol, network string) string {
+	symbol, network = CanonicalSymbol(symbol), CanonicalNetwork(network)
+	if network == "" || network == DefaultNetwork(symbol) {
+		return symbol
+	}
+	return symbol + "/" + network
+}
+
+// ID returns the canonical identifier of the currency.
+func (c Currency) ID() string {
+	return CurrencyID(c.Symbol, c.Network)
+}
+
+// CurrencyPrecision returns the number of decimals of the currency on the
+// network, network may be empty.
+func CurrencyPrecision(symbol, network string) int {
+	currency, ok := currencyIndex[CanonicalSymbol(symbol)]
+	if !ok {
+		return DefaultPrecision
+	}
+	if precision, ok := currency.NetworkPrecisions[CanonicalNetwork(network)]; ok {
+		return precision
+	}
+	return currency.Precision
+}
+
+// nativeNetwork returns the network of which the symbol is the native coin,
+// the lower case symbol for the coins missing from the registry.
+func nativeNetwork(symbol string) string {
+	if id, ok := nativeIndex[CanonicalSymbol(symbol)]; ok {
+		return id
+	}
+	return strings.ToLower(strings.TrimSpace(symbol))
+}
+
+// CurrencyKey is a canonical symbol and network.
+type CurrencyKey struct {
+	Symbol  string
+	Network string
+}
+
+// CodeMap maps the currency and network codes of an exchange api to the
+// canonical ones, the codes it does not map are resolved with the registry.
+type CodeMap struct {
+	// Networks maps the network codes of the exchange to canonical IDs.
+	Networks map[string]string
+	// Currencies maps the currency codes of the exchange which include the
+	// network, like usdttrc20, to canonical currencies.
+	Currencies map[string]CurrencyKey
+	// Native is the network code the exchange uses for the own network of
+	// every coin, like Mainnet.
+	Native string
+}
+
+// Network returns the canonical ID of the network code of the exchange for
+// the currency symbol.
+func (m CodeMap) Network(code, symbol string) string {
+	if code == "" {
+		return ""
+	}
+	if m.Native != "" && strings.EqualFold(code, m.Native) {
+		return nativeNetwork(symbol)
+	}
+	for c, id := range m.Networks {
+		if strings.EqualFold(c, code) {
+			return id
+		}
+	}
+	return CanonicalNetwork(code)
+}
+
+// ProviderNetwork returns the network code of the exchange for a canonical
+// network, or any name LookupNetwork resolves, of the currency symbol. The
+// unknown networks are returned unchanged.
+func (m CodeMap) ProviderNetwork(network, symbol string) string {
+	if network == "" {
+		return ""
+	}
+	for c := range m.Networks {
+		if strings.EqualFold(c, network) {
+			return c
+		}
+	}
+	if m.Native != "" && strings.EqualFold(m.Network(network, symbol), nativeNetwork(symbol)) {
+		return m.Native
+	}
+	id, ok := m.canonicalNetwork(network)
+	if !ok {
+		return network
+	}
+	if code, ok := m.networkCode(id); ok {
+		return code
+	}
+	return id
+}
+
+func (m CodeMap) canonicalNetwork(network string) (string, bool) {
+	if info, ok := LookupNetwork(network); ok {
+		return info.ID, true
+	}
+	for _, id := range m.Networks {
+		if strings.EqualFold(id, network) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// networkCode returns the lowest exchange code of the network so the result
+// does not depend on the map order.
+func (m CodeMap) networkCode(id string) (string, bool) {
+	code, found := "", false
+	for c, networkID := range m.Networks {
+		if networkID == id && (!found || c < code) {
+			code, found = c, true
+		}
+	}
+	return code, found
+}
+
+// Currency returns the canonical symbol and network of the currency code and
+// network code of the exchange.
+func (m CodeMap) Currency(code, network string) (symbol, canonicalNetwork string) {
+	for c, key := range m.Currencies {
+		if strings.EqualFold(c, code) {
+			return key.Symbol, key.Network
+		}
+	}
+	symbol = CanonicalSymbol(code)
+	return symbol, m.Network(network, symbol)
+}
+
+// ProviderCurrency returns the currency code and network code of the exchange
+// for a currency symbol and network. The symbol may be a code of the
+// exchange, it is then returned unchanged.
+func (m CodeMap) ProviderCurrency(symbol, network string) (code, networkCode string) {
+	if network != "" {
+		id, ok := m.canonicalNetwork(network)
+		if !ok {
+			id = m.Network(network, symbol)
+		}
+		code, found := "", false
+		for c, key := range m.Currencies {
+			if strings.EqualFold(key.Symbol, symbol) && key.Network == id && (!found || c < code) {
+				code, found = c, true
+			}
+		}
+		if found {
+			return code, m.ProviderNetwork(network, symbol)
+		}
+	}
+	return symbol, m.ProviderNetwork(network, symbol)
+}
+
+// NewCurrency returns the canonical Currency of a currency code and network
+// code of the exchange, the registry completes its flags and precision.
+func (m CodeMap) NewCurrency(name, code, network string) Currency {
+	symbol, network := m.Currency(code, network)
+	currency := Currency{
+		Name:      name,
+		Symbol:    symbol,
+		Network:   network,
+		Precision: CurrencyPrecision(symbol, network),
+	}
+	if info, ok := currencyIndex[symbol]; ok {
+		currency.IsFiat = info.IsFiat
+		currency.IsStable = info.IsStable
+		if currency.Name == "" {
+			currency.Name = info.Name
+		}
+	}
+	return currency
+}
+
+// NewCurrency returns the canonical Currency of a symbol on a network.
+func NewCurrency(name, symbol, network string) Currency {
+	return CodeMap{}.NewCurrency(name, symbol, network)
+}
@@ -0,0 +1,102 @@
+package instantswap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupNetwork(t *testing.T) {
+	tests := []struct {
+		name, id string
+	}{
+		{"bitcoin", NetworkBitcoin},
+		{"ERC20", NetworkEthereum},
+		{"trc-20", NetworkTron},
+		{"BNB Smart Chain", NetworkBSC},
+		{"bep20", NetworkBSC},
+		{"Mainnet", "mainnet"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := CanonicalNetwork(test.name); got != test.id {
+			t.Errorf("CanonicalNetwork(%q) = %q, expected: %q", test.name, got, test.id)
+		}
+	}
+	if _, ok := LookupNetwork("mainnet"); ok {
+		t.Error("LookupNetwork(mainnet) expected not found")
+	}
+}
+
+func TestCurrencyID(t *testing.T) {
+	tests := []struct {
+		symbol, network, id string
+	}{
+		{"btc", "", "BTC"},
+		{"BTC", "bitcoin", "BTC"},
+		{"usdt", "ethereum", "USDT"},
+		{"USDT", "TRC20", "USDT/tron"},
+		{"FOO", "bar", "FOO/bar"},
+	}
+	for _, test := range tests {
+		if got := CurrencyID(test.symbol, test.network); got != test.id {
+			t.Errorf("CurrencyID(%s, %s) = %s, expected: %s", test.symbol, test.network, got, test.id)
+		}
+	}
+}
+
+func TestCurrencyPrecision(t *testing.T) {
+	tests := []struct {
+		symbol, network string
+		precision       int
+	}{
+		{"BTC", "", DefaultPrecision},
+		{"ETH", "", 18},
+		{"xmr", "", 12},
+		{"USDT", "TRX", 6},
+		{"USDT", "BSC", 18},
+	}
+	for _, test := range tests {
+		if got := CurrencyPrecision(test.symbol, test.network); got != test.precision {
+			t.Errorf("CurrencyPrecision(%s, %s) = %d, expected: %d", test.symbol, test.network, got, test.precision)
+		}
+	}
+}
+
+func TestCodeMap(t *testing.T) {
+	codes := CodeMap{
+		Native: "Mainnet",
+		Networks: map[string]string{
+			"ERC20": NetworkEthereum,
+			"TRC20": NetworkTron,
+		},
+		Currencies: map[string]CurrencyKey{
+			"usdttrc20": {Symbol: "USDT", Network: NetworkTron},
+		},
+	}
+	tests := []struct {
+		code, network   string
+		symbol, netID   string
+		providerCode    string
+		providerNetwork string
+	}{
+		{"btc", "Mainnet", "BTC", NetworkBitcoin, "btc", "Mainnet"},
+		{"usdt", "ERC20", "USDT", NetworkEthereum, "usdt", "ERC20"},
+		{"usdt", "trc20", "USDT", NetworkTron, "usdttrc20", "TRC20"},
+		{"usdttrc20", "", "USDT", NetworkTron, "usdttrc20", "TRC20"},
+	}
+	for _, test := range tests {
+		c := codes.NewCurrency("", test.code, test.network)
+		if c.Symbol != test.symbol || c.Network != test.netID {
+			t.Errorf("NewCurrency(%s, %s) = %s/%s, expected: %s/%s", test.code, test.network,
+				c.Symbol, c.Network, test.symbol, test.netID)
+		}
+		code, network := codes.ProviderCurrency(c.Symbol, c.Network)
+		if !strings.EqualFold(code, test.providerCode) || network != test.providerNetwork {
+			t.Errorf("ProviderCurrency(%s, %s) = %s, %s, expected: %s, %s", c.Symbol, c.Network,
+				code, network, test.providerCode, test.providerNetwork)
+		}
+	}
+	if c := codes.NewCurrency("", "usdt", "ERC20"); !c.IsStable || c.Precision != 6 || c.Name == "" {
+		t.Errorf("NewCurrency(usdt) = %+v, expected registry flags", c)
+	}
+}
@@ -0,0 +1,147 @@
+package instantswap
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultRetryPolicy is the retry policy of the clients which configuration
+// leaves MaxAttempts to zero.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// RetryPolicy configures the retries of the failed requests of a Client.
+//
+// The idempotent requests, GET, HEAD, OPTIONS, PUT, DELETE and the requests
+// which context is marked with WithIdempotent, are retried on network errors
+// and on 502, 503 and 504 responses. The other requests, and the ones which
+// context is marked with WithNotIdempotent, are only retried on 429
+// responses, which are rejected before being processed, so an order is never
+// created twice.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a request including the
+	// first one, 1 disables the retries.
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, it doubles for every
+	// next retry. A random jitter of up to half the delay is removed.
+	MinBackoff time.Duration
+	// MaxBackoff bounds the delay between two attempts. A request is not
+	// retried when the Retry-After header of the response asks to wait
+	// longer.
+	MaxBackoff time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.MinBackoff <= 0 {
+		p.MinBackoff = DefaultRetryPolicy.MinBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.MaxBackoff < p.MinBackoff {
+		p.MaxBackoff = p.MinBackoff
+	}
+	return p
+}
+
+// backoff returns the jittered delay before the retry following attempt,
+// attempts are counted from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MinBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay - time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks the requests sent with ctx as safe to retry. It is
+// used for the POST requests which do not change anything, like quotes.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// WithNotIdempotent marks the requests sent with ctx as unsafe to retry. It
+// is used for the GET requests which create orders.
+func WithNotIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, false)
+}
+
+func isIdempotent(ctx context.Context, method string) bool {
+	if idempotent, ok := ctx.Value(idempotentKey{}).(bool); ok {
+		return idempotent
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// shouldRetry tells whether the request is retried after resp, which is nil
+// on network errors.
+func shouldRetry(ctx context.Context, method string, resp *http.Response) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if resp == nil {
+		return isIdempotent(ctx, method)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(ctx, method)
+	}
+	return false
+}
+
+// retryAfter reads the Retry-After header of resp, in seconds or as a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			seconds = 0
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// sleep waits for delay unless ctx is done first.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
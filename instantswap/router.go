@@ -0,0 +1,264 @@
+package instantswap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const ROUTER_NAME = "router"
+
+// DefaultIntermediates are the currencies the routes go through when the
+// router is created without intermediates.
+var DefaultIntermediates = []CurrencyKey{
+	{Symbol: "BTC", Network: NetworkBitcoin},
+	{Symbol: "USDT", Network: NetworkEthereum},
+	{Symbol: "ETH", Network: NetworkEthereum},
+}
+
+// RouteLeg is a swap of a route on one exchange.
+type RouteLeg struct {
+	Exchange string
+	Request  ExchangeRateRequest
+	ExchangeRateInfo
+}
+
+// Route is a direct swap or a swap through an intermediate currency. The
+// amount of the second leg is the amount estimated by the first one.
+type Route struct {
+	Legs []RouteLeg
+	// EstimatedAmount is the amount received at the end of the last leg.
+	EstimatedAmount Amount
+}
+
+// Rate returns the compounded rate of the route.
+func (r Route) Rate() Amount {
+	if len(r.Legs) == 0 {
+		return Amount{}
+	}
+	return r.EstimatedAmount.Div(r.Legs[0].Request.Amount, RatePrecision)
+}
+
+// Router finds the routes of a swap on the exchanges of an aggregator, the
+// direct ones and the ones through an intermediate currency, when no exchange
+// swaps the pair or a two legs route is better.
+type Router struct {
+	aggregator    *Aggregator
+	intermediates []CurrencyKey
+}
+
+// NewRouter returns a router on the exchanges of aggregator, the routes go
+// through the intermediates or DefaultIntermediates.
+func NewRouter(aggregator *Aggregator, intermediates ...CurrencyKey) *Router {
+	if len(intermediates) == 0 {
+		intermediates = DefaultIntermediates
+	}
+	return &Router{aggregator: aggregator, intermediates: intermediates}
+}
+
+// Routes returns the routes of vars which amounts are within the limits of
+// every leg, best first. The first leg of the routes through an intermediate
+// currency is the best rate to the intermediate, the second leg is requested
+// from every exchange with its estimated amount.
+func (r *Router) Routes(ctx context.Context, vars ExchangeRateRequest) ([]Route, error) {
+	if vars.Reverse() {
+		return nil, NotSupportedError(ROUTER_NAME, "reverse quote")
+	}
+	var routes []Route
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	add := func(found []Route) {
+		mu.Lock()
+		routes = append(routes, found...)
+		mu.Unlock()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var direct []Route
+		for _, rate := range r.aggregator.Rates(ctx, vars).Rates {
+			if rate.InRange {
+				direct = append(direct, Route{
+					Legs:            []RouteLeg{{Exchange: rate.Exchange, Request: vars, ExchangeRateInfo: rate.ExchangeRateInfo}},
+					EstimatedAmount: rate.estimatedAmount(vars.Amount),
+				})
+			}
+		}
+		add(direct)
+	}()
+	for _, via := range r.intermediates {
+		symbol := CanonicalSymbol(via.Symbol)
+		if symbol == CanonicalSymbol(vars.From) || symbol == CanonicalSymbol(vars.To) {
+			continue
+		}
+		wg.Add(1)
+		go func(via CurrencyKey) {
+			defer wg.Done()
+			add(r.routesVia(ctx, vars, via))
+		}(via)
+	}
+	wg.Wait()
+	if len(routes) == 0 {
+		return nil, NewError(ROUTER_NAME, ErrPairNotSupported,
+			fmt.Sprintf("no route for %s-%s", vars.From, vars.To))
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		ri, rj := &routes[i], &routes[j]
+		if c := ri.EstimatedAmount.Cmp(rj.EstimatedAmount); c != 0 {
+			return c > 0
+		}
+		return len(ri.Legs) < len(rj.Legs)
+	})
+	return routes, nil
+}
+
+// routesVia returns the routes of vars through the currency via.
+func (r *Router) routesVia(ctx context.Context, vars ExchangeRateRequest, via CurrencyKey) []Route {
+	first := ExchangeRateRequest{
+		From:        vars.From,
+		FromNetwork: vars.FromNetwork,
+		To:          via.Symbol,
+		ToNetwork:   via.Network,
+		Amount:      vars.Amount,
+	}
+	var leg *RouteLeg
+	for _, rate := range r.aggregator.Rates(ctx, first).Rates {
+		if rate.InRange && r.carries(rate.Exchange, via) {
+			leg = &RouteLeg{Exchange: rate.Exchange, Request: first, ExchangeRateInfo: rate.ExchangeRateInfo}
+			leg.EstimatedAmount = rate.estimatedAmount(vars.Amount)
+			break
+		}
+	}
+	if leg == nil {
+		return nil
+	}
+	second := ExchangeRateRequest{
+		From:        via.Symbol,
+		FromNetwork: via.Network,
+		To:          vars.To,
+		ToNetwork:   vars.ToNetwork,
+		Amount:      leg.EstimatedAmount.Truncate(CurrencyPrecision(via.Symbol, via.Network)),
+	}
+	if second.Amount.Sign() <= 0 {
+		return nil
+	}
+	var routes []Route
+	for _, rate := range r.aggregator.Rates(ctx, second).Rates {
+		if !rate.InRange || !r.carries(rate.Exchange, via) {
+			continue
+		}
+		routes = append(routes, Route{
+			Legs:            []RouteLeg{*leg, {Exchange: rate.Exchange, Request: second, ExchangeRateInfo: rate.ExchangeRateInfo}},
+			EstimatedAmount: rate.estimatedAmount(second.Amount),
+		})
+	}
+	return routes
+}
+
+// carries tells whether the exchange swaps the currency key on its network.
+// The exchanges which ignore the networks only swap the currencies on their
+// default network.
+func (r *Router) carries(exchange string, key CurrencyKey) bool {
+	if key.Network == "" || CanonicalNetwork(key.Network) == DefaultNetwork(key.Symbol) {
+		return true
+	}
+	return ExchangeCapabilities(r.aggregator.exchanges[exchange]).Networks
+}
+
+// Best returns the best route of vars.
+func (r *Router) Best(ctx context.Context, vars ExchangeRateRequest) (Route, error) {
+	routes, err := r.Routes(ctx, vars)
+	if err != nil {
+		return Route{}, err
+	}
+	return routes[0], nil
+}
+
+// RouteOrder is the order of a route.
+type RouteOrder struct {
+	// Destination receives the currency of the last leg.
+	Destination string
+	ExtraID     string
+	// RefundAddress refunds the currency of the first leg.
+	RefundAddress string
+	// IntermediateRefundAddress refunds the intermediate currency when the
+	// second leg fails.
+	IntermediateRefundAddress string
+}
+
+// RouteOrderResult are the orders created for the legs of a route. The user
+// sends the invoiced amount of the first order to its DepositAddress.
+type RouteOrderResult struct {
+	Orders []CreateResultInfo
+}
+
+// DepositAddress returns the deposit address and extra id of the route.
+func (r RouteOrderResult) DepositAddress() (address, extraID string) {
+	if len(r.Orders) == 0 {
+		return "", ""
+	}
+	return r.Orders[0].DepositAddress, r.Orders[0].ExtraID
+}
+
+// CreateOrder creates the orders of the route, from the last leg to the first
+// one so the destination of every leg is the deposit address of the next one.
+// When a leg fails, the orders already created are cancelled on the
+// exchanges supporting it. The orders are recorded in the order store of the
+// aggregator, the orders are returned along with the error of the store.
+func (r *Router) CreateOrder(ctx context.Context, route Route, order RouteOrder) (res RouteOrderResult, err error) {
+	if len(route.Legs) == 0 {
+		return res, NewError(ROUTER_NAME, nil, "route has no leg")
+	}
+	res.Orders = make([]CreateResultInfo, len(route.Legs))
+	destination, extraID := order.Destination, order.ExtraID
+	var storeErr error
+	for i := len(route.Legs) - 1; i >= 0; i-- {
+		leg := route.Legs[i]
+		exchange, ok := r.aggregator.exchanges[leg.Exchange]
+		if !ok {
+			r.cancel(ctx, route.Legs[i+1:], res.Orders[i+1:])
+			return res, NewError(ROUTER_NAME, nil, fmt.Sprintf("[%s] exchange is not aggregated", leg.Exchange))
+		}
+		refund := order.IntermediateRefundAddress
+		if i == 0 {
+			refund = order.RefundAddress
+		}
+		vars := CreateOrder{
+			RefundAddress:  refund,
+			Destination:    destination,
+			FromCurrency:   leg.Request.From,
+			FromNetwork:    leg.Request.FromNetwork,
+			ToCurrency:     leg.Request.To,
+			ToNetwork:      leg.Request.ToNetwork,
+			InvoicedAmount: leg.Request.Amount,
+			OrderedAmount:  leg.EstimatedAmount,
+			Provider:       leg.Provider,
+			ExtraID:        extraID,
+			Signature:      leg.Signature,
+			RateType:       leg.RateType,
+		}
+		created, err := exchange.CreateOrder(ctx, vars)
+		if err != nil {
+			r.cancel(ctx, route.Legs[i+1:], res.Orders[i+1:])
+			return res, err
+		}
+		res.Orders[i] = created
+		if err = r.aggregator.record(leg.Exchange, vars, created); err != nil && storeErr == nil {
+			storeErr = err
+		}
+		destination, extraID = created.DepositAddress, created.ExtraID
+	}
+	return res, storeErr
+}
+
+// cancel cancels the orders created for the legs, the errors are ignored as
+// the orders expire without deposit anyway.
+func (r *Router) cancel(ctx context.Context, legs []RouteLeg, orders []CreateResultInfo) {
+	for i, leg := range legs {
+		exchange := r.aggregator.exchanges[leg.Exchange]
+		if orders[i].UUID != "" && ExchangeCapabilities(exchange).Cancel {
+			exchange.CancelOrder(ctx, orders[i].UUID)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// routeExchange quotes the pairs of rates, keyed by FROM-TO.
+type routeExchange struct {
+	fakeExchange
+	rates map[string]ExchangeRateInfo
+}
+
+func (r *routeExchange) GetExchangeRateInfo(ctx context.Context, vars ExchangeRateRequest) (ExchangeRateInfo, error) {
+	info, ok := r.rates[vars.From+"-"+vars.To]
+	if !ok {
+		return info, NewError(r.name, ErrPairNotSupported, "pair not supported")
+	}
+	info.EstimatedAmount = vars.Amount.Mul(info.ExchangeRate)
+	info.Signature = r.name + "-" + vars.From + "-" + vars.To
+	return info, nil
+}
+
+func (r *routeExchange) CreateOrder(ctx context.Context, vars CreateOrder) (CreateResultInfo, error) {
+	r.orders = append(r.orders, vars)
+	if r.err != nil {
+		return CreateResultInfo{}, r.err
+	}
+	return CreateResultInfo{
+		UUID:           r.name + "-order",
+		DepositAddress: r.name + "-" + vars.FromCurrency,
+		ExtraID:        r.name + "-memo",
+	}, nil
+}
+
+func TestRouterRoutes(t *testing.T) {
+	a := &routeExchange{fakeExchange: fakeExchange{name: "a"}, rates: map[string]ExchangeRateInfo{
+		"DCR-BTC":  {ExchangeRate: MustParseAmount("0.0005")},
+		"DCR-USDT": {ExchangeRate: NewAmount(30, 0), Min: NewAmount(100, 0), Max: NewAmount(1000, 0)},
+	}}
+	b := &routeExchange{fakeExchange: fakeExchange{name: "b"}, rates: map[string]ExchangeRateInfo{
+		"BTC-XMR":  {ExchangeRate: NewAmount(400, 0), Max: NewAmount(1, 0)},
+		"USDT-XMR": {ExchangeRate: MustParseAmount("0.006")},
+	}}
+	router := NewRouter(NewAggregator(0, a, b))
+
+	routes, err := router.Routes(context.Background(), ExchangeRateRequest{From: "DCR", To: "XMR", Amount: NewAmount(10, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DCR-USDT is below the minimum of a
+	if len(routes) != 1 || len(routes[0].Legs) != 2 {
+		t.Fatalf("routes = %+v", routes)
+	}
+	route := routes[0]
+	if route.Legs[0].Exchange != "a" || route.Legs[1].Exchange != "b" || route.Legs[1].Request.From != "BTC" {
+		t.Errorf("legs = %+v", route.Legs)
+	}
+	if !route.Legs[1].Request.Amount.Equal(MustParseAmount("0.005")) {
+		t.Errorf("second leg amount = %s, expected: 0.005", route.Legs[1].Request.Amount)
+	}
+	if !route.EstimatedAmount.Equal(NewAmount(2, 0)) || !route.Rate().Equal(MustParseAmount("0.2")) {
+		t.Errorf("estimate = %s, rate = %s, expected: 2, 0.2", route.EstimatedAmount, route.Rate())
+	}
+
+	// the second leg through BTC is above the maximum of b, the first leg
+	// through USDT is above the maximum of a
+	_, err = router.Routes(context.Background(), ExchangeRateRequest{From: "DCR", To: "XMR", Amount: NewAmount(3000, 0)})
+	if !errors.Is(err, ErrPairNotSupported) {
+		t.Errorf("Routes above the maximum = %v, expected: %v", err, ErrPairNotSupported)
+	}
+}
+
+func TestRouterCreateOrder(t *testing.T) {
+	a := &routeExchange{fakeExchange: fakeExchange{name: "a"}, rates: map[string]ExchangeRateInfo{
+		"DCR-BTC": {ExchangeRate: MustParseAmount("0.0005")},
+	}}
+	b := &routeExchange{fakeExchange: fakeExchange{name: "b"}, rates: map[string]ExchangeRateInfo{
+		"BTC-XMR": {ExchangeRate: NewAmount(400, 0)},
+	}}
+	aggregator := NewAggregator(0, a, b)
+	router := NewRouter(aggregator)
+	route, err := router.Best(context.Background(), ExchangeRateRequest{From: "DCR", To: "XMR", Amount: NewAmount(10, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := router.CreateOrder(context.Background(), route, RouteOrder{
+		Destination:               "xmr-address",
+		RefundAddress:             "dcr-address",
+		IntermediateRefundAddress: "btc-address",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.orders) != 1 || len(b.orders) != 1 {
+		t.Fatalf("orders = %v, %v", a.orders, b.orders)
+	}
+	first, second := a.orders[0], b.orders[0]
+	if second.Destination != "xmr-address" || second.RefundAddress != "btc-address" || second.Signature != "b-BTC-XMR" {
+		t.Errorf("second leg order = %+v", second)
+	}
+	if first.Destination != "b-BTC" || first.ExtraID != "b-memo" || first.RefundAddress != "dcr-address" {
+		t.Errorf("first leg order = %+v, expected the deposit of the second leg", first)
+	}
+	if address, _ := res.DepositAddress(); address != "a-DCR" {
+		t.Errorf("deposit address = %s, expected: a-DCR", address)
+	}
+	if name, _ := aggregator.OrderExchange("b-order"); name != "b" {
+		t.Errorf("OrderExchange(b-order) = %s, expected: b", name)
+	}
+
+	a.err = errors.New("down")
+	if _, err := router.CreateOrder(context.Background(), route, RouteOrder{Destination: "xmr-address"}); err == nil {
+		t.Error("expected the error of the first leg")
+	}
+}
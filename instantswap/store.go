@@ -0,0 +1,304 @@
+package instantswap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StoredOrder is an order created on an exchange with the request which
+// created it.
+type StoredOrder struct {
+	Exchange  string
+	Request   CreateOrder
+	Result    CreateResultInfo
+	Status    Status
+	History   []StatusChange
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// StatusChange is a status of an order and the time it was seen.
+type StatusChange struct {
+	Status Status
+	Time   time.Time
+}
+
+// OrderStore records the created orders, the orders are identified by their
+// exchange and Result.UUID.
+type OrderStore interface {
+	// Save creates or replaces the order.
+	Save(order StoredOrder) error
+	// Get returns the order, the error is of kind ErrOrderNotFound when the
+	// order is not stored.
+	Get(exchange, orderID string) (StoredOrder, error)
+	// UpdateStatus sets the status of the order and appends it to the
+	// history when it changed.
+	UpdateStatus(exchange, orderID string, status Status, at time.Time) error
+	// List returns all the orders, oldest first.
+	List() ([]StoredOrder, error)
+}
+
+// PendingOrders returns the orders of the store which status is not terminal.
+func PendingOrders(store OrderStore) ([]StoredOrder, error) {
+	orders, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	pending := orders[:0]
+	for _, order := range orders {
+		if !order.Status.IsTerminal() {
+			pending = append(pending, order)
+		}
+	}
+	return pending, nil
+}
+
+// MemoryOrderStore is an OrderStore keeping the orders in memory.
+type MemoryOrderStore struct {
+	mu     sync.RWMutex
+	orders map[string]StoredOrder
+}
+
+func NewMemoryOrderStore() *MemoryOrderStore {
+	return &MemoryOrderStore{orders: make(map[string]StoredOrder)}
+}
+
+func (s *MemoryOrderStore) Save(order StoredOrder) error {
+	if order.CreatedAt.IsZero() {
+		order.CreatedAt = time.Now()
+	}
+	if order.UpdatedAt.IsZero() {
+		order.UpdatedAt = order.CreatedAt
+	}
+	order.History = append([]StatusChange(nil), order.History...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[trackerKey(order.Exchange, order.Result.UUID)] = order
+	return nil
+}
+
+func (s *MemoryOrderStore) Get(exchange, orderID string) (StoredOrder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.orders[trackerKey(exchange, orderID)]
+	if !ok {
+		return order, NewError(exchange, ErrOrderNotFound, "order "+orderID+" is not stored")
+	}
+	order.History = append([]StatusChange(nil), order.History...)
+	return order, nil
+}
+
+func (s *MemoryOrderStore) UpdateStatus(exchange, orderID string, status Status, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := trackerKey(exchange, orderID)
+	order, ok := s.orders[key]
+	if !ok {
+		return NewError(exchange, ErrOrderNotFound, "order "+orderID+" is not stored")
+	}
+	if len(order.History) == 0 || order.History[len(order.History)-1].Status != status {
+		order.History = append(order.History, StatusChange{Status: status, Time: at})
+	}
+	order.Status = status
+	order.UpdatedAt = at
+	s.orders[key] = order
+	return nil
+}
+
+// restore puts back the order as it was before a change, it is deleted when
+// it did not exist.
+func (s *MemoryOrderStore) restore(exchange, orderID string, order StoredOrder, existed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existed {
+		s.orders[trackerKey(exchange, orderID)] = order
+	} else {
+		delete(s.orders, trackerKey(exchange, orderID))
+	}
+}
+
+func (s *MemoryOrderStore) List() ([]StoredOrder, error) {
+	s.mu.RLock()
+	orders := make([]StoredOrder, 0, len(s.orders))
+	for _, order := range s.orders {
+		order.History = append([]StatusChange(nil), order.History...)
+		orders = append(orders, order)
+	}
+	s.mu.RUnlock()
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+		}
+		return trackerKey(orders[i].Exchange, orders[i].Result.UUID) < trackerKey(orders[j].Exchange, orders[j].Result.UUID)
+	})
+	return orders, nil
+}
+
+// FileOrderStore is an OrderStore keeping the orders in a JSON file. The
+// file is rewritten atomically on every change, the change is rolled back
+// when the file could not be written.
+type FileOrderStore struct {
+	path string
+	mu   sync.Mutex
+	mem  *MemoryOrderStore
+}
+
+// NewFileOrderStore returns the store of the file at path, the orders already
+// in the file are loaded. The file is created on the first change.
+func NewFileOrderStore(path string) (*FileOrderStore, error) {
+	s := &FileOrderStore{path: path, mem: NewMemoryOrderStore()}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var orders []StoredOrder
+	if err = json.Unmarshal(data, &orders); err != nil {
+		return nil, errors.New("instantswap:error: " + path + ": " + err.Error())
+	}
+	for _, order := range orders {
+		s.mem.Save(order)
+	}
+	return s, nil
+}
+
+func (s *FileOrderStore) Save(order StoredOrder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, err := s.mem.Get(order.Exchange, order.Result.UUID)
+	s.mem.Save(order)
+	if writeErr := s.write(); writeErr != nil {
+		s.mem.restore(order.Exchange, order.Result.UUID, previous, err == nil)
+		return writeErr
+	}
+	return nil
+}
+
+func (s *FileOrderStore) Get(exchange, orderID string) (StoredOrder, error) {
+	return s.mem.Get(exchange, orderID)
+}
+
+func (s *FileOrderStore) UpdateStatus(exchange, orderID string, status Status, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, err := s.mem.Get(exchange, orderID)
+	if err != nil {
+		return err
+	}
+	s.mem.UpdateStatus(exchange, orderID, status, at)
+	if err = s.write(); err != nil {
+		s.mem.restore(exchange, orderID, previous, true)
+		return err
+	}
+	return nil
+}
+
+func (s *FileOrderStore) List() ([]StoredOrder, error) {
+	return s.mem.List()
+}
+
+// write replaces the file with the orders of the store.
+func (s *FileOrderStore) write() error {
+	orders, _ := s.mem.List()
+	data, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// StoredExchange is an IDExchange recording the orders it creates and their
+// status into an OrderStore.
+type StoredExchange struct {
+	IDExchange
+	store OrderStore
+}
+
+// NewStoredExchange returns exchange recording its orders into store.
+func NewStoredExchange(exchange IDExchange, store OrderStore) *StoredExchange {
+	return &StoredExchange{IDExchange: exchange, store: store}
+}
+
+// Capabilities returns the capabilities of the wrapped exchange.
+func (e *StoredExchange) Capabilities() Capabilities {
+	return ExchangeCapabilities(e.IDExchange)
+}
+
+// Store returns the store of the orders.
+func (e *StoredExchange) Store() OrderStore {
+	return e.store
+}
+
+// CreateOrder creates the order on the exchange and saves it. The order is
+// returned along with the error when it could not be saved.
+func (e *StoredExchange) CreateOrder(ctx context.Context, vars CreateOrder) (res CreateResultInfo, err error) {
+	res, err = e.IDExchange.CreateOrder(ctx, vars)
+	if err != nil {
+		return res, err
+	}
+	now := time.Now()
+	return res, e.store.Save(StoredOrder{
+		Exchange:  e.Name(),
+		Request:   vars,
+		Result:    res,
+		Status:    OrderStatusNew,
+		History:   []StatusChange{{Status: OrderStatusNew, Time: now}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// OrderInfo returns the order info of the exchange and updates the status of
+// the stored order. Orders which are not stored are not recorded.
+func (e *StoredExchange) OrderInfo(ctx context.Context, req TrackingRequest) (res OrderInfoResult, err error) {
+	res, err = e.IDExchange.OrderInfo(ctx, req)
+	if err != nil || res.InternalStatus == OrderStatusUnknown {
+		return res, err
+	}
+	err = e.store.UpdateStatus(e.Name(), req.OrderId, res.InternalStatus, time.Now())
+	if errors.Is(err, ErrOrderNotFound) {
+		err = nil
+	}
+	return res, err
+}
+
+// Resume watches with tracker the pending orders of the store created by
+// the exchange.
+func (e *StoredExchange) Resume(tracker *Tracker) error {
+	orders, err := PendingOrders(e.store)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if order.Exchange != e.Name() {
+			continue
+		}
+		err = tracker.Watch(e, TrackingRequest{OrderId: order.Result.UUID, ExtraId: order.Result.ExtraID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFileOrderStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.json")
+	store, err := NewFileOrderStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchange := NewStoredExchange(&statusExchange{
+		fakeExchange: fakeExchange{name: "fake"},
+		results:      []interface{}{OrderStatusWaitingForDeposit, OrderStatusCompleted},
+	}, store)
+	ctx := context.Background()
+	request := CreateOrder{FromCurrency: "BTC", ToCurrency: "DCR", InvoicedAmount: NewAmount(1, 0), Destination: "Ds1"}
+	res, err := exchange.CreateOrder(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = exchange.OrderInfo(ctx, TrackingRequest{OrderId: res.UUID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = exchange.OrderInfo(ctx, TrackingRequest{OrderId: "unknown"}); err != nil {
+		t.Fatalf("OrderInfo of an order not stored error = %v", err)
+	}
+
+	// the orders are read back from the file.
+	store, err = NewFileOrderStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := store.Get("fake", res.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order.Request, request) || !reflect.DeepEqual(order.Result, res) {
+		t.Errorf("stored order = %+v", order)
+	}
+	if order.Status != OrderStatusWaitingForDeposit || len(order.History) != 2 {
+		t.Errorf("status = %v, history = %v", order.Status, order.History)
+	}
+	pending, err := PendingOrders(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("pending orders = %d, expected: 1", len(pending))
+	}
+
+	// the pending orders are tracked again after a restart.
+	exchange = NewStoredExchange(&statusExchange{
+		fakeExchange: fakeExchange{name: "fake"},
+		results:      []interface{}{OrderStatusCompleted},
+	}, store)
+	tracker := NewTracker(TrackerConfig{MinInterval: time.Millisecond})
+	if err = exchange.Resume(tracker); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-tracker.Events():
+		if event.Status != OrderStatusCompleted {
+			t.Fatalf("event status = %v", event.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the order was not resumed")
+	}
+	tracker.Stop()
+	order, _ = store.Get("fake", res.UUID)
+	if order.Status != OrderStatusCompleted || len(order.History) != 3 {
+		t.Errorf("status = %v, history = %v", order.Status, order.History)
+	}
+	if pending, _ = PendingOrders(store); len(pending) != 0 {
+		t.Errorf("pending orders = %d, expected: 0", len(pending))
+	}
+	if _, err = store.Get("fake", "unknown"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Get of unknown order error = %v", err)
+	}
+}
+
+func TestFileOrderStoreRollback(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewFileOrderStore(filepath.Join(dir, "orders.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := StoredOrder{Exchange: "fake", Result: CreateResultInfo{UUID: "1"}, Status: OrderStatusNew}
+	if err = store.Save(order); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file can not be written once its directory is removed.
+	if err = os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.UpdateStatus("fake", "1", OrderStatusCompleted, time.Now()); err == nil {
+		t.Fatal("UpdateStatus succeeded, expected the write error")
+	}
+	if stored, _ := store.Get("fake", "1"); stored.Status != OrderStatusNew {
+		t.Errorf("status = %v, expected the change to be rolled back", stored.Status)
+	}
+	if err = store.Save(StoredOrder{Exchange: "fake", Result: CreateResultInfo{UUID: "2"}}); err == nil {
+		t.Fatal("Save succeeded, expected the write error")
+	}
+	if _, err = store.Get("fake", "2"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Get of the order not written error = %v, expected: %v", err, ErrOrderNotFound)
+	}
+}
+
+func TestStoredExchangeCapabilities(t *testing.T) {
+	exchange := NewStoredExchange(&quoteExchange{fakeExchange: fakeExchange{name: "fake"}}, NewMemoryOrderStore())
+	if !ExchangeCapabilities(exchange).Cancel {
+		t.Error("the capabilities of the wrapped exchange are hidden")
+	}
+}
@@ -0,0 +1,231 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTrackerMinInterval = 10 * time.Second
+	DefaultTrackerMaxInterval = 5 * time.Minute
+)
+
+var ErrTrackerStopped = errors.New("tracker is stopped")
+
+// OrderEvent is emitted by the Tracker when the status of a watched order
+// changed or when the order could not be polled.
+type OrderEvent struct {
+	Exchange string
+	OrderId  string
+	// Previous is the status before the change, it is OrderStatusUnknown for
+	// the first status of the order.
+	Previous Status
+	Status   Status
+	Info     OrderInfoResult
+	// Err is the error of the poll, the status fields are not set then. Rate
+	// limit errors are not emitted, the tracker backs off instead.
+	Err  error
+	Time time.Time
+}
+
+// TrackerConfig configures a Tracker.
+type TrackerConfig struct {
+	// MinInterval is the interval between the polls of an order after its
+	// status changed. It defaults to DefaultTrackerMinInterval.
+	MinInterval time.Duration
+	// MaxInterval bounds the interval between the polls of an order, which
+	// grows while the status does not change and on errors. It defaults to
+	// DefaultTrackerMaxInterval.
+	MaxInterval time.Duration
+	// OnEvent is called with every event from the polling goroutines. When
+	// it is nil the events are sent on the Events channel.
+	OnEvent func(OrderEvent)
+	// EventsBuffer is the capacity of the Events channel.
+	EventsBuffer int
+}
+
+// Tracker polls OrderInfo of the watched orders and emits an event when their
+// status changes. An order stops being polled once its status is terminal.
+type Tracker struct {
+	conf   TrackerConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	events chan OrderEvent
+
+	mu      sync.Mutex
+	watched map[string]*trackedOrder
+	stopped bool
+}
+
+// NewTracker returns a started tracker, Stop must be called to release it.
+func NewTracker(conf TrackerConfig) *Tracker {
+	if conf.MinInterval <= 0 {
+		conf.MinInterval = DefaultTrackerMinInterval
+	}
+	if conf.MaxInterval <= 0 {
+		conf.MaxInterval = DefaultTrackerMaxInterval
+	}
+	if conf.MaxInterval < conf.MinInterval {
+		conf.MaxInterval = conf.MinInterval
+	}
+	t := &Tracker{
+		conf:    conf,
+		events:  make(chan OrderEvent, conf.EventsBuffer),
+		watched: make(map[string]*trackedOrder),
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t
+}
+
+// Events returns the channel of the events, it is closed by Stop. It does not
+// receive anything when TrackerConfig.OnEvent is set.
+func (t *Tracker) Events() <-chan OrderEvent {
+	return t.events
+}
+
+// Watch starts polling the order of the exchange. Watching an order already
+// watched does nothing.
+func (t *Tracker) Watch(exchange IDExchange, req TrackingRequest) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return ErrTrackerStopped
+	}
+	key := trackerKey(exchange.Name(), req.OrderId)
+	if _, ok := t.watched[key]; ok {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(t.ctx)
+	order := &trackedOrder{cancel: cancel}
+	t.watched[key] = order
+	t.wg.Add(1)
+	go t.poll(ctx, order, key, exchange, req)
+	return nil
+}
+
+// Unwatch stops polling the order of the exchange.
+func (t *Tracker) Unwatch(exchange, orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := trackerKey(exchange, orderID)
+	if order, ok := t.watched[key]; ok {
+		order.cancel()
+		delete(t.watched, key)
+	}
+}
+
+// Watching returns the number of the orders being polled.
+func (t *Tracker) Watching() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.watched)
+}
+
+// Stop stops polling all the orders, waits for the polling goroutines to
+// return and closes the Events channel.
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	t.stopped = true
+	t.mu.Unlock()
+	t.cancel()
+	t.wg.Wait()
+	close(t.events)
+}
+
+func (t *Tracker) poll(ctx context.Context, order *trackedOrder, key string, exchange IDExchange, req TrackingRequest) {
+	defer t.wg.Done()
+	defer t.forget(key, order)
+	var status Status
+	first := true
+	interval := t.conf.MinInterval
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		info, err := exchange.OrderInfo(ctx, req)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			if !errors.Is(err, TooManyRequestsError) {
+				t.emit(ctx, OrderEvent{
+					Exchange: exchange.Name(),
+					OrderId:  req.OrderId,
+					Previous: status,
+					Status:   status,
+					Err:      err,
+					Time:     time.Now(),
+				})
+			}
+			interval = t.backoff(interval * 2)
+		case first || info.InternalStatus != status:
+			t.emit(ctx, OrderEvent{
+				Exchange: exchange.Name(),
+				OrderId:  req.OrderId,
+				Previous: status,
+				Status:   info.InternalStatus,
+				Info:     info,
+				Time:     time.Now(),
+			})
+			if info.InternalStatus.IsTerminal() {
+				return
+			}
+			first = false
+			status = info.InternalStatus
+			interval = t.conf.MinInterval
+		default:
+			interval = t.backoff(interval + interval/2)
+		}
+		timer.Reset(interval)
+	}
+}
+
+// backoff bounds interval to the maximum interval.
+func (t *Tracker) backoff(interval time.Duration) time.Duration {
+	if interval > t.conf.MaxInterval {
+		return t.conf.MaxInterval
+	}
+	return interval
+}
+
+func (t *Tracker) emit(ctx context.Context, event OrderEvent) {
+	if t.conf.OnEvent != nil {
+		t.conf.OnEvent(event)
+		return
+	}
+	select {
+	case t.events <- event:
+	case <-ctx.Done():
+	}
+}
+
+// forget removes the order from the watched orders unless it was watched
+// again after being unwatched.
+func (t *Tracker) forget(key string, order *trackedOrder) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	order.cancel()
+	if t.watched[key] == order {
+		delete(t.watched, key)
+	}
+}
+
+type trackedOrder struct {
+	cancel context.CancelFunc
+}
+
+func trackerKey(exchange, orderID string) string {
+	return exchange + "/" + orderID
+}
@@ -0,0 +1,120 @@
+package instantswap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusExchange answers OrderInfo with the next of its results.
+type statusExchange struct {
+	fakeExchange
+	mu      sync.Mutex
+	results []interface{}
+	calls   int
+}
+
+func (s *statusExchange) OrderInfo(ctx context.Context, req TrackingRequest) (OrderInfoResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if len(s.results) == 0 {
+		return OrderInfoResult{InternalStatus: OrderStatusWaitingForDeposit}, nil
+	}
+	result := s.results[0]
+	s.results = s.results[1:]
+	if err, ok := result.(error); ok {
+		return OrderInfoResult{}, err
+	}
+	return OrderInfoResult{InternalStatus: result.(Status)}, nil
+}
+
+func TestTrackerEvents(t *testing.T) {
+	exchange := &statusExchange{
+		fakeExchange: fakeExchange{name: "fake"},
+		results: []interface{}{
+			OrderStatusNew,
+			OrderStatusNew,
+			TooManyRequestsError,
+			OrderStatusWaitingForDeposit,
+			NewError("fake", ErrExchangeUnavailable, "maintenance"),
+			OrderStatusWaitingForDeposit,
+			OrderStatusCompleted,
+			OrderStatusRefunded,
+		},
+	}
+	tracker := NewTracker(TrackerConfig{MinInterval: time.Millisecond, MaxInterval: 5 * time.Millisecond})
+	if err := tracker.Watch(exchange, TrackingRequest{OrderId: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	type transition struct {
+		previous, status Status
+		failed           bool
+	}
+	expected := []transition{
+		{OrderStatusUnknown, OrderStatusNew, false},
+		{OrderStatusNew, OrderStatusWaitingForDeposit, false},
+		{OrderStatusWaitingForDeposit, OrderStatusWaitingForDeposit, true},
+		{OrderStatusWaitingForDeposit, OrderStatusCompleted, false},
+	}
+	for _, exp := range expected {
+		select {
+		case event := <-tracker.Events():
+			if event.Exchange != "fake" || event.OrderId != "1" {
+				t.Fatalf("event of %s/%s", event.Exchange, event.OrderId)
+			}
+			if event.Previous != exp.previous || event.Status != exp.status || (event.Err != nil) != exp.failed {
+				t.Fatalf("event = %v -> %v (%v), expected: %v -> %v", event.Previous, event.Status, event.Err, exp.previous, exp.status)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event, expected: %v -> %v", exp.previous, exp.status)
+		}
+	}
+	tracker.Stop()
+	if event, ok := <-tracker.Events(); ok {
+		t.Fatalf("unexpected event after the terminal status: %v", event.Status)
+	}
+	if len(exchange.results) != 1 {
+		t.Errorf("the order was polled after the terminal status")
+	}
+}
+
+func TestTrackerStop(t *testing.T) {
+	var mu sync.Mutex
+	var events []OrderEvent
+	tracker := NewTracker(TrackerConfig{
+		MinInterval: time.Millisecond,
+		OnEvent: func(event OrderEvent) {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		},
+	})
+	for _, id := range []string{"1", "2", "2"} {
+		exchange := &statusExchange{fakeExchange: fakeExchange{name: "fake"}}
+		if err := tracker.Watch(exchange, TrackingRequest{OrderId: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := tracker.Watching(); n != 2 {
+		t.Fatalf("Watching = %d, expected: 2", n)
+	}
+	tracker.Unwatch("fake", "1")
+	if n := tracker.Watching(); n != 1 {
+		t.Fatalf("Watching = %d, expected: 1", n)
+	}
+	tracker.Stop()
+	tracker.Stop()
+	if n := tracker.Watching(); n != 0 {
+		t.Errorf("Watching = %d after Stop", n)
+	}
+	err := tracker.Watch(&statusExchange{fakeExchange: fakeExchange{name: "fake"}}, TrackingRequest{OrderId: "3"})
+	if !errors.Is(err, ErrTrackerStopped) {
+		t.Errorf("Watch after Stop error = %v", err)
+	}
+	if _, ok := <-tracker.Events(); ok {
+		t.Errorf("events were sent on the channel with OnEvent set")
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -8,8 +9,17 @@ import (
 	"strings"
 )
 
-func GetPublicIP() (ip string, err error) {
-	resp, err := http.Get("https://myexternalip.com/raw")
+// GetPublicIP returns the public ip address of the running machine. The
+// request is sent by client, http.DefaultClient is used when it is nil.
+func GetPublicIP(ctx context.Context, client *http.Client) (ip string, err error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://myexternalip.com/raw", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
 	}